@@ -4,43 +4,160 @@ Copyright © 2026 JACOB ARTHURS
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/jacobarthurs/pgplan/internal/advisor"
 	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/batch"
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/hypoindex"
 	"github.com/jacobarthurs/pgplan/internal/output"
 	"github.com/jacobarthurs/pgplan/internal/plan"
-	"github.com/jacobarthurs/pgplan/internal/profile"
 
 	"github.com/spf13/cobra"
 )
 
 var analyzeCmd = &cobra.Command{
-	Use:   "analyze [file]",
-	Short: "Analyze a single query plan",
-	Long: `Analyze a single PostgreSQL query plan and provide optimization insights.
+	Use:   "analyze [file|dir|glob]...",
+	Short: "Analyze a query plan",
+	Long: `Analyze PostgreSQL query plans and provide optimization insights.
 
-Input can be a SQL file, or JSON file (EXPLAIN output).
+Input can be a SQL file, or EXPLAIN output in JSON, YAML, XML or text format.
 Use "-" to read from stdin. If no file is provided, enters interactive mode.
 
-For SQL input, a database connection is required to run EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON).`,
+For SQL input, a database connection is required to run EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON).
+With --estimate, plain EXPLAIN (VERBOSE, FORMAT JSON) is run instead: the query is
+planned but not executed, and findings are based on planner estimates.
+Every statement in the input is analyzed. Statements that can't be explained
+(SET, CREATE TEMP TABLE, ...) are run in the same transaction first, so later
+statements see their effects. With more than one plan, findings are reported
+per statement, followed by a combined summary.
+
+Statements may use $1, $2, ... placeholders. Give their values in order with
+--param, or in a --params file: JSON/YAML values, or CSV with one parameter set
+per row, each run separately. Without values, those statements are planned with
+EXPLAIN (GENERIC_PLAN) (PostgreSQL 16+) and not executed.
+
+To reproduce another environment, --set name=value (and the profile's settings)
+sets configuration parameters such as work_mem, random_page_cost, search_path,
+role or jit first, and --setup runs a SQL file (SET, CREATE TEMP TABLE, ...)
+after them, all in the transaction EXPLAIN runs in. The settings in effect are
+reported with each plan.
+
+--timeout bounds each statement: it is set as statement_timeout, and a statement
+that runs longer is canceled and reported as timed out. Ctrl-C cancels the
+running query on the server as well, instead of leaving it behind.
+
+Statements that modify data or schema (INSERT, UPDATE, DELETE, MERGE, writable
+CTEs, SELECT ... FOR UPDATE, DDL, CALL, DO) are refused, and so are queries
+calling known functions with side effects (nextval, setval, dblink_exec,
+pg_advisory_lock, ...): even though the transaction is rolled back, sequences
+advance, triggers fire and locks are taken. A user-defined function that writes
+can't be told apart from one that doesn't, so calling one counts as a read.
+Use --estimate to plan them without running, or --allow-writes (or allow_writes
+in the profile) to run them anyway. --read-only (or read_only in the profile)
+runs the transaction as READ ONLY.
+
+As a CI check, --fail-on fails on findings at or above a severity, and
+--max-time, --max-cost, --max-reads and --max-buffers set budgets each
+statement must stay within. pgplan then exits with code 2 when the gate fails,
+and 1 when pgplan itself fails; the JSON output reports the gate's outcome.
+
+Given several files, a directory or a glob pattern, analyze runs in batch mode:
+directories are searched recursively for .sql and plan files, and --jobs files
+are analyzed at a time, each on its own connection. Each file's findings are
+printed as it completes, followed by a summary of the whole batch: the most
+frequent rules, the most affected relations and the slowest statements. A file
+that fails is reported without stopping the batch, and pgplan then exits with
+code 1. The JSON output is a single document written at the end.
+
+With --hypothetical, every index the findings suggest is then created
+hypothetically with the hypopg extension, one at a time, and the SQL file is
+planned again with plain EXPLAIN, to show whether the planner would pick the
+index and how the estimated cost changes, without building anything. The
+database needs hypopg installed (CREATE EXTENSION hypopg).
+
+--index-script consolidates the indexes the findings suggest, across every
+statement or, in batch mode, every file: identical suggestions are merged, and
+so is an index whose key columns lead another's. The candidates are ranked by
+the time of the nodes they would speed up (or their cost without ANALYZE
+data), listed after the report, and written to the given file as a CREATE
+INDEX CONCURRENTLY script to review before running.
+
+For SQL input, the catalog entries of the relations the plans scan are read
+from the same database: their indexes (pg_indexes), column statistics
+(pg_stats), sizes (pg_class) and activity (pg_stat_user_tables). Rules then
+point out an existing index the planner didn't choose instead of suggesting it
+again, and report statistics gone stale and tables full of dead tuples.
+--no-catalog skips this. A plan file may have come from another database, so
+its catalog is only read with --catalog, from the database connected to. If
+the catalog can't be read, a warning is printed and the analysis goes on
+without it.`,
 	Example: `  # Analyze from file
   pgplan analyze query.sql
 
+  # Analyze every statement in a script
+  pgplan analyze report.sql --profile staging
+
   # Use saved profile
   pgplan analyze query.sql --profile prod
 
+  # Plan without executing (safe for long reports and DML)
+  pgplan analyze report.sql --profile prod --estimate
+
+  # Bind $1 and $2 of a query captured from the application
+  pgplan analyze orders-by-status.sql --param 42 --param shipped
+
+  # One run per row of a CSV of parameter sets
+  pgplan analyze orders-by-status.sql --params params.csv
+
+  # Give up on any statement that takes more than 30 seconds
+  pgplan analyze report.sql --profile staging --timeout 30s
+
+  # Plan with production's memory settings and without JIT
+  pgplan analyze report.sql --set work_mem=256MB --set jit=off
+
+  # Profile an UPDATE against a scratch database
+  pgplan analyze backfill.sql --profile dev --allow-writes
+
+  # Fail a CI job on critical findings or a slow query
+  pgplan analyze query.sql --profile ci --fail-on critical --max-time 500ms
+
+  # Would the planner use the indexes the findings suggest?
+  pgplan analyze slow.sql --profile staging --hypothetical
+
+  # Collect the indexes a directory of queries asks for into one script
+  pgplan analyze queries/ --profile staging --index-script indexes.sql
+
+  # Analyze a plan captured on staging with staging's catalog
+  pgplan analyze staging-plan.json --profile staging --catalog
+
+  # Analyze every query under reports/, 8 at a time
+  pgplan analyze reports/ --profile staging --estimate --jobs 8
+
+  # Analyze files matching a pattern
+  pgplan analyze 'reports/daily-*.sql' --profile staging
+
   # Read from stdin
   cat query.sql | pgplan analyze -
 
   # Interactive mode
   pgplan analyze`,
-	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		db, _ := cmd.Flags().GetString("db")
-		profileName, _ := cmd.Flags().GetString("profile")
 		format, _ := cmd.Flags().GetString("format")
 		blockSize, _ := cmd.Flags().GetInt64("block-size")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		top, _ := cmd.Flags().GetInt("top")
+		hypothetical, _ := cmd.Flags().GetBool("hypothetical")
+		indexScript, _ := cmd.Flags().GetString("index-script")
+		noCatalog, _ := cmd.Flags().GetBool("no-catalog")
+		planCatalog, _ := cmd.Flags().GetBool("catalog")
 
 		if format != "text" && format != "json" {
 			return fmt.Errorf("invalid output format %q: must be \"text\" or \"json\"", format)
@@ -50,31 +167,136 @@ For SQL input, a database connection is required to run EXPLAIN (ANALYZE, BUFFER
 			return fmt.Errorf("block-size must be positive, got %d", blockSize)
 		}
 
-		connStr, err := profile.ResolveConnStr(db, profileName)
+		if jobs < 1 {
+			return fmt.Errorf("jobs must be at least 1, got %d", jobs)
+		}
+
+		if top < 0 {
+			return fmt.Errorf("top must be non-negative, got %d", top)
+		}
+
+		budget, err := analysisBudget(cmd)
+		if err != nil {
+			return err
+		}
+
+		connStr, execOpts, err := resolveConnection(cmd)
 		if err != nil {
 			return err
 		}
 
+		ctx, stop := interruptible(cmd)
+		defer stop()
+
+		if batch.IsBatch(args) {
+			if hypothetical {
+				return fmt.Errorf("--hypothetical checks one SQL file at a time")
+			}
+			files, err := batch.Expand(args)
+			if err != nil {
+				return err
+			}
+			return analyzeBatch(ctx, files, batch.Options{
+				DBConn:      connStr,
+				Exec:        execOpts,
+				BlockSize:   blockSize,
+				Catalog:     !noCatalog,
+				PlanCatalog: planCatalog,
+				Jobs:        jobs,
+			}, format, top, budget, indexScript)
+		}
+
 		var file string
 		if len(args) > 0 {
 			file = args[0]
 		}
 
-		planOutput, err := plan.Resolve(file, connStr, "")
+		if hypothetical {
+			if !strings.EqualFold(filepath.Ext(file), ".sql") {
+				return fmt.Errorf("--hypothetical needs a .sql file to plan again")
+			}
+			if connStr == "" {
+				return fmt.Errorf("--hypothetical requires a database connection")
+			}
+		}
+
+		planOutputs, err := plan.ResolveAll(ctx, file, connStr, "", execOpts)
 		if err != nil {
 			return err
 		}
 
-		result := analyzer.Analyze(planOutput, blockSize)
+		analysis := analyzer.Options{BlockSize: blockSize}
+		if connStr != "" && !noCatalog && (planCatalog || slices.ContainsFunc(planOutputs, func(p plan.ExplainOutput) bool { return p.FromSQL })) {
+			if analysis.Catalog, err = plan.LoadCatalog(ctx, connStr, planOutputs, execOpts); err != nil {
+				fmt.Fprintln(os.Stderr, "Warning: catalog not loaded:", err)
+			}
+		}
+
+		// A single plan goes through the same path as a script, and is
+		// reported on its own, without statement numbers or a summary.
+		result := analyzer.AnalyzeAllWith(planOutputs, analysis)
+		single := len(result.Statements) == 1
+
+		var gateResult *gate.Result
+		if budget != nil {
+			var r gate.Result
+			if single {
+				r = budget.Check(result.Statements[0].Result)
+			} else {
+				r = budget.CheckAll(result)
+			}
+			gateResult = &r
+		}
+
+		var hypo *hypoindex.Report
+		if hypothetical {
+			if hypo, err = hypotheticalIndexes(ctx, file, connStr, execOpts, result.Statements); err != nil {
+				return err
+			}
+		}
+
+		var advice *advisor.Advice
+		if indexScript != "" {
+			a := advisor.Statements(result.Statements)
+			if single {
+				a = advisor.Analysis(result.Statements[0].Result)
+			}
+			if advice, err = writeIndexScript(indexScript, a); err != nil {
+				return err
+			}
+		}
 
 		switch format {
 		case "json":
-			return output.RenderJSON(os.Stdout, result)
+			extras := analyzeExtras{analysis.Catalog, hypo, advice, gateResult}
+			if single {
+				err = output.RenderJSON(os.Stdout, struct {
+					analyzer.AnalysisResult
+					analyzeExtras
+				}{result.Statements[0].Result, extras})
+			} else {
+				err = output.RenderJSON(os.Stdout, struct {
+					analyzer.MultiAnalysisResult
+					analyzeExtras
+				}{result, extras})
+			}
 		case "text":
-			return output.RenderAnalysisText(os.Stdout, result, blockSize)
+			if single {
+				err = output.RenderAnalysisText(os.Stdout, result.Statements[0].Result, blockSize)
+			} else {
+				err = output.RenderMultiAnalysisText(os.Stdout, result, blockSize)
+			}
+			if err == nil && hypo != nil {
+				err = output.RenderHypotheticalIndexesText(os.Stdout, *hypo)
+			}
+			if err == nil && advice != nil {
+				err = output.RenderIndexAdviceText(os.Stdout, *advice, indexScript)
+			}
 		}
-
-		return nil
+		if err != nil {
+			return err
+		}
+		return finishGate(format, gateResult)
 	},
 }
 
@@ -83,6 +305,120 @@ func init() {
 	analyzeCmd.Flags().StringP("db", "d", "", "PostgreSQL connection string")
 	analyzeCmd.Flags().StringP("profile", "p", "", "Use named profile from config")
 	analyzeCmd.Flags().StringP("format", "f", "text", "Output format: text, json")
+	addExecFlags(analyzeCmd)
+	analyzeCmd.Flags().String("fail-on", "", "Exit with code 2 if any finding is at or above this severity: info, warning, critical")
+	analyzeCmd.Flags().Duration("max-time", 0, "Exit with code 2 if a statement's execution time exceeds this, e.g. 500ms")
+	analyzeCmd.Flags().Float64("max-cost", 0, "Exit with code 2 if a statement's total cost exceeds this")
+	analyzeCmd.Flags().Int64("max-reads", 0, "Exit with code 2 if a statement reads more than this many blocks")
+	analyzeCmd.Flags().Int64("max-buffers", 0, "Exit with code 2 if a statement reads or hits more than this many blocks")
+	analyzeCmd.Flags().Bool("hypothetical", false, "Create each suggested index hypothetically with hypopg and plan the SQL file again, to see whether the planner would use it")
+	analyzeCmd.Flags().String("index-script", "", "Write the indexes the findings suggest, merged and ranked, to this file as a CREATE INDEX CONCURRENTLY script")
+	analyzeCmd.Flags().Bool("no-catalog", false, "Don't read the indexes and statistics of the plans' relations from the database")
+	analyzeCmd.Flags().Bool("catalog", false, "Read the indexes and statistics of a plan file's relations too, from the database connected to")
+	analyzeCmd.Flags().IntP("jobs", "j", 4, "In batch mode, number of files analyzed at a time, each on its own connection")
+	analyzeCmd.Flags().IntP("top", "n", 10, "In batch mode, number of rules, relations and statements to list in the summary (0 for all)")
 	analyzeCmd.Flags().Int64("block-size", 8192, "PostgreSQL page size in bytes, used to show block counts as human-readable sizes")
 	analyzeCmd.MarkFlagsMutuallyExclusive("db", "profile")
+	analyzeCmd.MarkFlagsMutuallyExclusive("catalog", "no-catalog")
+}
+
+// analyzeExtras are the parts of analyze's JSON output beyond the analysis
+// itself, each present only when asked for.
+type analyzeExtras struct {
+	Catalog      *plan.Catalog     `json:",omitempty"`
+	Hypothetical *hypoindex.Report `json:",omitempty"`
+	IndexAdvice  *advisor.Advice   `json:",omitempty"`
+	Gate         *gate.Result      `json:",omitempty"`
+}
+
+// analyzeBatch analyzes files in batch mode, printing each file's result as
+// it completes in text format, then the batch summary.
+func analyzeBatch(ctx context.Context, files []string, opts batch.Options, format string, top int, budget *gate.Budget, indexScript string) error {
+	var streamErr error
+	report := batch.Run(ctx, files, opts, func(r batch.FileResult) {
+		if format == "text" && streamErr == nil {
+			streamErr = output.RenderBatchFileText(os.Stdout, r)
+		}
+	})
+	if streamErr != nil {
+		return streamErr
+	}
+
+	var gateResult *gate.Result
+	if budget != nil {
+		r := budget.CheckBatch(report.Files)
+		gateResult = &r
+	}
+
+	var advice *advisor.Advice
+	if indexScript != "" {
+		var err error
+		if advice, err = writeIndexScript(indexScript, advisor.Batch(report)); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch format {
+	case "json":
+		s := &report.Summary
+		s.Rules = truncate(s.Rules, top)
+		s.Relations = truncate(s.Relations, top)
+		s.Slowest = truncate(s.Slowest, top)
+		err = output.RenderJSON(os.Stdout, struct {
+			batch.Report
+			IndexAdvice *advisor.Advice `json:",omitempty"`
+			Gate        *gate.Result    `json:",omitempty"`
+		}{report, advice, gateResult})
+	case "text":
+		err = output.RenderBatchReportText(os.Stdout, report, top)
+		if err == nil && advice != nil {
+			err = output.RenderIndexAdviceText(os.Stdout, *advice, indexScript)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	s := report.Summary
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w after %d of %d files", plan.ErrInterrupted, s.Files, len(files))
+	}
+	if s.Failed > 0 {
+		if format == "text" && gateResult != nil {
+			if err := output.RenderGateText(os.Stdout, *gateResult); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("%d of %d files failed", s.Failed, s.Files)
+	}
+	return finishGate(format, gateResult)
+}
+
+// writeIndexScript writes advice's script to path, as --index-script asks,
+// and returns the advice to report.
+func writeIndexScript(path string, advice advisor.Advice) (*advisor.Advice, error) {
+	if err := os.WriteFile(path, []byte(advice.Script), 0o644); err != nil {
+		return nil, fmt.Errorf("writing index script: %w", err)
+	}
+	return &advice, nil
+}
+
+// hypotheticalIndexes tries the indexes the analysis of file suggests, as
+// --hypothetical asks.
+func hypotheticalIndexes(ctx context.Context, file, connStr string, execOpts plan.ExecOptions, statements []analyzer.StatementResult) (*hypoindex.Report, error) {
+	// The same threshold compare defaults to.
+	c := &comparator.Comparator{Threshold: 5}
+	report, err := hypoindex.Run(ctx, hypoindex.Options{File: file, DBConn: connStr, Exec: execOpts}, statements, c)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func truncate[T any](items []T, top int) []T {
+	if top > 0 && len(items) > top {
+		return items[:top]
+	}
+	return items
 }
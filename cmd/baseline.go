@@ -0,0 +1,171 @@
+/*
+Copyright © 2026 JACOB ARTHURS
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jacobarthurs/pgplan/internal/baseline"
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Save known-good plans and check new ones against them",
+	Long: `Keep a baseline of known-good plans for a directory of SQL files, for example
+the critical queries of an application, and check fresh plans against it.
+
+"pgplan baseline save" plans every statement of every .sql file under the
+directory and writes the plans to the baseline directory (--dir), keyed by file
+and statement, ready to commit. "pgplan baseline check" plans them again and
+compares each with its baseline plan.`,
+}
+
+var baselineSaveCmd = &cobra.Command{
+	Use:   "save <sql-dir>",
+	Short: "Plan every SQL file in a directory and save the plans as the baseline",
+	Long: `Plan every statement of every .sql file under sql-dir and save the plans as
+the baseline in --dir, replacing any baseline there.
+
+The SQL files are run as by "pgplan analyze", with the same safety checks and
+execution flags. Use --estimate for a baseline of plans that doesn't depend on
+timings, which vary between machines.`,
+	Example: `  # Save a baseline of the queries in queries/
+  pgplan baseline save queries --profile staging
+
+  # Save plans only, without running the queries
+  pgplan baseline save queries --profile staging --estimate --dir .pgplan-baseline`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+
+		connStr, execOpts, err := resolveConnection(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := interruptible(cmd)
+		defer stop()
+
+		manifest, err := baseline.Save(ctx, baseline.Options{
+			SQLDir:      args[0],
+			BaselineDir: dir,
+			DBConn:      connStr,
+			Exec:        execOpts,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Saved %d plan(s) to %s\n", len(manifest.Entries), dir)
+		return nil
+	},
+}
+
+var baselineCheckCmd = &cobra.Command{
+	Use:   "check <sql-dir>",
+	Short: "Plan every SQL file in a directory again and check the plans against the baseline",
+	Long: `Plan every statement of every .sql file under sql-dir again and compare each
+plan with its baseline plan in --dir.
+
+A statement fails when the planner chose a different plan shape (other node
+types, relations or indexes), even if its timing is within --threshold, or when
+it regressed: by default, when execution time or cost got worse by more than
+--threshold; set --max-time-regression, --max-cost-regression or
+--max-reads-regression to allow more. Statements without a baseline plan, and
+baseline plans whose statement is gone, fail too.
+
+pgplan prints one report for every statement and exits with code 2 if any
+failed, or 1 if pgplan itself failed.`,
+	Example: `  # Check a pull request's queries against the committed baseline
+  pgplan baseline check queries --profile ci
+
+  # Allow up to 20% slower execution, and report as JSON
+  pgplan baseline check queries --profile ci --max-time-regression 20 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		format, _ := cmd.Flags().GetString("format")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		alpha, _ := cmd.Flags().GetFloat64("alpha")
+
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid output format %q: must be \"text\" or \"json\"", format)
+		}
+
+		if threshold < 0 || threshold > 100 {
+			return fmt.Errorf("threshold must be between 0 and 100, got %.2f", threshold)
+		}
+
+		if alpha <= 0 || alpha >= 1 {
+			return fmt.Errorf("alpha must be between 0 and 1, got %g", alpha)
+		}
+
+		limits, err := comparisonLimits(cmd)
+		if err != nil {
+			return err
+		}
+		if limits == nil {
+			zero := 0.0
+			limits = &gate.Limits{Time: &zero, Cost: &zero, IgnoreUnknown: true}
+		}
+
+		connStr, execOpts, err := resolveConnection(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := interruptible(cmd)
+		defer stop()
+
+		report, err := baseline.Check(ctx, baseline.Options{
+			SQLDir:      args[0],
+			BaselineDir: dir,
+			DBConn:      connStr,
+			Exec:        execOpts,
+		}, &comparator.Comparator{Threshold: threshold, Alpha: alpha}, *limits)
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "json":
+			err = output.RenderJSON(os.Stdout, report)
+		case "text":
+			err = output.RenderBaselineReportText(os.Stdout, report)
+		}
+		if err != nil {
+			return err
+		}
+		if !report.Passed {
+			return &gateError{violations: report.Failed}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(baselineCmd)
+	baselineCmd.AddCommand(baselineSaveCmd, baselineCheckCmd)
+
+	for _, c := range []*cobra.Command{baselineSaveCmd, baselineCheckCmd} {
+		c.Flags().StringP("db", "d", "", "PostgreSQL connection string")
+		c.Flags().StringP("profile", "p", "", "Use named profile from config")
+		c.Flags().String("dir", ".pgplan-baseline", "Baseline directory")
+		addExecFlags(c)
+		c.MarkFlagsMutuallyExclusive("db", "profile")
+	}
+
+	baselineCheckCmd.Flags().StringP("format", "f", "text", "Output format: text, json")
+	baselineCheckCmd.Flags().Float64P("threshold", "t", 5.0, "Percent change threshold for significance (default 5%)")
+	baselineCheckCmd.Flags().Float64("alpha", comparator.DefaultAlpha, "Significance level for timings compared across several runs per side")
+	baselineCheckCmd.Flags().Float64("max-time-regression", 0, "Fail if execution time regresses by more than this percent (default: any regression beyond --threshold)")
+	baselineCheckCmd.Flags().Float64("max-cost-regression", 0, "Fail if total cost regresses by more than this percent (default: any regression beyond --threshold)")
+	baselineCheckCmd.Flags().Float64("max-reads-regression", 0, "Fail if blocks read grow by more than this percent")
+}
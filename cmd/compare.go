@@ -4,10 +4,16 @@ Copyright © 2026 JACOB ARTHURS
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"sync"
 
 	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/gitrev"
 	"github.com/jacobarthurs/pgplan/internal/output"
 	"github.com/jacobarthurs/pgplan/internal/plan"
 	"github.com/jacobarthurs/pgplan/internal/profile"
@@ -20,11 +26,47 @@ var compareCmd = &cobra.Command{
 	Short: "Compare two query plans",
 	Long: `Compare two PostgreSQL query plans side-by-side with semantic understanding.
 
-Inputs can be SQL files, or JSON files (EXPLAIN output).
+Inputs can be SQL files, or EXPLAIN output in JSON, YAML, XML or text format.
 Files don't need to be the same type. Either file (but not both) can be "-" to read from stdin.
 If no files are provided, enters interactive mode.
 
-For SQL input, a database connection is required to run EXPLAIN (ANALYZE, VERBOSE, BUFFERS, FORMAT JSON).`,
+For SQL input, a database connection is required to run EXPLAIN (ANALYZE, VERBOSE, BUFFERS, FORMAT JSON).
+With --estimate, plain EXPLAIN (VERBOSE, FORMAT JSON) is run instead. Whenever either plan
+lacks ANALYZE data, only planner cost and estimated rows are compared.
+Statements that modify data or schema are refused unless --allow-writes (or
+allow_writes in the profile) is given. $1, $2, ... placeholders take the values
+of --param or --params in both inputs, and --set, --setup and --timeout apply
+to both as well; see "pgplan analyze --help".
+
+When an input contains several statements, the first is compared by default.
+Use --statement to pick another, or --pair to compare every statement one to one.
+With --pair, the JSON output is an object holding the statements, with the gate
+and the --git diff alongside when there are any.
+
+To compare distributions rather than single runs, give several plan files per
+side with --old and --new, or run SQL input repeatedly with --runs. Each side is
+then aggregated to its median plan, and execution and per-node times are
+compared with a Mann-Whitney U test: a difference only counts when it is both
+beyond --threshold and significant at --alpha, and is otherwise reported as
+within noise.
+
+To find out why a query is fast on one database and slow on another, give two
+--profile (or two --db) values and one SQL file: it runs against both at once,
+and the sides are named after the profiles instead of "old" and "new". Each
+side uses its own profile's settings. With two SQL files, the first runs
+against the first database and the second against the second.
+
+To review a change to a query kept in git, give one file and a revision range
+with --git, such as main..HEAD: the file is read as it was at both revisions
+from the local repository, and the plan diff is shown after a unified diff of
+the SQL. With main...HEAD, the old side is the commit HEAD branched off main
+at, as in a pull request.
+
+As a CI check, --max-time-regression, --max-cost-regression and
+--max-reads-regression fail when that metric regresses by more than the given
+percent (0 fails on any regression beyond --threshold). pgplan then exits with
+code 2, and 1 when pgplan itself fails; the JSON output reports the gate's
+outcome. With --pair, every statement is checked.`,
 	Example: `  # Compare two SQL files
   pgplan compare old.sql new.sql
 
@@ -34,6 +76,28 @@ For SQL input, a database connection is required to run EXPLAIN (ANALYZE, VERBOS
   # Mix input types
   pgplan compare prod-plan.json new-query.sql
 
+  # Compare the third statement of two scripts
+  pgplan compare old.sql new.sql --statement 3
+
+  # Compare two scripts statement by statement
+  pgplan compare old.sql new.sql --pair
+
+  # Compare several captured runs per side
+  pgplan compare --old before-1.json --old before-2.json --old before-3.json \
+    --new after-1.json --new after-2.json --new after-3.json
+
+  # Benchmark both versions and test the difference for significance
+  pgplan compare old.sql new.sql --runs 10 --warmup 2
+
+  # Fail a CI job if the new version is more than 10% slower
+  pgplan compare main.sql branch.sql --runs 10 --max-time-regression 10
+
+  # Compare the same query on staging and production
+  pgplan compare query.sql --profile staging --profile prod
+
+  # Review a branch's change to a query
+  pgplan compare queries/orders.sql --git main...HEAD --profile staging
+
   # Read one plan from stdin
   cat old.sql |  pgplan compare - new.sql
 
@@ -41,11 +105,15 @@ For SQL input, a database connection is required to run EXPLAIN (ANALYZE, VERBOS
   pgplan compare`,
 	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		db, _ := cmd.Flags().GetString("db")
-		profileName, _ := cmd.Flags().GetString("profile")
 		format, _ := cmd.Flags().GetString("format")
 		threshold, _ := cmd.Flags().GetFloat64("threshold")
 		blockSize, _ := cmd.Flags().GetInt64("block-size")
+		statement, _ := cmd.Flags().GetInt("statement")
+		pair, _ := cmd.Flags().GetBool("pair")
+		alpha, _ := cmd.Flags().GetFloat64("alpha")
+		oldInputs, _ := cmd.Flags().GetStringArray("old")
+		newInputs, _ := cmd.Flags().GetStringArray("new")
+		gitSpec, _ := cmd.Flags().GetString("git")
 
 		if format != "text" && format != "json" {
 			return fmt.Errorf("invalid output format %q: must be \"text\" or \"json\"", format)
@@ -59,55 +127,310 @@ For SQL input, a database connection is required to run EXPLAIN (ANALYZE, VERBOS
 			return fmt.Errorf("threshold must be <= 100%%, got %.2f", threshold)
 		}
 
+		if alpha <= 0 || alpha >= 1 {
+			return fmt.Errorf("alpha must be between 0 and 1, got %g", alpha)
+		}
+
+		if len(oldInputs) > 0 || len(newInputs) > 0 {
+			if len(args) > 0 {
+				return fmt.Errorf("give the plans either as arguments or with --old and --new, not both")
+			}
+			if len(oldInputs) == 0 || len(newInputs) == 0 {
+				return fmt.Errorf("--old and --new must be given together")
+			}
+		}
+
 		if blockSize <= 0 {
 			return fmt.Errorf("block-size must be positive, got %d", blockSize)
 		}
 
-		connStr, err := profile.ResolveConnStr(db, profileName)
+		if statement < 1 {
+			return fmt.Errorf("statement must be at least 1, got %d", statement)
+		}
+
+		limits, err := comparisonLimits(cmd)
+		if err != nil {
+			return err
+		}
+
+		targets, err := connectionTargets(cmd)
 		if err != nil {
 			return err
 		}
 
-		var oldFile string
-		if len(args) > 0 {
-			oldFile = args[0]
+		oldSide := compareSide{inputs: oldInputs, label: "old plan"}
+		newSide := compareSide{inputs: newInputs, label: "new plan"}
+		for i, side := range []*compareSide{&oldSide, &newSide} {
+			target := targets[min(i, len(targets)-1)]
+			side.connStr = target.ConnStr
+			if side.opts, err = execOptions(cmd, target); err != nil {
+				return err
+			}
+			if len(targets) > 1 {
+				side.name = connectionLabel(target)
+				side.label = side.name
+			}
+		}
+
+		var revisions gitrev.Range
+		if gitSpec != "" {
+			if revisions, err = gitrev.ParseRange(gitSpec); err != nil {
+				return err
+			}
+			switch {
+			case len(oldInputs) > 0:
+				return fmt.Errorf("--old and --new can't be combined with --git")
+			case len(targets) > 1:
+				return fmt.Errorf("--git compares one file across two revisions on one database; give one --db or --profile")
+			case len(args) != 1 || args[0] == "-":
+				return fmt.Errorf("give the file to read at both revisions of %s", revisions)
+			}
 		}
 
-		var newFile string
-		if len(args) > 1 {
-			newFile = args[1]
+		if len(targets) > 1 {
+			switch {
+			case len(oldInputs) > 0:
+				return fmt.Errorf("--old and --new can't be combined with two --db or --profile values")
+			case len(args) == 0:
+				return fmt.Errorf("give the SQL file to run against both databases")
+			case slices.Contains(args, "-"):
+				return fmt.Errorf("stdin can't be read for both databases; give the SQL as a file")
+			}
+			// One file runs against both databases; two run against one each.
+			args = []string{args[0], args[len(args)-1]}
 		}
 
-		oldPlanOutput, err := plan.Resolve(oldFile, connStr, "old plan ")
+		if len(oldSide.inputs) == 0 {
+			oldSide.inputs = []string{""}
+			if len(args) > 0 {
+				oldSide.inputs[0] = args[0]
+			}
+		}
+
+		if len(newSide.inputs) == 0 {
+			newSide.inputs = []string{""}
+			if len(args) > 1 {
+				newSide.inputs[0] = args[1]
+			}
+		}
+
+		ctx, stop := interruptible(cmd)
+		defer stop()
+
+		var versions gitrev.Versions
+		if gitSpec != "" {
+			if versions, err = gitrev.Read(ctx, args[0], revisions); err != nil {
+				return err
+			}
+			oldSide.inputs, oldSide.data, oldSide.name = args[:1], versions.Old, versions.OldLabel
+			newSide.inputs, newSide.data, newSide.name = args[:1], versions.New, versions.NewLabel
+			oldSide.label, newSide.label = oldSide.name, newSide.name
+			if format == "text" {
+				if err := output.RenderSourceDiffText(os.Stdout, versions); err != nil {
+					return err
+				}
+			}
+		}
+
+		var oldPlanOutputs, newPlanOutputs []plan.ExplainOutput
+		if len(targets) > 1 {
+			// Different databases don't compete for the same resources, so
+			// both sides can run at once.
+			var wg sync.WaitGroup
+			var oldErr, newErr error
+			wg.Go(func() { oldPlanOutputs, oldErr = oldSide.resolve(ctx) })
+			wg.Go(func() { newPlanOutputs, newErr = newSide.resolve(ctx) })
+			wg.Wait()
+			if err := errors.Join(oldErr, newErr); err != nil {
+				return err
+			}
+		} else {
+			if oldPlanOutputs, err = oldSide.resolve(ctx); err != nil {
+				return err
+			}
+			if newPlanOutputs, err = newSide.resolve(ctx); err != nil {
+				return err
+			}
+		}
+
+		cmp := &comparator.Comparator{Threshold: threshold, Alpha: alpha}
+
+		if pair {
+			results, err := cmp.ComparePairs(oldPlanOutputs, newPlanOutputs)
+			if err != nil {
+				return err
+			}
+			for i := range results {
+				results[i].Result.Summary.OldLabel, results[i].Result.Summary.NewLabel = oldSide.name, newSide.name
+			}
+
+			var gateResult *gate.Result
+			if limits != nil {
+				r := limits.CheckAll(results)
+				gateResult = &r
+			}
+
+			switch format {
+			case "json":
+				err = output.RenderJSON(os.Stdout, struct {
+					Statements []comparator.StatementComparison
+					Gate       *gate.Result `json:",omitempty"`
+					Diff       string       `json:",omitempty"`
+				}{results, gateResult, versions.Diff})
+			case "text":
+				err = output.RenderStatementComparisonsText(os.Stdout, results, blockSize)
+			}
+			if err != nil {
+				return err
+			}
+			return finishGate(format, gateResult)
+		}
+
+		oldPlanOutput, err := selectStatement(oldPlanOutputs, statement, oldSide.label)
 		if err != nil {
 			return err
 		}
 
-		newPlanOutput, err := plan.Resolve(newFile, connStr, "new plan ")
+		newPlanOutput, err := selectStatement(newPlanOutputs, statement, newSide.label)
 		if err != nil {
 			return err
 		}
 
-		cmp := &comparator.Comparator{Threshold: threshold}
 		result := cmp.Compare(oldPlanOutput, newPlanOutput)
+		result.Summary.OldLabel, result.Summary.NewLabel = oldSide.name, newSide.name
+
+		var gateResult *gate.Result
+		if limits != nil {
+			r := limits.Check(result)
+			gateResult = &r
+		}
 
 		switch format {
 		case "json":
-			return output.RenderJSON(os.Stdout, result)
+			err = output.RenderJSON(os.Stdout, struct {
+				comparator.ComparisonResult
+				Gate *gate.Result `json:",omitempty"`
+				// Diff is the unified diff of the file across --git's
+				// revisions.
+				Diff string `json:",omitempty"`
+			}{result, gateResult, versions.Diff})
 		case "text":
-			return output.RenderComparisonText(os.Stdout, result, blockSize)
+			err = output.RenderComparisonText(os.Stdout, result, blockSize)
 		}
-
-		return nil
+		if err != nil {
+			return err
+		}
+		return finishGate(format, gateResult)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(compareCmd)
-	compareCmd.Flags().StringP("db", "d", "", "PostgreSQL connection string")
-	compareCmd.Flags().StringP("profile", "p", "", "Use named profile from config")
+	compareCmd.Flags().StringArrayP("db", "d", nil, "PostgreSQL connection string; give two to compare a SQL file across two databases")
+	compareCmd.Flags().StringArrayP("profile", "p", nil, "Use named profile from config; give two to compare a SQL file across two databases")
 	compareCmd.Flags().StringP("format", "f", "text", "Output format: text, json")
 	compareCmd.Flags().Float64P("threshold", "t", 5.0, "Percent change threshold for significance (default 5%)")
+	addExecFlags(compareCmd)
 	compareCmd.Flags().Int64("block-size", 8192, "PostgreSQL page size in bytes, used to show block counts as human-readable sizes")
+	compareCmd.Flags().Int("statement", 1, "1-based index of the statement to compare when an input contains several")
+	compareCmd.Flags().Bool("pair", false, "Compare the statements of both inputs one to one")
+	compareCmd.Flags().StringArray("old", nil, "A run of the \"before\" plan (repeatable); several are aggregated and compared statistically")
+	compareCmd.Flags().StringArray("new", nil, "A run of the \"after\" plan (repeatable); several are aggregated and compared statistically")
+	compareCmd.Flags().Float64("max-time-regression", 0, "Exit with code 2 if execution time regresses by more than this percent")
+	compareCmd.Flags().Float64("max-cost-regression", 0, "Exit with code 2 if total cost regresses by more than this percent")
+	compareCmd.Flags().Float64("max-reads-regression", 0, "Exit with code 2 if blocks read grow by more than this percent")
+	compareCmd.Flags().String("git", "", "Compare one file as it was at two git revisions, e.g. main..HEAD or main...HEAD")
+	compareCmd.Flags().Float64("alpha", comparator.DefaultAlpha, "Significance level for timings compared across several runs per side")
 	compareCmd.MarkFlagsMutuallyExclusive("db", "profile")
+	compareCmd.MarkFlagsMutuallyExclusive("statement", "pair")
+}
+
+// compareSide is one side of a comparison: its inputs and the database to
+// run SQL input against.
+type compareSide struct {
+	inputs  []string
+	connStr string
+	opts    plan.ExecOptions
+	// data is the input's content when it was read ahead, e.g. from a git
+	// revision; inputs then holds the one file it came from.
+	data []byte
+
+	// label names the side in messages; name names it in the output, when
+	// it is more than "old" or "new": the profile or database it ran on.
+	label string
+	name  string
+}
+
+// resolve resolves the side's plans: a single input, or the runs of
+// several aggregated into one plan per statement.
+func (s *compareSide) resolve(ctx context.Context) ([]plan.ExplainOutput, error) {
+	var outputs []plan.ExplainOutput
+	var err error
+	switch {
+	case s.data != nil:
+		outputs, err = plan.ResolveData(ctx, s.data, s.inputs[0], s.connStr, s.label+" ", s.opts)
+	case len(s.inputs) == 1:
+		outputs, err = plan.ResolveAll(ctx, s.inputs[0], s.connStr, s.label+" ", s.opts)
+	default:
+		outputs, err = plan.ResolveRuns(ctx, s.inputs, s.connStr, s.label+" ", s.opts)
+	}
+	if err != nil && s.name != "" {
+		return nil, fmt.Errorf("%s: %w", s.name, err)
+	}
+	return outputs, err
+}
+
+// connectionTargets returns the profiles compare's --db or --profile values
+// stand for: one, the default profile when there are none, or two to
+// compare across databases.
+func connectionTargets(cmd *cobra.Command) ([]profile.Profile, error) {
+	dbs, _ := cmd.Flags().GetStringArray("db")
+	names, _ := cmd.Flags().GetStringArray("profile")
+	if len(dbs) > 2 || len(names) > 2 {
+		return nil, fmt.Errorf("give at most two --db or --profile values, one per side")
+	}
+
+	if len(dbs) == 0 && len(names) == 0 {
+		p, err := profile.Lookup("", "")
+		if err != nil {
+			return nil, err
+		}
+		return []profile.Profile{p}, nil
+	}
+
+	var targets []profile.Profile
+	for _, db := range dbs {
+		p, err := profile.Lookup(db, "")
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, p)
+	}
+	for _, name := range names {
+		p, err := profile.Lookup("", name)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, p)
+	}
+	return targets, nil
+}
+
+// connectionLabel names the database p connects to: the profile's name, or
+// the host and database of a bare connection string, leaving out its
+// credentials.
+func connectionLabel(p profile.Profile) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return plan.DescribeConn(p.ConnStr)
+}
+
+// selectStatement returns the plan for the 1-based statement index from a
+// multi-statement input.
+func selectStatement(outputs []plan.ExplainOutput, statement int, label string) (plan.ExplainOutput, error) {
+	if statement > len(outputs) {
+		return plan.ExplainOutput{}, fmt.Errorf("%s has %d statement(s), cannot select statement %d", label, len(outputs), statement)
+	}
+	return outputs[statement-1], nil
 }
@@ -0,0 +1,143 @@
+/*
+Copyright © 2026 JACOB ARTHURS
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+	"github.com/jacobarthurs/pgplan/internal/profile"
+
+	"github.com/spf13/cobra"
+)
+
+// resolveConnection returns the connection string and EXPLAIN options for a
+// command with --db/--profile and the execution flags: see execOptions.
+func resolveConnection(cmd *cobra.Command) (string, plan.ExecOptions, error) {
+	db, _ := cmd.Flags().GetString("db")
+	profileName, _ := cmd.Flags().GetString("profile")
+
+	p, err := profile.Lookup(db, profileName)
+	if err != nil {
+		return "", plan.ExecOptions{}, err
+	}
+	opts, err := execOptions(cmd, p)
+	if err != nil {
+		return "", plan.ExecOptions{}, err
+	}
+	return p.ConnStr, opts, nil
+}
+
+// execOptions returns the EXPLAIN options for running against p, from the
+// execution flags. The profile's safety settings apply on top of the flags:
+// either can allow writes or ask for a read-only transaction. Parameter
+// values come from --param, or from the file named by --params. Session
+// settings are the profile's, in name order, then those of --set, so a flag
+// overrides the profile. --runs and --warmup ask for a benchmark.
+func execOptions(cmd *cobra.Command, p profile.Profile) (plan.ExecOptions, error) {
+	estimate, _ := cmd.Flags().GetBool("estimate")
+	allowWrites, _ := cmd.Flags().GetBool("allow-writes")
+	readOnly, _ := cmd.Flags().GetBool("read-only")
+	paramValues, _ := cmd.Flags().GetStringArray("param")
+	paramsFile, _ := cmd.Flags().GetString("params")
+	sets, _ := cmd.Flags().GetStringArray("set")
+	setupFile, _ := cmd.Flags().GetString("setup")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	runs, _ := cmd.Flags().GetInt("runs")
+	warmup, _ := cmd.Flags().GetInt("warmup")
+
+	if timeout < 0 {
+		return plan.ExecOptions{}, fmt.Errorf("timeout must be non-negative, got %s", timeout)
+	}
+	if runs < 1 {
+		return plan.ExecOptions{}, fmt.Errorf("runs must be at least 1, got %d", runs)
+	}
+	if warmup < 0 {
+		return plan.ExecOptions{}, fmt.Errorf("warmup must be non-negative, got %d", warmup)
+	}
+	if estimate && (runs > 1 || warmup > 0) {
+		return plan.ExecOptions{}, fmt.Errorf("--runs and --warmup measure execution, so they can't be combined with --estimate")
+	}
+
+	opts := plan.ExecOptions{
+		EstimateOnly: estimate,
+		AllowWrites:  allowWrites || p.AllowWrites,
+		ReadOnly:     readOnly || p.ReadOnly,
+		Timeout:      timeout,
+		Runs:         runs,
+		Warmup:       warmup,
+	}
+
+	var err error
+	switch {
+	case paramsFile != "":
+		if opts.ParamSets, err = plan.LoadParams(paramsFile); err != nil {
+			return plan.ExecOptions{}, err
+		}
+	case len(paramValues) > 0:
+		params := make([]plan.Param, len(paramValues))
+		for i, v := range paramValues {
+			params[i] = plan.ParseParam(v)
+		}
+		opts.ParamSets = [][]plan.Param{params}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(p.Settings)) {
+		setting, err := plan.ParseSetting(name + "=" + p.Settings[name])
+		if err != nil {
+			return plan.ExecOptions{}, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		opts.Settings = append(opts.Settings, setting)
+	}
+	for _, s := range sets {
+		setting, err := plan.ParseSetting(s)
+		if err != nil {
+			return plan.ExecOptions{}, err
+		}
+		opts.Settings = append(opts.Settings, setting)
+	}
+
+	if setupFile != "" {
+		data, err := os.ReadFile(setupFile)
+		if err != nil {
+			return plan.ExecOptions{}, fmt.Errorf("reading setup file: %w", err)
+		}
+		opts.Setup = string(data)
+	}
+
+	return opts, nil
+}
+
+// addExecFlags registers the execution flags execOptions reads on cmd.
+func addExecFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("estimate", false, "Run EXPLAIN without ANALYZE: plan only, the statements are not executed")
+	cmd.Flags().Bool("allow-writes", false, "Allow EXPLAIN ANALYZE of statements that modify data or schema or call functions such as nextval (they run, then are rolled back)")
+	cmd.Flags().Bool("read-only", false, "Run EXPLAIN in a READ ONLY transaction")
+	cmd.Flags().StringArray("param", nil, "Value for the next $n placeholder (repeatable; \\N for NULL)")
+	cmd.Flags().String("params", "", "JSON/YAML file of parameter values, or CSV file with one parameter set per row")
+	cmd.Flags().StringArray("set", nil, "Set a configuration parameter before EXPLAIN, as name=value (repeatable)")
+	cmd.Flags().String("setup", "", "SQL file to run in the same transaction before EXPLAIN")
+	cmd.Flags().Duration("timeout", 0, "Cancel any statement that runs longer than this, e.g. 30s (0 for no limit)")
+	cmd.Flags().Int("runs", 1, "Run each statement this many times and use the median of every timing and buffer count")
+	cmd.Flags().Int("warmup", 0, "Runs to discard before the measured ones, to warm caches")
+	cmd.MarkFlagsMutuallyExclusive("param", "params")
+}
+
+// interruptible returns cmd's context, canceled on SIGINT or SIGTERM so that
+// a running query is canceled on the server instead of being left behind.
+// A second signal kills the process as usual.
+func interruptible(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
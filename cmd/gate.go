@@ -0,0 +1,105 @@
+/*
+Copyright © 2026 JACOB ARTHURS
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes: a gate failing is distinct from pgplan itself failing.
+const (
+	exitError      = 1
+	exitGateFailed = 2
+)
+
+// gateError ends a command whose gate failed, after its report is written.
+type gateError struct {
+	violations int
+}
+
+func (e *gateError) Error() string {
+	return fmt.Sprintf("gate failed with %d violation(s)", e.violations)
+}
+
+// analysisBudget returns the budget set by analyze's gate flags, or nil when
+// none is set.
+func analysisBudget(cmd *cobra.Command) (*gate.Budget, error) {
+	failOn, _ := cmd.Flags().GetString("fail-on")
+	maxTime, _ := cmd.Flags().GetDuration("max-time")
+	maxCost, _ := cmd.Flags().GetFloat64("max-cost")
+	maxReads, _ := cmd.Flags().GetInt64("max-reads")
+	maxBuffers, _ := cmd.Flags().GetInt64("max-buffers")
+
+	if maxTime < 0 || maxCost < 0 || maxReads < 0 || maxBuffers < 0 {
+		return nil, fmt.Errorf("budgets must be non-negative")
+	}
+
+	budget := gate.Budget{
+		MaxExecutionTime: maxTime,
+		MaxCost:          maxCost,
+		MaxReads:         maxReads,
+		MaxBuffers:       maxBuffers,
+	}
+	if failOn != "" {
+		severity, err := analyzer.ParseSeverity(failOn)
+		if err != nil {
+			return nil, fmt.Errorf("--fail-on: %w", err)
+		}
+		budget.FailOn = &severity
+	}
+
+	if budget == (gate.Budget{}) {
+		return nil, nil
+	}
+	return &budget, nil
+}
+
+// comparisonLimits returns the limits set by compare's gate flags, or nil
+// when none is set.
+func comparisonLimits(cmd *cobra.Command) (*gate.Limits, error) {
+	var limits gate.Limits
+	for flag, limit := range map[string]**float64{
+		"max-time-regression":  &limits.Time,
+		"max-cost-regression":  &limits.Cost,
+		"max-reads-regression": &limits.Reads,
+	} {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		pct, _ := cmd.Flags().GetFloat64(flag)
+		if pct < 0 {
+			return nil, fmt.Errorf("--%s must be non-negative, got %.2f", flag, pct)
+		}
+		*limit = &pct
+	}
+
+	if limits == (gate.Limits{}) {
+		return nil, nil
+	}
+	return &limits, nil
+}
+
+// finishGate prints the gate's outcome after a text report, and turns a
+// failed gate into a gateError. A nil result means no gate was set.
+func finishGate(format string, result *gate.Result) error {
+	if result == nil {
+		return nil
+	}
+	if format == "text" {
+		if err := output.RenderGateText(os.Stdout, *result); err != nil {
+			return err
+		}
+	}
+	if !result.Passed {
+		return &gateError{violations: len(result.Violations)}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2026 JACOB ARTHURS
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/batch"
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/impact"
+	"github.com/jacobarthurs/pgplan/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var impactCmd = &cobra.Command{
+	Use:   "impact <migration.sql> <file|dir|glob>...",
+	Short: "Check how a migration changes the plans of a query suite",
+	Long: `Plan every query in the given SQL files, apply the migration, plan every query
+again, and compare each query's plans, to find out what a schema change does
+to the queries that run against it before it ships.
+
+Everything runs in one transaction that is always rolled back, so the
+migration never takes effect. Its locks are held until then, so point pgplan
+at a copy of production rather than production itself. Statements that can't
+run in a transaction block, such as CREATE INDEX CONCURRENTLY, fail: use the
+plain form for the check.
+
+Directories are walked for .sql files. The report lists the queries whose
+plans the migration affected, worst first: regressed queries by how much they
+slowed down (or by cost with --estimate), then those whose plan changed
+without getting better or worse, then the improved ones.
+
+--max-time-regression, --max-cost-regression and --max-reads-regression fail
+the check when any query regresses by more than the given percent, as with
+"pgplan compare". pgplan then exits with code 2.`,
+	Example: `  # What does dropping an index do to the app's queries?
+  pgplan impact migrations/0042_drop_index.sql queries/ --profile staging
+
+  # Plan only, and fail CI if any query's cost more than doubles
+  pgplan impact 0042.sql 'queries/*.sql' --estimate --max-cost-regression 100`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		alpha, _ := cmd.Flags().GetFloat64("alpha")
+
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid output format %q: must be \"text\" or \"json\"", format)
+		}
+
+		if threshold < 0 || threshold > 100 {
+			return fmt.Errorf("threshold must be between 0 and 100, got %.2f", threshold)
+		}
+
+		if alpha <= 0 || alpha >= 1 {
+			return fmt.Errorf("alpha must be between 0 and 1, got %g", alpha)
+		}
+
+		limits, err := comparisonLimits(cmd)
+		if err != nil {
+			return err
+		}
+
+		expanded, err := batch.Expand(args[1:])
+		if err != nil {
+			return err
+		}
+		var files []string
+		for _, f := range expanded {
+			if strings.EqualFold(filepath.Ext(f), ".sql") {
+				files = append(files, f)
+			}
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no SQL files in %s", strings.Join(args[1:], ", "))
+		}
+
+		connStr, execOpts, err := resolveConnection(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := interruptible(cmd)
+		defer stop()
+
+		report, err := impact.Run(ctx, impact.Options{
+			Migration: args[0],
+			Files:     files,
+			DBConn:    connStr,
+			Exec:      execOpts,
+		}, &comparator.Comparator{Threshold: threshold, Alpha: alpha}, limits)
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "json":
+			err = output.RenderJSON(os.Stdout, report)
+		default:
+			err = output.RenderImpactText(os.Stdout, report)
+		}
+		if err != nil {
+			return err
+		}
+		return finishGate(format, report.Gate)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(impactCmd)
+	impactCmd.Flags().StringP("db", "d", "", "PostgreSQL connection string")
+	impactCmd.Flags().StringP("profile", "p", "", "Use named profile from config")
+	impactCmd.Flags().StringP("format", "f", "text", "Output format: text, json")
+	impactCmd.Flags().Float64P("threshold", "t", 5.0, "Percent change threshold for significance (default 5%)")
+	impactCmd.Flags().Float64("alpha", comparator.DefaultAlpha, "Significance level for timings compared across several runs per side")
+	addExecFlags(impactCmd)
+	impactCmd.Flags().Float64("max-time-regression", 0, "Exit with code 2 if any query's execution time regresses by more than this percent")
+	impactCmd.Flags().Float64("max-cost-regression", 0, "Exit with code 2 if any query's total cost regresses by more than this percent")
+	impactCmd.Flags().Float64("max-reads-regression", 0, "Exit with code 2 if any query's blocks read grow by more than this percent")
+	impactCmd.MarkFlagsMutuallyExclusive("db", "profile")
+}
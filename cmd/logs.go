@@ -0,0 +1,93 @@
+/*
+Copyright © 2026 JACOB ARTHURS
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jacobarthurs/pgplan/internal/autoexplain"
+	"github.com/jacobarthurs/pgplan/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <file>",
+	Short: "Analyze auto_explain plans from a PostgreSQL log",
+	Long: `Extract every auto_explain plan from a PostgreSQL server log, analyze each one,
+and report the worst offenders grouped by normalized query.
+
+Supports stderr, csvlog and jsonlog output, detected from the first line unless
+--log-format is given, and any auto_explain.log_format (json is recommended).
+Queries are grouped after replacing literals and bind parameters with "?".
+Use "-" to read from stdin.`,
+	Example: `  # Report the 10 worst queries in a day's log
+  pgplan logs postgresql-2026-05-01.log
+
+  # csvlog, top 25, as JSON
+  pgplan logs postgresql.csv --log-format csvlog --top 25 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		logFormat, _ := cmd.Flags().GetString("log-format")
+		top, _ := cmd.Flags().GetInt("top")
+		blockSize, _ := cmd.Flags().GetInt64("block-size")
+
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid output format %q: must be \"text\" or \"json\"", format)
+		}
+
+		if blockSize <= 0 {
+			return fmt.Errorf("block-size must be positive, got %d", blockSize)
+		}
+
+		if top < 0 {
+			return fmt.Errorf("top must be non-negative, got %d", top)
+		}
+
+		parsedFormat, err := autoexplain.ParseFormat(logFormat)
+		if err != nil {
+			return err
+		}
+
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+			r = f
+		}
+
+		log, err := autoexplain.Parse(r, parsedFormat)
+		if err != nil {
+			return err
+		}
+
+		report := autoexplain.Summarize(log, blockSize)
+
+		switch format {
+		case "json":
+			if top > 0 && len(report.Groups) > top {
+				report.Groups = report.Groups[:top]
+			}
+			return output.RenderJSON(os.Stdout, report)
+		case "text":
+			return output.RenderLogReportText(os.Stdout, report, top, blockSize)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().StringP("format", "f", "text", "Output format: text, json")
+	logsCmd.Flags().String("log-format", "auto", "Log format: auto, stderr, csvlog, jsonlog")
+	logsCmd.Flags().IntP("top", "n", 10, "Number of query groups to report (0 for all)")
+	logsCmd.Flags().Int64("block-size", 8192, "PostgreSQL page size in bytes, used to show block counts as human-readable sizes")
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2026 JACOB ARTHURS
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/output"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+
+	"github.com/spf13/cobra"
+)
+
+var trendCmd = &cobra.Command{
+	Use:   "trend <plan> <plan> [plan]...",
+	Short: "Follow a query's plan across several versions",
+	Long: `Follow one query's plan across several versions, e.g. one per release, given
+oldest first.
+
+Inputs are read as by "pgplan compare": SQL files (run against --db or
+--profile) or EXPLAIN output in any format. When an input contains several
+statements, the first is used; pick another with --statement.
+
+For the whole plan, pgplan reports cost, execution and planning time, and
+buffer hits and reads as a series over the plans. Plan nodes are paired from
+each plan to the next as "pgplan compare" pairs them, and get series of their
+own: cost, time, rows and blocks read. Each plan is compared with the one
+before it, and the first that regressed (execution time, or cost without
+ANALYZE data, worse by more than --threshold) is flagged, as is the first
+regression of each series.
+
+Text output draws each series as a sparkline and lists the --top nodes by
+peak time; JSON output holds every series, with null where a plan lacks a
+value.`,
+	Example: `  # Follow a query across four releases
+  pgplan trend v1.json v2.json v3.json v4.json
+
+  # Write every series as JSON
+  pgplan trend plans/2026-*.json --format json`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		alpha, _ := cmd.Flags().GetFloat64("alpha")
+		statement, _ := cmd.Flags().GetInt("statement")
+		top, _ := cmd.Flags().GetInt("top")
+
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid output format %q: must be \"text\" or \"json\"", format)
+		}
+
+		if threshold < 0 || threshold > 100 {
+			return fmt.Errorf("threshold must be between 0 and 100, got %.2f", threshold)
+		}
+
+		if alpha <= 0 || alpha >= 1 {
+			return fmt.Errorf("alpha must be between 0 and 1, got %g", alpha)
+		}
+
+		if statement < 1 {
+			return fmt.Errorf("statement must be at least 1, got %d", statement)
+		}
+
+		connStr, execOpts, err := resolveConnection(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := interruptible(cmd)
+		defer stop()
+
+		plans := make([]plan.ExplainOutput, len(args))
+		for i, arg := range args {
+			outputs, err := plan.ResolveAll(ctx, arg, connStr, arg+" ", execOpts)
+			if err != nil {
+				return err
+			}
+			if plans[i], err = selectStatement(outputs, statement, arg); err != nil {
+				return err
+			}
+		}
+
+		c := &comparator.Comparator{Threshold: threshold, Alpha: alpha}
+		result := c.Trend(args, plans)
+
+		switch format {
+		case "json":
+			return output.RenderJSON(os.Stdout, result)
+		default:
+			return output.RenderTrendText(os.Stdout, result, top)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trendCmd)
+	trendCmd.Flags().StringP("db", "d", "", "PostgreSQL connection string")
+	trendCmd.Flags().StringP("profile", "p", "", "Use named profile from config")
+	trendCmd.Flags().StringP("format", "f", "text", "Output format: text, json")
+	trendCmd.Flags().Float64P("threshold", "t", 5.0, "Percent change threshold for significance (default 5%)")
+	trendCmd.Flags().Float64("alpha", comparator.DefaultAlpha, "Significance level for timings compared across several runs per side")
+	addExecFlags(trendCmd)
+	trendCmd.Flags().Int("statement", 1, "1-based index of the statement to follow when an input contains several")
+	trendCmd.Flags().IntP("top", "n", 10, "Nodes to list in text output (0 for all)")
+	trendCmd.MarkFlagsMutuallyExclusive("db", "profile")
+}
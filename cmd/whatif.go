@@ -0,0 +1,110 @@
+/*
+Copyright © 2026 JACOB ARTHURS
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/output"
+	"github.com/jacobarthurs/pgplan/internal/whatif"
+
+	"github.com/spf13/cobra"
+)
+
+var whatifCmd = &cobra.Command{
+	Use:     "whatif <sql-file>",
+	Aliases: []string{"what-if"},
+	Short:   "Compare a query's plan under alternative planner settings",
+	Long: `Run a SQL file under the current settings, then under each --variant, and
+compare each variant's plans with the baseline's, to find out whether a bad
+plan is a costing problem.
+
+A variant is a set of settings, with an optional name:
+
+  --variant enable_nestloop=off
+  --variant big-mem:work_mem=256MB,hash_mem_multiplier=4
+
+Each variant runs in its own transaction, with its settings applied on top of
+the profile's and --set's, so nothing carries over from one variant to the
+next. The report shows which variants changed the plan shape, what changed,
+and the difference in execution time and cost.
+
+The SQL file is run as by "pgplan analyze", with the same safety checks and
+execution flags.`,
+	Example: `  # Would the query be faster without nested loops, or without JIT?
+  pgplan whatif slow.sql --profile staging --variant enable_nestloop=off --variant jit=off
+
+  # Name the variants, and plan only
+  pgplan whatif slow.sql --variant mem:work_mem=256MB --variant cheap-io:random_page_cost=1.1 --estimate`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		alpha, _ := cmd.Flags().GetFloat64("alpha")
+		specs, _ := cmd.Flags().GetStringArray("variant")
+
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid output format %q: must be \"text\" or \"json\"", format)
+		}
+
+		if threshold < 0 || threshold > 100 {
+			return fmt.Errorf("threshold must be between 0 and 100, got %.2f", threshold)
+		}
+
+		if alpha <= 0 || alpha >= 1 {
+			return fmt.Errorf("alpha must be between 0 and 1, got %g", alpha)
+		}
+
+		if len(specs) == 0 {
+			return fmt.Errorf("give at least one --variant, e.g. --variant enable_nestloop=off")
+		}
+		variants := make([]whatif.Variant, len(specs))
+		for i, spec := range specs {
+			v, err := whatif.ParseVariant(spec)
+			if err != nil {
+				return err
+			}
+			variants[i] = v
+		}
+
+		connStr, execOpts, err := resolveConnection(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := interruptible(cmd)
+		defer stop()
+
+		report, err := whatif.Run(ctx, whatif.Options{
+			File:     args[0],
+			DBConn:   connStr,
+			Exec:     execOpts,
+			Variants: variants,
+		}, &comparator.Comparator{Threshold: threshold, Alpha: alpha})
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "json":
+			return output.RenderJSON(os.Stdout, report)
+		default:
+			return output.RenderWhatIfText(os.Stdout, report)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whatifCmd)
+	whatifCmd.Flags().StringP("db", "d", "", "PostgreSQL connection string")
+	whatifCmd.Flags().StringP("profile", "p", "", "Use named profile from config")
+	whatifCmd.Flags().StringArray("variant", nil, "Settings to compare against the baseline, as [name:]setting=value[,setting=value] (repeatable)")
+	whatifCmd.Flags().StringP("format", "f", "text", "Output format: text, json")
+	whatifCmd.Flags().Float64P("threshold", "t", 5.0, "Percent change threshold for significance (default 5%)")
+	whatifCmd.Flags().Float64("alpha", comparator.DefaultAlpha, "Significance level for timings compared across several runs per side")
+	addExecFlags(whatifCmd)
+	whatifCmd.MarkFlagsMutuallyExclusive("db", "profile")
+}
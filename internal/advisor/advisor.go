@@ -0,0 +1,300 @@
+// Package advisor consolidates the indexes findings suggest into a ranked
+// list of candidates and a CREATE INDEX CONCURRENTLY script: identical
+// suggestions are merged, and so is an index whose key columns lead
+// another's, since the longer index serves both.
+package advisor
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/batch"
+)
+
+// Advice is the outcome of the advisor: its candidates, most valuable
+// first, and the script that creates them.
+type Advice struct {
+	Candidates []Candidate
+	Script     string
+}
+
+// Candidate is an index the advisor recommends.
+type Candidate struct {
+	Index analyzer.IndexSuggestion
+	// Definition is the CREATE INDEX CONCURRENTLY statement for Index.
+	Definition string
+
+	// Time is the summed time of the nodes the index would speed up, in
+	// ms, and Cost their summed estimated cost. Candidates are ranked by
+	// Time, then, for plans without ANALYZE data, by Cost.
+	Time float64
+	Cost float64
+
+	// Sources are the findings that suggested the index, or one it
+	// absorbed.
+	Sources []Source
+	// Merged are the definitions of the suggestions the index absorbed:
+	// duplicates aside, those whose key columns lead Index's.
+	Merged []string `json:",omitempty"`
+	// AlternativeTo is the 1-based rank of a candidate before this one
+	// that every source also suggested, such as a composite index offered
+	// alongside a partial one: creating both is rarely needed.
+	AlternativeTo int `json:",omitempty"`
+}
+
+// Source is a finding that suggested an index.
+type Source struct {
+	File      string `json:",omitempty"`
+	Statement int    `json:",omitempty"`
+	Rule      string
+	NodeType  string
+	Relation  string
+	Time      float64
+	Cost      float64
+}
+
+// suggestion is one index a finding suggested, before consolidation.
+type suggestion struct {
+	index  analyzer.IndexSuggestion
+	source Source
+}
+
+// Analysis advises on the findings of one plan.
+func Analysis(result analyzer.AnalysisResult) Advice {
+	return advise(collect(nil, "", 0, result))
+}
+
+// Statements advises on the findings of every statement of an input.
+func Statements(statements []analyzer.StatementResult) Advice {
+	var suggestions []suggestion
+	for _, s := range statements {
+		suggestions = collect(suggestions, "", s.Index, s.Result)
+	}
+	return advise(suggestions)
+}
+
+// Batch advises on the findings of every file of a batch, leaving out the
+// files that failed.
+func Batch(report batch.Report) Advice {
+	var suggestions []suggestion
+	for _, f := range report.Files {
+		if f.Result == nil {
+			continue
+		}
+		for _, s := range f.Result.Statements {
+			suggestions = collect(suggestions, f.File, s.Index, s.Result)
+		}
+	}
+	return advise(suggestions)
+}
+
+func collect(suggestions []suggestion, file string, statement int, result analyzer.AnalysisResult) []suggestion {
+	for _, f := range result.Findings {
+		var time float64
+		if f.HasActualRows {
+			time = f.NodeTime
+		}
+		for _, idx := range f.Indexes {
+			suggestions = append(suggestions, suggestion{index: idx, source: Source{
+				File:      file,
+				Statement: statement,
+				Rule:      f.Rule,
+				NodeType:  f.NodeType,
+				Relation:  f.Relation,
+				Time:      time,
+				Cost:      f.NodeCost,
+			}})
+		}
+	}
+	return suggestions
+}
+
+// advise merges suggestions into candidates and ranks them.
+func advise(suggestions []suggestion) Advice {
+	var candidates []Candidate
+	byDefinition := make(map[string]int)
+	for _, s := range suggestions {
+		def := s.index.ConcurrentDefinition()
+		i, ok := byDefinition[def]
+		if !ok {
+			i = len(candidates)
+			byDefinition[def] = i
+			candidates = append(candidates, Candidate{Index: s.index, Definition: def})
+		}
+		candidates[i].Sources = append(candidates[i].Sources, s.source)
+	}
+
+	candidates = mergePrefixes(candidates)
+	for i := range candidates {
+		c := &candidates[i]
+		c.Sources = uniqueSources(c.Sources)
+		for _, src := range c.Sources {
+			c.Time += src.Time
+			c.Cost += src.Cost
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		return cmp.Or(
+			cmp.Compare(b.Time, a.Time),
+			cmp.Compare(b.Cost, a.Cost),
+			cmp.Compare(a.Definition, b.Definition),
+		)
+	})
+	for i := range candidates {
+		for j := range i {
+			if sourcesWithin(candidates[i].Sources, candidates[j].Sources) {
+				candidates[i].AlternativeTo = j + 1
+				break
+			}
+		}
+	}
+	return Advice{Candidates: candidates, Script: Script(candidates)}
+}
+
+// mergePrefixes folds each candidate whose key columns lead another's on
+// the same table and with the same predicate into the longest such one:
+// an index on (a, b) serves a lookup on a as well as one on (a, b).
+func mergePrefixes(candidates []Candidate) []Candidate {
+	absorbedBy := make([]int, len(candidates))
+	for i := range candidates {
+		absorbedBy[i] = -1
+		for j := range candidates {
+			if i == j || !covers(candidates[j].Index, candidates[i].Index) {
+				continue
+			}
+			// Identical keys only happen with different INCLUDE lists;
+			// keep the first so two such candidates don't absorb each other.
+			if len(candidates[j].Index.Columns) == len(candidates[i].Index.Columns) && j > i {
+				continue
+			}
+			if best := absorbedBy[i]; best < 0 || len(candidates[j].Index.Columns) > len(candidates[best].Index.Columns) {
+				absorbedBy[i] = j
+			}
+		}
+	}
+
+	// Follow chains, so (a) absorbed by (a, b) absorbed by (a, b, c) ends
+	// up in (a, b, c).
+	root := func(i int) int {
+		for absorbedBy[i] >= 0 {
+			i = absorbedBy[i]
+		}
+		return i
+	}
+
+	var merged []Candidate
+	index := make(map[int]int)
+	for i, c := range candidates {
+		if absorbedBy[i] < 0 {
+			index[i] = len(merged)
+			c.Index.Include = slices.Clip(c.Index.Include)
+			merged = append(merged, c)
+		}
+	}
+	for i, c := range candidates {
+		if absorbedBy[i] < 0 {
+			continue
+		}
+		target := &merged[index[root(i)]]
+		target.Sources = append(target.Sources, c.Sources...)
+		target.Merged = append(target.Merged, c.Definition)
+		for _, col := range c.Index.Include {
+			if !slices.Contains(target.Index.Columns, col) && !slices.Contains(target.Index.Include, col) {
+				target.Index.Include = append(target.Index.Include, col)
+			}
+		}
+		target.Definition = target.Index.ConcurrentDefinition()
+	}
+	return merged
+}
+
+// covers reports whether an index like a serves every lookup b does: on the
+// same table, with the same predicate, and with b's key columns leading
+// a's.
+func covers(a, b analyzer.IndexSuggestion) bool {
+	return a.Table == b.Table && a.Where == b.Where &&
+		len(b.Columns) <= len(a.Columns) && slices.Equal(a.Columns[:len(b.Columns)], b.Columns)
+}
+
+// uniqueSources drops repeated sources: the same node suggesting one index
+// through two alternatives that were merged.
+func uniqueSources(sources []Source) []Source {
+	var unique []Source
+	for _, s := range sources {
+		if !slices.Contains(unique, s) {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+func sourcesWithin(sources, others []Source) bool {
+	for _, s := range sources {
+		if !slices.Contains(others, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Script returns a SQL script that creates candidates in order, each with
+// a comment saying why. CREATE INDEX CONCURRENTLY can't run in a
+// transaction block, so the script must be run statement by statement, as
+// psql does by default.
+func Script(candidates []Candidate) string {
+	var b strings.Builder
+	b.WriteString("-- Indexes suggested by pgplan, most valuable first.\n")
+	b.WriteString("-- Review each before running: CREATE INDEX CONCURRENTLY can't run in a\n")
+	b.WriteString("-- transaction block, and a failed build leaves an INVALID index to drop.\n")
+	if len(candidates) == 0 {
+		b.WriteString("\n-- No finding suggests an index.\n")
+		return b.String()
+	}
+
+	for i, c := range candidates {
+		fmt.Fprintf(&b, "\n-- %d. %s\n", i+1, Describe(c))
+		for _, src := range c.Sources {
+			fmt.Fprintf(&b, "--    %s: %s on %s\n", Location(src), src.Rule, src.Relation)
+		}
+		for _, m := range c.Merged {
+			fmt.Fprintf(&b, "--    replaces %s\n", m)
+		}
+		if c.AlternativeTo > 0 {
+			fmt.Fprintf(&b, "--    alternative to %d, which serves the same nodes\n", c.AlternativeTo)
+		}
+		b.WriteString(c.Definition)
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+// Describe sums up what a candidate would affect, e.g. "2 nodes, 1234.5 ms".
+func Describe(c Candidate) string {
+	nodes := "1 node"
+	if len(c.Sources) != 1 {
+		nodes = fmt.Sprintf("%d nodes", len(c.Sources))
+	}
+	if c.Time > 0 {
+		return fmt.Sprintf("%s, %.1f ms", nodes, c.Time)
+	}
+	return fmt.Sprintf("%s, estimated cost %.2f", nodes, c.Cost)
+}
+
+// Location names where a source was found: "orders.sql #2", "#2", or
+// "plan" for a single plan.
+func Location(s Source) string {
+	switch {
+	case s.File != "" && s.Statement > 0:
+		return fmt.Sprintf("%s #%d", s.File, s.Statement)
+	case s.File != "":
+		return s.File
+	case s.Statement > 0:
+		return fmt.Sprintf("#%d", s.Statement)
+	default:
+		return "plan"
+	}
+}
@@ -0,0 +1,165 @@
+package advisor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/batch"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func finding(rule, relation string, time float64, indexes ...analyzer.IndexSuggestion) analyzer.Finding {
+	return analyzer.Finding{
+		Rule:          rule,
+		NodeType:      "Seq Scan",
+		Relation:      relation,
+		HasActualRows: true,
+		NodeTime:      time,
+		NodeCost:      time * 10,
+		Indexes:       indexes,
+	}
+}
+
+func index(table string, columns ...string) analyzer.IndexSuggestion {
+	return analyzer.IndexSuggestion{Table: table, Columns: columns}
+}
+
+func TestStatements_MergesDuplicatesAndPrefixes(t *testing.T) {
+	advice := Statements([]analyzer.StatementResult{
+		{Index: 1, Result: analyzer.AnalysisResult{Findings: []analyzer.Finding{
+			finding("Seq Scan with Filter", "orders", 100, index("orders", "customer_id")),
+			finding("Seq Scan in Join", "users", 5, index("users", "lower(email)")),
+		}}},
+		{Index: 2, Result: analyzer.AnalysisResult{Findings: []analyzer.Finding{
+			finding("Index Scan Filter Inefficiency", "orders", 300, index("orders", "customer_id", "status")),
+			finding("Seq Scan with Filter", "orders", 50, index("orders", "customer_id")),
+		}}},
+	})
+
+	if len(advice.Candidates) != 2 {
+		t.Fatalf("got %d candidates, want 2: %+v", len(advice.Candidates), advice.Candidates)
+	}
+	orders := advice.Candidates[0]
+	if orders.Definition != "CREATE INDEX CONCURRENTLY ON orders (customer_id, status)" {
+		t.Errorf("first candidate = %q, want the composite index absorbing its prefix", orders.Definition)
+	}
+	if orders.Time != 450 || len(orders.Sources) != 3 {
+		t.Errorf("orders candidate time %.1f from %d sources, want 450 from 3", orders.Time, len(orders.Sources))
+	}
+	if len(orders.Merged) != 1 || orders.Merged[0] != "CREATE INDEX CONCURRENTLY ON orders (customer_id)" {
+		t.Errorf("merged = %v", orders.Merged)
+	}
+	if users := advice.Candidates[1]; users.Definition != "CREATE INDEX CONCURRENTLY ON users ((lower(email)))" {
+		t.Errorf("second candidate = %q", users.Definition)
+	}
+}
+
+func TestAnalysis_KeepsPartialIndexesApart(t *testing.T) {
+	partial := index("orders", "created_at")
+	partial.Where = "status = 'open'"
+	advice := Analysis(analyzer.AnalysisResult{Findings: []analyzer.Finding{
+		finding("Index Scan Filter Inefficiency", "orders", 10, index("orders", "created_at", "status"), partial),
+	}})
+
+	if len(advice.Candidates) != 2 {
+		t.Fatalf("got %d candidates, want the composite and the partial index: %+v", len(advice.Candidates), advice.Candidates)
+	}
+	for _, c := range advice.Candidates {
+		if len(c.Merged) > 0 {
+			t.Errorf("%s absorbed %v: a partial index only serves its predicate", c.Definition, c.Merged)
+		}
+	}
+	if advice.Candidates[0].AlternativeTo != 0 || advice.Candidates[1].AlternativeTo != 1 {
+		t.Errorf("alternatives = %d, %d; want the second marked as an alternative to the first",
+			advice.Candidates[0].AlternativeTo, advice.Candidates[1].AlternativeTo)
+	}
+	if !strings.Contains(advice.Script, "alternative to 1") {
+		t.Errorf("script doesn't mark the alternative:\n%s", advice.Script)
+	}
+}
+
+func TestAnalysis_SeqScanPartialIndex(t *testing.T) {
+	result := analyzer.Analyze(plan.ExplainOutput{
+		Plan: plan.PlanNode{
+			NodeType:            "Seq Scan",
+			RelationName:        "events",
+			Filter:              "(events.status = 'active'::text)",
+			TotalCost:           900,
+			ActualRows:          20000,
+			ActualLoops:         1,
+			ActualTotalTime:     40,
+			RowsRemovedByFilter: 200000,
+		},
+		ExecutionTime: 40,
+	})
+	advice := Analysis(result)
+
+	if len(advice.Candidates) != 2 {
+		t.Fatalf("got %d candidates, want the index and its partial alternative: %+v", len(advice.Candidates), advice.Candidates)
+	}
+	if got := advice.Candidates[1].Definition; got != "CREATE INDEX CONCURRENTLY ON events (status) WHERE status = 'active'" {
+		t.Errorf("second candidate = %q, want the partial index", got)
+	}
+	if !strings.Contains(advice.Script, "WHERE status = 'active'") {
+		t.Errorf("script lacks the partial index:\n%s", advice.Script)
+	}
+}
+
+func TestMergePrefixes_IncludesAndChains(t *testing.T) {
+	withInclude := index("orders", "customer_id")
+	withInclude.Include = []string{"total", "status"}
+	candidates := mergePrefixes([]Candidate{
+		{Index: withInclude, Definition: withInclude.ConcurrentDefinition()},
+		{Index: index("orders", "customer_id", "status"), Definition: "b"},
+		{Index: index("orders", "customer_id", "status", "created_at"), Definition: "c"},
+	})
+
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1: %+v", len(candidates), candidates)
+	}
+	c := candidates[0]
+	if c.Definition != "CREATE INDEX CONCURRENTLY ON orders (customer_id, status, created_at) INCLUDE (total)" {
+		t.Errorf("definition = %q, want the longest index with the remaining INCLUDE column", c.Definition)
+	}
+	if len(c.Merged) != 2 {
+		t.Errorf("merged = %v, want both shorter indexes", c.Merged)
+	}
+}
+
+func TestBatch_RanksByTimeThenCost(t *testing.T) {
+	estimated := finding("Costly Seq Scan (estimated)", "events", 0, index("events", "kind"))
+	estimated.HasActualRows = false
+	estimated.NodeCost = 90000
+
+	report := batch.Report{Files: []batch.FileResult{
+		{File: "a.sql", Result: &analyzer.MultiAnalysisResult{Statements: []analyzer.StatementResult{
+			{Index: 1, Result: analyzer.AnalysisResult{Findings: []analyzer.Finding{estimated}}},
+		}}},
+		{File: "broken.sql", Error: "syntax error"},
+		{File: "b.sql", Result: &analyzer.MultiAnalysisResult{Statements: []analyzer.StatementResult{
+			{Index: 2, Result: analyzer.AnalysisResult{Findings: []analyzer.Finding{
+				finding("Seq Scan with Filter", "orders", 12, index("orders", "customer_id")),
+			}}},
+		}}},
+	}}
+
+	advice := Batch(report)
+	if len(advice.Candidates) != 2 || advice.Candidates[0].Index.Table != "orders" {
+		t.Fatalf("candidates = %+v, want the timed orders index first", advice.Candidates)
+	}
+	for _, want := range []string{
+		"-- 1. 1 node, 12.0 ms\n--    b.sql #2: Seq Scan with Filter on orders\nCREATE INDEX CONCURRENTLY ON orders (customer_id);",
+		"-- 2. 1 node, estimated cost 90000.00",
+	} {
+		if !strings.Contains(advice.Script, want) {
+			t.Errorf("script missing %q:\n%s", want, advice.Script)
+		}
+	}
+}
+
+func TestScript_NoCandidates(t *testing.T) {
+	if got := Script(nil); !strings.Contains(got, "No finding suggests an index.") {
+		t.Errorf("Script(nil) = %q", got)
+	}
+}
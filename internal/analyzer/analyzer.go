@@ -6,16 +6,36 @@ import (
 	"github.com/jacobarthurs/pgplan/internal/plan"
 )
 
-// Analyze evaluates output against the default rule set. blockSize is the
+// Analyze evaluates output against the default rule set. Plans without
+// ANALYZE data are checked with the estimate-based rules only, and the
+// result is marked EstimateOnly. blockSize is the
 // PostgreSQL page size (bytes) used to render block counts in Finding
 // descriptions as human-readable sizes; omit it (or pass <= 0) to use
 // plan.DefaultBlockSize.
 func Analyze(output plan.ExplainOutput, blockSize ...int64) AnalysisResult {
-	// Planning/Execution Time are only present in the JSON when ANALYZE was
-	// used. Determined once at the query level rather than per node: a
-	// node's own Actual Loops is legitimately 0 for a skipped CASE branch,
-	// excluded partition, etc. even when the query was analyzed.
-	analyzed := output.PlanningTime > 0 || output.ExecutionTime > 0
+	return AnalyzeWith(output, optionsFor(blockSize))
+}
+
+// Options adjust an analysis. The zero value uses plan.DefaultBlockSize
+// and no catalog.
+type Options struct {
+	// BlockSize is as for Analyze.
+	BlockSize int64
+	// Catalog, when loaded from the database the plan ran on, lets rules
+	// take its indexes and statistics into account: see PlanContext.Catalog.
+	Catalog *plan.Catalog
+}
+
+func optionsFor(blockSize []int64) Options {
+	if len(blockSize) > 0 {
+		return Options{BlockSize: blockSize[0]}
+	}
+	return Options{}
+}
+
+// AnalyzeWith is Analyze with options.
+func AnalyzeWith(output plan.ExplainOutput, opts Options) AnalysisResult {
+	analyzed := output.Analyzed()
 
 	result := AnalysisResult{
 		TotalCost:     output.Plan.TotalCost,
@@ -24,6 +44,9 @@ func Analyze(output plan.ExplainOutput, blockSize ...int64) AnalysisResult {
 		Buffers:       plan.AggregateBuffers(&output.Plan),
 		SortSpaceUsed: plan.AggregateSortSpaceUsed(&output.Plan),
 		HasActualRows: analyzed,
+		EstimateOnly:  !analyzed,
+		Settings:      output.Settings,
+		Benchmark:     output.Benchmark,
 	}
 	if analyzed {
 		result.ActualRows = output.Plan.ActualRows
@@ -31,13 +54,13 @@ func Analyze(output plan.ExplainOutput, blockSize ...int64) AnalysisResult {
 
 	ctx := BuildContext(&output.Plan)
 	ctx.Analyzed = analyzed
-	if len(blockSize) > 0 {
-		ctx.BlockSize = blockSize[0]
-	}
+	ctx.BlockSize = opts.BlockSize
+	ctx.Catalog = opts.Catalog
 	walkTree(&output.Plan, nil, -1, defaultRules, &ctx, &result)
 
 	consolidated := ConsolidateEstimateMismatches(&output.Plan, &ctx)
 	result.Findings = append(result.Findings, consolidated...)
+	result.Findings = append(result.Findings, checkRelationStatistics(&ctx)...)
 
 	sort.Slice(result.Findings, func(i, j int) bool {
 		return result.Findings[i].Severity > result.Findings[j].Severity
@@ -51,8 +74,10 @@ func walkTree(node, parent *plan.PlanNode, childIdx int, rules []Rule, ctx *Plan
 		findings := rule(node, parent, childIdx, ctx)
 		for i := range findings {
 			findings[i].HasActualRows = ctx.Analyzed
+			findings[i].NodeCost = node.TotalCost
 			if ctx.Analyzed {
 				findings[i].ActualRows = node.ActualRows
+				findings[i].NodeTime = node.ActualTotalTime * float64(max(node.ActualLoops, 1))
 			}
 		}
 		result.Findings = append(result.Findings, findings...)
@@ -62,3 +87,57 @@ func walkTree(node, parent *plan.PlanNode, childIdx int, rules []Rule, ctx *Plan
 		walkTree(&node.Plans[i], node, i, rules, ctx, result)
 	}
 }
+
+// AnalyzeAll evaluates every plan in outputs, e.g. one per statement of a
+// SQL script, and summarizes them together.
+func AnalyzeAll(outputs []plan.ExplainOutput, blockSize ...int64) MultiAnalysisResult {
+	return AnalyzeAllWith(outputs, optionsFor(blockSize))
+}
+
+// AnalyzeAllWith is AnalyzeAll with options.
+func AnalyzeAllWith(outputs []plan.ExplainOutput, opts Options) MultiAnalysisResult {
+	multi := MultiAnalysisResult{
+		Statements: make([]StatementResult, 0, len(outputs)),
+		Summary:    CombinedSummary{Statements: len(outputs)},
+	}
+
+	s := &multi.Summary
+	var slowestTime, slowestCost float64
+	for i, output := range outputs {
+		result := AnalyzeWith(output, opts)
+		multi.Statements = append(multi.Statements, StatementResult{
+			Index:      i + 1,
+			Query:      output.QueryText,
+			Parameters: output.QueryParameters,
+			Result:     result,
+		})
+
+		s.TotalCost += result.TotalCost
+		s.ExecutionTime += result.ExecutionTime
+		s.PlanningTime += result.PlanningTime
+		s.Buffers = s.Buffers.Add(result.Buffers)
+		s.SortSpaceUsed += result.SortSpaceUsed
+
+		for _, f := range result.Findings {
+			switch f.Severity {
+			case Critical:
+				s.Critical++
+			case Warning:
+				s.Warnings++
+			default:
+				s.Infos++
+			}
+		}
+
+		switch {
+		case s.SlowestStatement == 0,
+			result.ExecutionTime > slowestTime,
+			slowestTime == 0 && result.ExecutionTime == 0 && result.TotalCost > slowestCost:
+			s.SlowestStatement = i + 1
+			slowestTime = result.ExecutionTime
+			slowestCost = result.TotalCost
+		}
+	}
+
+	return multi
+}
@@ -133,6 +133,35 @@ func TestAnalyze_ActualRows_PresentWhenAnalyzed(t *testing.T) {
 	}
 }
 
+func TestAnalyze_NodeTimeAcrossLoops(t *testing.T) {
+	output := plan.ExplainOutput{
+		Plan: plan.PlanNode{
+			NodeType:            "Seq Scan",
+			RelationName:        "events",
+			Filter:              "(e.status = 'active')",
+			TotalCost:           900,
+			ActualRows:          20000,
+			ActualLoops:         3,
+			ActualTotalTime:     40,
+			RowsRemovedByFilter: 200000,
+		},
+		ExecutionTime: 120,
+	}
+
+	result := Analyze(output)
+	if len(result.Findings) == 0 {
+		t.Fatal("expected at least one finding")
+	}
+	f := result.Findings[0]
+	if f.NodeTime != 120 || f.NodeCost != 900 {
+		t.Errorf("NodeTime, NodeCost = %v, %v; want 120 (40 ms x 3 loops), 900", f.NodeTime, f.NodeCost)
+	}
+	if len(f.Indexes) != 2 || f.Indexes[0].Definition() != "CREATE INDEX ON events (status)" ||
+		f.Indexes[1].Definition() != "CREATE INDEX ON events (status) WHERE status = 'active'" {
+		t.Errorf("Indexes = %+v, want one on events (status) and its partial alternative", f.Indexes)
+	}
+}
+
 func TestAnalyze_ActualRows_AbsentWithoutAnalyze(t *testing.T) {
 	// A plain EXPLAIN (no ANALYZE) never populates Actual Loops/Rows, nor
 	// the top-level Planning/Execution Time.
@@ -182,3 +211,138 @@ func TestWalkTree_SkippedNodeStillReportedAsAnalyzed(t *testing.T) {
 		}
 	}
 }
+
+func TestAnalyzeAll_PerStatementAndCombinedSummary(t *testing.T) {
+	outputs := []plan.ExplainOutput{
+		{
+			QueryText: "SELECT * FROM users",
+			Plan: plan.PlanNode{
+				NodeType:         "Seq Scan",
+				RelationName:     "users",
+				TotalCost:        20.0,
+				SharedHitBlocks:  5,
+				SharedReadBlocks: 1,
+				ActualLoops:      1,
+			},
+			PlanningTime:  0.5,
+			ExecutionTime: 2.0,
+		},
+		{
+			QueryText:       "SELECT * FROM orders WHERE id = $1",
+			QueryParameters: "$1 = '7'",
+			Plan: plan.PlanNode{
+				NodeType:         "Seq Scan",
+				RelationName:     "orders",
+				TotalCost:        80.0,
+				SharedHitBlocks:  10,
+				SharedReadBlocks: 4,
+				ActualLoops:      1,
+			},
+			PlanningTime:  0.5,
+			ExecutionTime: 9.0,
+		},
+	}
+
+	multi := AnalyzeAll(outputs)
+
+	if len(multi.Statements) != 2 {
+		t.Fatalf("got %d statement results, want 2", len(multi.Statements))
+	}
+	if got := multi.Statements[1]; got.Index != 2 || got.Query != "SELECT * FROM orders WHERE id = $1" || got.Parameters != "$1 = '7'" {
+		t.Errorf("Statements[1] = {%d, %q, %q}, want {2, %q, %q}", got.Index, got.Query, got.Parameters, "SELECT * FROM orders WHERE id = $1", "$1 = '7'")
+	}
+
+	s := multi.Summary
+	if s.Statements != 2 {
+		t.Errorf("Statements = %d, want 2", s.Statements)
+	}
+	if s.TotalCost != 100.0 {
+		t.Errorf("TotalCost = %f, want 100", s.TotalCost)
+	}
+	if s.ExecutionTime != 11.0 {
+		t.Errorf("ExecutionTime = %f, want 11", s.ExecutionTime)
+	}
+	if s.Buffers.Shared.Hit != 15 || s.Buffers.Shared.Read != 5 {
+		t.Errorf("Buffers.Shared = %+v, want hit 15, read 5", s.Buffers.Shared)
+	}
+	if s.SlowestStatement != 2 {
+		t.Errorf("SlowestStatement = %d, want 2", s.SlowestStatement)
+	}
+
+	findings := 0
+	for _, stmt := range multi.Statements {
+		findings += len(stmt.Result.Findings)
+	}
+	if got := s.Critical + s.Warnings + s.Infos; got != findings {
+		t.Errorf("severity counts sum to %d, want %d", got, findings)
+	}
+}
+
+func TestAnalyzeAll_SlowestByCostWithoutAnalyze(t *testing.T) {
+	outputs := []plan.ExplainOutput{
+		{Plan: plan.PlanNode{NodeType: "Result", TotalCost: 50.0}},
+		{Plan: plan.PlanNode{NodeType: "Result", TotalCost: 10.0}},
+	}
+
+	if got := AnalyzeAll(outputs).Summary.SlowestStatement; got != 1 {
+		t.Errorf("SlowestStatement = %d, want 1", got)
+	}
+}
+
+func TestAnalyze_EstimateOnly(t *testing.T) {
+	output := plan.ExplainOutput{
+		Plan: plan.PlanNode{
+			NodeType:       "Gather",
+			TotalCost:      25000.0,
+			WorkersPlanned: 2,
+			Plans: []plan.PlanNode{{
+				NodeType:     "Seq Scan",
+				RelationName: "orders",
+				TotalCost:    24000.0,
+				PlanRows:     10,
+				Filter:       "(orders.status = 'open'::text)",
+			}},
+		},
+	}
+
+	result := Analyze(output)
+
+	if !result.EstimateOnly || result.HasActualRows {
+		t.Errorf("EstimateOnly/HasActualRows = %v/%v, want true/false", result.EstimateOnly, result.HasActualRows)
+	}
+	if len(result.Findings) != 1 || !strings.Contains(result.Findings[0].Description, "estimated 96% of plan cost") {
+		t.Fatalf("Findings = %+v, want only the estimated Seq Scan cost finding", result.Findings)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []Severity{Info, Warning, Critical} {
+		got, err := ParseSeverity(strings.ToUpper(s.String()))
+		if err != nil || got != s {
+			t.Errorf("ParseSeverity(%q) = %v, %v; want %v", strings.ToUpper(s.String()), got, err, s)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+}
+
+func TestAnalyze_FindingsNameTheirRule(t *testing.T) {
+	output := plan.ExplainOutput{
+		Plan: plan.PlanNode{
+			NodeType:      "Sort",
+			ActualLoops:   1,
+			ActualRows:    1000,
+			SortSpaceUsed: 4000,
+			SortSpaceType: "Disk",
+		},
+		ExecutionTime: 50,
+	}
+	result := Analyze(output)
+	if len(result.Findings) == 0 {
+		t.Fatal("expected a sort spill finding")
+	}
+	if got := result.Findings[0].Rule; got != "Sort Spill to Disk" {
+		t.Errorf("Rule = %q, want %q", got, "Sort Spill to Disk")
+	}
+}
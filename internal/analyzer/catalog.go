@@ -0,0 +1,237 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// Thresholds for the catalog-based rules, which only run when the analysis
+// has a catalog: see PlanContext.Catalog.
+const (
+	// StaleStatsModifiedPct is the share of a table's rows modified since
+	// it was last analyzed above which its statistics are stale: twice
+	// autovacuum_analyze_scale_factor's default, so autoanalyze is behind.
+	StaleStatsModifiedPct  = 20.0
+	MinRowsForStaleStats   = 1000
+	StaleStatsCriticalPct  = 100.0
+	DeadTuplesWarningPct   = 20.0
+	MinDeadTuplesForBloat  = 10000
+	LowCardinalityDistinct = 10
+	// LowCorrelation is the absolute correlation between a column's order
+	// and the table's physical order below which an index scan on it reads
+	// pages in effectively random order.
+	LowCorrelation = 0.1
+)
+
+// checkRelationStatistics reports, once per relation the plan scans, what
+// the catalog says is wrong with the table itself rather than a node:
+// statistics too stale for the planner's estimates to be trusted, and dead
+// tuples that scans read in vain.
+func checkRelationStatistics(ctx *PlanContext) []Finding {
+	if ctx.Catalog == nil {
+		return nil
+	}
+
+	var findings []Finding
+	seen := make(map[*plan.Relation]bool)
+	for _, ref := range ctx.AllNodes {
+		node := ref.Node
+		rel := ctx.Relation(node)
+		if rel == nil || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+
+		finding := func(rule string, severity Severity, desc, suggestion string) Finding {
+			return Finding{
+				Rule:          rule,
+				Severity:      severity,
+				NodeType:      node.NodeType,
+				Relation:      node.RelationName,
+				Description:   desc,
+				Suggestion:    suggestion,
+				HasActualRows: ctx.Analyzed,
+			}
+		}
+
+		if desc, modifiedPct := staleStatistics(rel, ctx.Catalog.LoadedAt); desc != "" {
+			severity := Warning
+			if modifiedPct > StaleStatsCriticalPct {
+				severity = Critical
+			}
+			findings = append(findings, finding("Stale Statistics", severity, desc,
+				fmt.Sprintf("Run ANALYZE %s so row estimates reflect the current data, and check why autovacuum hasn't", rel.QualifiedName())))
+		}
+
+		if total := rel.LiveTuples + rel.DeadTuples; rel.DeadTuples >= MinDeadTuplesForBloat {
+			if deadPct := float64(rel.DeadTuples) / float64(total) * 100; deadPct >= DeadTuplesWarningPct {
+				findings = append(findings, finding("Dead Tuples", Warning,
+					fmt.Sprintf("%s has %d dead tuples, %.0f%% of its rows (%s on disk with indexes)",
+						rel.QualifiedName(), rel.DeadTuples, deadPct, plan.FormatBytes(rel.TotalBytes)),
+					fmt.Sprintf("Scans read dead tuples too; VACUUM %s, or make autovacuum visit it more often", rel.QualifiedName())))
+			}
+		}
+	}
+	return findings
+}
+
+// staleStatistics describes rel's statistics when they are stale, as of now,
+// e.g. "Statistics on public.orders are 3 weeks old; 250000 rows (25%)
+// changed since", along with the share of rows modified. It returns "" when
+// they are current enough.
+func staleStatistics(rel *plan.Relation, now time.Time) (string, float64) {
+	rows := max(rel.LiveTuples, 1)
+	if rel.LastAnalyzed == nil {
+		if rel.LiveTuples < MinRowsForStaleStats {
+			return "", 0
+		}
+		return fmt.Sprintf("%s has never been analyzed, so the planner guesses its row counts and value distribution (%d rows)",
+			rel.QualifiedName(), rel.LiveTuples), math.Inf(1)
+	}
+
+	if rel.ModifiedSinceAnalyze < MinRowsForStaleStats {
+		return "", 0
+	}
+	modifiedPct := float64(rel.ModifiedSinceAnalyze) / float64(rows) * 100
+	if modifiedPct < StaleStatsModifiedPct {
+		return "", 0
+	}
+	return fmt.Sprintf("Statistics on %s are %s old; %d rows (%.0f%%) changed since",
+		rel.QualifiedName(), formatAge(now.Sub(*rel.LastAnalyzed)), rel.ModifiedSinceAnalyze, modifiedPct), modifiedPct
+}
+
+// formatAge renders d coarsely, as people say how long ago something was:
+// "45 minutes", "3 days", "3 weeks".
+func formatAge(d time.Duration) string {
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return "1 " + name
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+
+	day := 24 * time.Hour
+	switch {
+	case d < time.Hour:
+		return unit(max(int(d/time.Minute), 1), "minute")
+	case d < day:
+		return unit(int(d/time.Hour), "hour")
+	case d < 14*day:
+		return unit(int(d/day), "day")
+	case d < 60*day:
+		return unit(int(d/(7*day)), "week")
+	case d < 365*day:
+		return unit(int(d/(30*day)), "month")
+	default:
+		return unit(int(d/(365*day)), "year")
+	}
+}
+
+// servingIndex returns an index of rel that a condition on columns can use,
+// its leading key column being one of them, or nil. Partial indexes are
+// left out: whether the query satisfies the predicate is not known.
+func servingIndex(rel *plan.Relation, columns []string) *plan.Index {
+	if rel == nil {
+		return nil
+	}
+	for i := range rel.Indexes {
+		idx := &rel.Indexes[i]
+		if idx.Predicate != "" || len(idx.Columns) == 0 {
+			continue
+		}
+		if slices.ContainsFunc(columns, func(col string) bool { return sameColumn(idx.Columns[0], col) }) {
+			return idx
+		}
+	}
+	return nil
+}
+
+// coveringIndex returns an index of rel other than except whose leading key
+// columns are columns, in any order, or nil. Partial indexes are left out,
+// as for servingIndex.
+func coveringIndex(rel *plan.Relation, columns []string, except string) *plan.Index {
+	if rel == nil {
+		return nil
+	}
+	for i := range rel.Indexes {
+		idx := &rel.Indexes[i]
+		if idx.Name == except || idx.Predicate != "" || len(idx.Columns) < len(columns) {
+			continue
+		}
+		covered := true
+		for _, key := range idx.Columns[:len(columns)] {
+			if !slices.ContainsFunc(columns, func(col string) bool { return sameColumn(key, col) }) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return idx
+		}
+	}
+	return nil
+}
+
+var castRe = regexp.MustCompile(`::[a-z_ ]+(\[\])?`)
+
+// sameColumn reports whether an index key column, as pg_get_indexdef shows
+// it, is the column or expression a plan names: lower((email)::text) is
+// lower(email).
+func sameColumn(key, col string) bool {
+	normalize := func(s string) string {
+		s = castRe.ReplaceAllString(strings.ToLower(s), "")
+		return strings.NewReplacer(`"`, "", "(", "", ")", "", " ", "").Replace(s)
+	}
+	return normalize(key) == normalize(col)
+}
+
+// unusedIndexSuggestion is the suggestion for a node whose condition idx
+// could serve but the planner didn't use it, in place of suggesting an
+// index that already exists. When rel's statistics are stale, it says so,
+// since they are the likeliest reason.
+func unusedIndexSuggestion(ctx *PlanContext, rel *plan.Relation, idx *plan.Index, chosen string) string {
+	suggestion := fmt.Sprintf("Index %s on (%s) already covers this, but the planner chose %s",
+		idx.Name, strings.Join(idx.Columns, ", "), chosen)
+	if desc, _ := staleStatistics(rel, ctx.Catalog.LoadedAt); desc != "" {
+		return suggestion + fmt.Sprintf("; statistics are stale (see Stale Statistics), so run ANALYZE %s and check again", rel.QualifiedName())
+	}
+	return suggestion + "; check that the condition compares the column without a cast or function on it, and whether the planner's row estimate is right"
+}
+
+// correlationNote explains an index scan's scattered reads by the low
+// correlation of its index's leading column, or returns "".
+func correlationNote(rel *plan.Relation, indexName string) string {
+	if rel == nil {
+		return ""
+	}
+	idx := rel.Index(indexName)
+	if idx == nil || len(idx.Columns) == 0 {
+		return ""
+	}
+	col := rel.Column(strings.Trim(idx.Columns[0], `"`))
+	if col == nil || col.Correlation == nil || math.Abs(*col.Correlation) >= LowCorrelation {
+		return ""
+	}
+	return fmt.Sprintf("; %s is barely correlated with the table's physical order (%.2f), so each row is likely a separate page read: CLUSTER %s USING %s or a covering index for an Index Only Scan would help",
+		col.Name, *col.Correlation, rel.QualifiedName(), idx.Name)
+}
+
+// lowCardinalityNote points out that a filter column has few distinct
+// values, which makes a partial index for the filtered value a better bet
+// than a plain one, or returns "".
+func lowCardinalityNote(rel *plan.Relation, column string) string {
+	if rel == nil {
+		return ""
+	}
+	col := rel.Column(column)
+	if col == nil || col.NDistinct <= 0 || col.NDistinct > LowCardinalityDistinct {
+		return ""
+	}
+	return fmt.Sprintf("; %s has only %.0f distinct values, so the partial index is likelier to be chosen", column, col.NDistinct)
+}
@@ -0,0 +1,303 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+var catalogNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// ordersCatalog has one table, public.orders, analyzed at analyzedAt.
+func ordersCatalog(analyzedAt time.Time, modified int64) *plan.Catalog {
+	correlation := 0.02
+	return &plan.Catalog{
+		LoadedAt: catalogNow,
+		Relations: []plan.Relation{{
+			Schema:               "public",
+			Name:                 "orders",
+			Tuples:               1000000,
+			TotalBytes:           512 << 20,
+			LiveTuples:           1000000,
+			ModifiedSinceAnalyze: modified,
+			LastAnalyzed:         &analyzedAt,
+			Indexes: []plan.Index{
+				{Name: "orders_pkey", Columns: []string{"id"}, Unique: true},
+				{Name: "orders_customer_id_idx", Columns: []string{"customer_id"}},
+				{Name: "orders_customer_status_idx", Columns: []string{"customer_id", "status"}},
+				{Name: "orders_email_idx", Columns: []string{"lower((email)::text)"}},
+				{Name: "orders_open_idx", Columns: []string{"created_at"}, Predicate: "status = 'open'::text"},
+			},
+			Columns: []plan.ColumnStats{
+				{Name: "customer_id", NDistinct: 50000, Correlation: &correlation},
+				{Name: "region", NDistinct: 4},
+			},
+		}},
+	}
+}
+
+func catalogCtx(catalog *plan.Catalog) *PlanContext {
+	ctx := analyzedCtx()
+	ctx.Catalog = catalog
+	return ctx
+}
+
+func TestCheckRelationStatistics_Stale(t *testing.T) {
+	root := &plan.PlanNode{NodeType: "Seq Scan", Schema: "public", RelationName: "orders"}
+	ctx := BuildContext(root)
+	ctx.Catalog = ordersCatalog(catalogNow.Add(-21*24*time.Hour), 250000)
+
+	findings := checkRelationStatistics(&ctx)
+	requireFindings(t, findings, 1)
+	f := findings[0]
+	if f.Rule != "Stale Statistics" || f.Severity != Warning || f.Relation != "orders" {
+		t.Errorf("finding = %+v, want a Stale Statistics warning on orders", f)
+	}
+	if !strings.Contains(f.Description, "3 weeks old") || !strings.Contains(f.Description, "250000 rows (25%)") {
+		t.Errorf("description = %q, want its age and the rows changed", f.Description)
+	}
+	if !strings.Contains(f.Suggestion, "ANALYZE public.orders") {
+		t.Errorf("suggestion = %q, want ANALYZE public.orders", f.Suggestion)
+	}
+}
+
+func TestCheckRelationStatistics_Current(t *testing.T) {
+	root := &plan.PlanNode{NodeType: "Seq Scan", Schema: "public", RelationName: "orders"}
+	ctx := BuildContext(root)
+	// Old, but hardly anything changed since.
+	ctx.Catalog = ordersCatalog(catalogNow.Add(-90*24*time.Hour), 5000)
+
+	requireNoFindings(t, checkRelationStatistics(&ctx))
+}
+
+func TestCheckRelationStatistics_NeverAnalyzed(t *testing.T) {
+	root := &plan.PlanNode{NodeType: "Seq Scan", Schema: "public", RelationName: "orders"}
+	ctx := BuildContext(root)
+	ctx.Catalog = ordersCatalog(catalogNow, 0)
+	ctx.Catalog.Relations[0].LastAnalyzed = nil
+
+	findings := checkRelationStatistics(&ctx)
+	requireFindings(t, findings, 1)
+	if findings[0].Severity != Critical || !strings.Contains(findings[0].Description, "never been analyzed") {
+		t.Errorf("finding = %+v, want a critical never-analyzed finding", findings[0])
+	}
+}
+
+func TestCheckRelationStatistics_DeadTuplesOncePerRelation(t *testing.T) {
+	root := &plan.PlanNode{NodeType: "Hash Join", Plans: []plan.PlanNode{
+		{NodeType: "Seq Scan", Schema: "public", RelationName: "orders"},
+		{NodeType: "Hash", Plans: []plan.PlanNode{
+			{NodeType: "Index Scan", Schema: "public", RelationName: "orders", Alias: "o2"},
+		}},
+	}}
+	ctx := BuildContext(root)
+	ctx.Catalog = ordersCatalog(catalogNow, 0)
+	ctx.Catalog.Relations[0].DeadTuples = 400000
+
+	findings := checkRelationStatistics(&ctx)
+	if len(findings) != 1 || findings[0].Rule != "Dead Tuples" {
+		t.Fatalf("findings = %+v, want one Dead Tuples finding", findings)
+	}
+	if !strings.Contains(findings[0].Description, "400000 dead tuples, 29%") {
+		t.Errorf("description = %q", findings[0].Description)
+	}
+}
+
+func TestCheckRelationStatistics_NoCatalog(t *testing.T) {
+	root := &plan.PlanNode{NodeType: "Seq Scan", Schema: "public", RelationName: "orders"}
+	ctx := BuildContext(root)
+	requireNoFindings(t, checkRelationStatistics(&ctx))
+}
+
+func TestSeqScanStandalone_ExistingIndexNotChosen(t *testing.T) {
+	node := &plan.PlanNode{
+		NodeType:            "Seq Scan",
+		Schema:              "public",
+		RelationName:        "orders",
+		Filter:              "(orders.customer_id = 42)",
+		ActualRows:          50000,
+		RowsRemovedByFilter: 950000,
+		ActualLoops:         1,
+	}
+
+	ctx := catalogCtx(ordersCatalog(catalogNow.Add(-21*24*time.Hour), 250000))
+	findings := checkSeqScanStandalone(node, nil, -1, ctx)
+	requireFindings(t, findings, 1)
+	f := findings[0]
+	if len(f.Indexes) != 0 {
+		t.Errorf("Indexes = %+v, want none: the index exists", f.Indexes)
+	}
+	if !strings.Contains(f.Suggestion, "orders_customer_id_idx") || !strings.Contains(f.Suggestion, "chose a Seq Scan") {
+		t.Errorf("suggestion = %q, want the existing index named", f.Suggestion)
+	}
+	if !strings.Contains(f.Suggestion, "statistics are stale") {
+		t.Errorf("suggestion = %q, want stale statistics blamed", f.Suggestion)
+	}
+
+	ctx = catalogCtx(ordersCatalog(catalogNow, 0))
+	findings = checkSeqScanStandalone(node, nil, -1, ctx)
+	if !strings.Contains(findings[0].Suggestion, "without a cast") {
+		t.Errorf("suggestion = %q, want the condition questioned when statistics are current", findings[0].Suggestion)
+	}
+}
+
+func TestSeqScanStandalone_PartialIndexIgnored(t *testing.T) {
+	node := &plan.PlanNode{
+		NodeType:            "Seq Scan",
+		Schema:              "public",
+		RelationName:        "orders",
+		Filter:              "(orders.created_at > now())",
+		ActualRows:          50000,
+		RowsRemovedByFilter: 950000,
+		ActualLoops:         1,
+	}
+
+	findings := checkSeqScanStandalone(node, nil, -1, catalogCtx(ordersCatalog(catalogNow, 0)))
+	requireFindings(t, findings, 1)
+	if len(findings[0].Indexes) != 1 {
+		t.Errorf("Indexes = %+v, want the index suggested: only a partial one exists", findings[0].Indexes)
+	}
+}
+
+func TestSeqScanStandalone_LowCardinality(t *testing.T) {
+	node := &plan.PlanNode{
+		NodeType:            "Seq Scan",
+		Schema:              "public",
+		RelationName:        "orders",
+		Filter:              "(orders.region = 'emea'::text)",
+		ActualRows:          50000,
+		RowsRemovedByFilter: 950000,
+		ActualLoops:         1,
+	}
+
+	findings := checkSeqScanStandalone(node, nil, -1, catalogCtx(ordersCatalog(catalogNow, 0)))
+	requireFindings(t, findings, 1)
+	if !strings.Contains(findings[0].Suggestion, "region has only 4 distinct values") {
+		t.Errorf("suggestion = %q, want the column's cardinality", findings[0].Suggestion)
+	}
+}
+
+func TestSeqScanInJoin_ExistingExpressionIndex(t *testing.T) {
+	parent := &plan.PlanNode{
+		NodeType: "Hash Join",
+		HashCond: "(lower((o.email)::text) = (c.email)::text)",
+		Plans: []plan.PlanNode{
+			{NodeType: "Seq Scan", Schema: "public", RelationName: "orders", Alias: "o", ActualRows: 1000000, ActualLoops: 1},
+			{NodeType: "Hash", ActualRows: 20, ActualLoops: 1},
+		},
+	}
+
+	findings := checkSeqScanInJoin(&parent.Plans[0], parent, 0, catalogCtx(ordersCatalog(catalogNow, 0)))
+	requireFindings(t, findings, 1)
+	if len(findings[0].Indexes) != 0 || !strings.Contains(findings[0].Suggestion, "orders_email_idx") {
+		t.Errorf("finding = %+v, want the existing expression index named instead of a suggestion", findings[0])
+	}
+}
+
+func TestIndexScanFilterInefficiency_CoveringIndexNotChosen(t *testing.T) {
+	node := &plan.PlanNode{
+		NodeType:            "Index Scan",
+		Schema:              "public",
+		RelationName:        "orders",
+		IndexName:           "orders_customer_id_idx",
+		IndexCond:           "(orders.customer_id = 42)",
+		Filter:              "(orders.status = 'open'::text)",
+		ActualRows:          10,
+		RowsRemovedByFilter: 5000,
+		ActualLoops:         1,
+	}
+
+	findings := checkIndexScanFilterInefficiency(node, nil, -1, catalogCtx(ordersCatalog(catalogNow, 0)))
+	requireFindings(t, findings, 1)
+	f := findings[0]
+	if len(f.Indexes) != 0 {
+		t.Errorf("Indexes = %+v, want none: the composite index exists", f.Indexes)
+	}
+	if !strings.Contains(f.Suggestion, "orders_customer_status_idx") || !strings.Contains(f.Suggestion, "chose orders_customer_id_idx") {
+		t.Errorf("suggestion = %q, want the covering index and the one chosen", f.Suggestion)
+	}
+}
+
+func TestIndexScanLowSelectivity_LowCorrelation(t *testing.T) {
+	node := &plan.PlanNode{
+		NodeType:         "Index Scan",
+		Schema:           "public",
+		RelationName:     "orders",
+		IndexName:        "orders_customer_id_idx",
+		ActualRows:       50000,
+		SharedHitBlocks:  100,
+		SharedReadBlocks: 5000,
+	}
+
+	findings := checkIndexScanLowSelectivity(node, nil, -1, catalogCtx(ordersCatalog(catalogNow, 0)))
+	requireFindings(t, findings, 1)
+	if !strings.Contains(findings[0].Suggestion, "customer_id is barely correlated") ||
+		!strings.Contains(findings[0].Suggestion, "CLUSTER public.orders USING orders_customer_id_idx") {
+		t.Errorf("suggestion = %q, want the low correlation explained", findings[0].Suggestion)
+	}
+}
+
+func TestAnalyzeWith_Catalog(t *testing.T) {
+	output := plan.ExplainOutput{Plan: plan.PlanNode{
+		NodeType:     "Seq Scan",
+		Schema:       "public",
+		RelationName: "orders",
+		TotalCost:    20000,
+		PlanRows:     1000000,
+	}}
+
+	result := AnalyzeWith(output, Options{Catalog: ordersCatalog(catalogNow.Add(-21*24*time.Hour), 250000)})
+	var stale bool
+	for _, f := range result.Findings {
+		stale = stale || f.Rule == "Stale Statistics"
+	}
+	if !stale {
+		t.Errorf("findings = %+v, want Stale Statistics", result.Findings)
+	}
+
+	for _, f := range Analyze(output).Findings {
+		if f.Rule == "Stale Statistics" {
+			t.Errorf("Stale Statistics reported without a catalog")
+		}
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "1 minute"},
+		{45 * time.Minute, "45 minutes"},
+		{5 * time.Hour, "5 hours"},
+		{24 * time.Hour, "1 day"},
+		{10 * 24 * time.Hour, "10 days"},
+		{21 * 24 * time.Hour, "3 weeks"},
+		{90 * 24 * time.Hour, "3 months"},
+		{800 * 24 * time.Hour, "2 years"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestSameColumn(t *testing.T) {
+	tests := []struct {
+		key, col string
+		want     bool
+	}{
+		{"customer_id", "customer_id", true},
+		{`"Status"`, "Status", true},
+		{"lower((email)::text)", "lower(email)", true},
+		{"customer_id", "customer", false},
+	}
+	for _, tt := range tests {
+		if got := sameColumn(tt.key, tt.col); got != tt.want {
+			t.Errorf("sameColumn(%q, %q) = %v, want %v", tt.key, tt.col, got, tt.want)
+		}
+	}
+}
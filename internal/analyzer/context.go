@@ -21,6 +21,21 @@ type PlanContext struct {
 	// a node's own Actual Loops is legitimately 0 for a skipped CASE branch,
 	// excluded partition, etc. even when the query was analyzed.
 	Analyzed bool
+
+	// Catalog holds the indexes, statistics and activity of the relations
+	// the plan scans, when the analysis had a database to read them from
+	// (see plan.LoadCatalog); nil otherwise. Rules look relations up with
+	// Relation.
+	Catalog *plan.Catalog
+}
+
+// Relation returns the catalog entry for the relation node scans, or nil
+// when there is no catalog or it has no such entry.
+func (ctx *PlanContext) Relation(node *plan.PlanNode) *plan.Relation {
+	if node.RelationName == "" {
+		return nil
+	}
+	return ctx.Catalog.Relation(node.Schema, node.RelationName)
 }
 
 // BlockSizeOrDefault returns ctx.BlockSize, falling back to
@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// IndexSuggestion is an index a finding suggests, in a form that can be
+// turned into DDL: see Definition.
+type IndexSuggestion struct {
+	// Table is the indexed relation, schema-qualified when the plan names
+	// its schema.
+	Table string
+	// Columns are the key columns in order. An expression such as
+	// lower(email) is a column too.
+	Columns []string
+	Include []string `json:",omitempty"`
+	// Where is the predicate of a partial index.
+	Where string `json:",omitempty"`
+}
+
+// Definition returns the CREATE INDEX statement for s, without an index
+// name, so PostgreSQL picks one.
+func (s IndexSuggestion) Definition() string {
+	return s.definition("CREATE INDEX ON ")
+}
+
+// ConcurrentDefinition is Definition with CREATE INDEX CONCURRENTLY, which
+// doesn't block writes to the table while the index builds.
+func (s IndexSuggestion) ConcurrentDefinition() string {
+	return s.definition("CREATE INDEX CONCURRENTLY ON ")
+}
+
+func (s IndexSuggestion) definition(create string) string {
+	var b strings.Builder
+	b.WriteString(create)
+	b.WriteString(s.Table)
+	b.WriteString(" (")
+	b.WriteString(indexColumns(s.Columns))
+	b.WriteString(")")
+	if len(s.Include) > 0 {
+		b.WriteString(" INCLUDE (")
+		b.WriteString(strings.Join(s.Include, ", "))
+		b.WriteString(")")
+	}
+	if s.Where != "" {
+		b.WriteString(" WHERE ")
+		b.WriteString(s.Where)
+	}
+	return b.String()
+}
+
+// indexColumns joins key columns, wrapping expressions in parentheses as
+// CREATE INDEX requires.
+func indexColumns(columns []string) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		if strings.Contains(col, "(") {
+			col = "(" + col + ")"
+		}
+		parts[i] = col
+	}
+	return strings.Join(parts, ", ")
+}
+
+// indexTable names node's relation for an index suggestion.
+func indexTable(node *plan.PlanNode) string {
+	if node.Schema != "" {
+		return node.Schema + "." + node.RelationName
+	}
+	return node.RelationName
+}
+
+// equalsPredicate returns "col = 'literal'", quoting literal for SQL.
+func equalsPredicate(col, literal string) string {
+	return col + " = '" + strings.ReplaceAll(literal, "'", "''") + "'"
+}
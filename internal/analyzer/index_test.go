@@ -0,0 +1,37 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func TestIndexSuggestion_Definition(t *testing.T) {
+	tests := []struct {
+		index IndexSuggestion
+		want  string
+	}{
+		{IndexSuggestion{Table: "orders", Columns: []string{"customer_id"}}, "CREATE INDEX ON orders (customer_id)"},
+		{IndexSuggestion{Table: "public.users", Columns: []string{"lower(email)", "id"}}, "CREATE INDEX ON public.users ((lower(email)), id)"},
+		{IndexSuggestion{Table: "orders", Columns: []string{"customer_id"}, Include: []string{"total"}, Where: equalsPredicate("status", "o'pen")}, "CREATE INDEX ON orders (customer_id) INCLUDE (total) WHERE status = 'o''pen'"},
+	}
+	for _, tt := range tests {
+		if got := tt.index.Definition(); got != tt.want {
+			t.Errorf("Definition() = %q, want %q", got, tt.want)
+		}
+	}
+
+	concurrent := IndexSuggestion{Table: "orders", Columns: []string{"customer_id"}}.ConcurrentDefinition()
+	if concurrent != "CREATE INDEX CONCURRENTLY ON orders (customer_id)" {
+		t.Errorf("ConcurrentDefinition() = %q", concurrent)
+	}
+}
+
+func TestIndexTable(t *testing.T) {
+	if got := indexTable(&plan.PlanNode{RelationName: "orders"}); got != "orders" {
+		t.Errorf("indexTable = %q, want orders", got)
+	}
+	if got := indexTable(&plan.PlanNode{RelationName: "orders", Schema: "sales"}); got != "sales.orders" {
+		t.Errorf("indexTable = %q, want sales.orders", got)
+	}
+}
@@ -1,6 +1,11 @@
 package analyzer
 
-import "github.com/jacobarthurs/pgplan/internal/plan"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
 
 type Severity int
 
@@ -23,18 +28,40 @@ func (s Severity) String() string {
 	}
 }
 
+// ParseSeverity parses a severity name as returned by String, in any case.
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range []Severity{Info, Warning, Critical} {
+		if strings.EqualFold(s, sev.String()) {
+			return sev, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid severity %q: must be info, warning or critical", s)
+}
+
 type Finding struct {
+	// Rule names the rule that produced the finding, as listed in the
+	// README: "Sort Spill to Disk", "Seq Scan in Join", ...
+	Rule        string
 	Severity    Severity
 	NodeType    string
 	Relation    string
 	Description string
 	Suggestion  string
+	// Indexes are the indexes Suggestion proposes, when it names the
+	// columns: alternatives to choose from, not a set to create.
+	Indexes []IndexSuggestion `json:",omitempty"`
 
 	// ActualRows is only meaningful when HasActualRows is true - EXPLAIN
 	// (without ANALYZE) never populates it, and a genuinely-zero actual row
 	// count must not be confused with "not reported".
 	ActualRows    float64
 	HasActualRows bool
+
+	// NodeTime is the time spent in the node the rule fired on, across all
+	// its loops, in ms; like ActualRows, it is only meaningful when
+	// HasActualRows is true. NodeCost is the node's estimated total cost.
+	NodeTime float64
+	NodeCost float64
 }
 
 type AnalysisResult struct {
@@ -50,4 +77,55 @@ type AnalysisResult struct {
 	// HasActualRows is true (i.e. the plan was produced with ANALYZE).
 	ActualRows    float64
 	HasActualRows bool
+
+	// EstimateOnly is true when the plan has no ANALYZE data: execution
+	// time, actual rows and buffers are unknown, and findings come from
+	// planner estimates.
+	EstimateOnly bool
+
+	// Settings are the configuration parameters the plan was made with, if
+	// known: see plan.ExplainOutput.Settings.
+	Settings map[string]string
+
+	// Benchmark describes the runs the plan was aggregated from, when it
+	// was: see plan.Aggregate. Times, rows and buffers are then medians.
+	Benchmark *plan.Benchmark
+}
+
+// StatementResult is the analysis of one statement in a multi-statement
+// input. Index is 1-based, in input order; Query is the statement text when
+// known (SQL input, or a plan carrying "Query Text"), and Parameters the
+// bind parameter values it ran with, if any.
+type StatementResult struct {
+	Index      int
+	Query      string
+	Parameters string
+	Result     AnalysisResult
+}
+
+// MultiAnalysisResult is the analysis of every plan in an input, plus a
+// summary across all of them.
+type MultiAnalysisResult struct {
+	Statements []StatementResult
+	Summary    CombinedSummary
+}
+
+type CombinedSummary struct {
+	Statements    int
+	TotalCost     float64
+	ExecutionTime float64
+	PlanningTime  float64
+
+	// Buffers sums each statement's root counters. Statements run
+	// independently, so unlike nodes within a plan they don't overlap.
+	Buffers       plan.NodeBuffers
+	SortSpaceUsed int64 // kB
+
+	Critical int
+	Warnings int
+	Infos    int
+
+	// SlowestStatement is the 1-based index of the statement with the
+	// highest execution time, or the highest cost when none was analyzed.
+	SlowestStatement int
 }
@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/jacobarthurs/pgplan/internal/plan"
@@ -38,6 +39,11 @@ const (
 	MinRowsForEstimateMismatch = 100
 	WideRowThreshold           = 2000
 	WideRowMinRows             = 10000
+
+	// Estimate-only plans (EXPLAIN without ANALYZE)
+	EstimatedCostWarningPct  = 50.0
+	EstimatedCostCriticalPct = 90.0
+	MinCostForEstimatedScan  = 10000.0
 )
 
 // childIdx is the node's index within parent.Plans (-1 for root).
@@ -59,6 +65,8 @@ var defaultRules = []Rule{
 	checkMaterializeHighLoops,
 	checkIndexScanLowSelectivity,
 	checkWideRows,
+	checkEstimatedSeqScanCost,
+	checkEstimatedNestedLoopSeqScan,
 }
 
 func checkIndexScanFilterInefficiency(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext) []Finding {
@@ -93,24 +101,35 @@ func checkIndexScanFilterInefficiency(node, parent *plan.PlanNode, childIdx int,
 		removedPct, node.RowsRemovedByFilter, total)
 
 	var suggestion string
+	var indexes []IndexSuggestion
 	if missingCols, indexCols := ConditionColumnsNotIn(node.Filter, node.IndexCond), ExtractConditionColumns(node.IndexCond); len(missingCols) > 0 && len(indexCols) > 0 {
 
-		compositeCols := strings.Join(append(indexCols, missingCols...), ", ")
-		suggestion = fmt.Sprintf("Column `%s` in filter is not in index; consider composite index on (%s)",
-			strings.Join(missingCols, ", "), compositeCols)
-		if literal := ExtractLiteralValue(node.Filter); literal != "" && len(missingCols) == 1 {
-			suggestion += fmt.Sprintf(" or partial index WHERE %s = '%s'", missingCols[0], literal)
+		composite := append(slices.Clone(indexCols), missingCols...)
+		rel := ctx.Relation(node)
+		if idx := coveringIndex(rel, composite, node.IndexName); idx != nil {
+			suggestion = unusedIndexSuggestion(ctx, rel, idx, node.IndexName)
+		} else {
+			suggestion = fmt.Sprintf("Column `%s` in filter is not in index; consider composite index on (%s)",
+				strings.Join(missingCols, ", "), strings.Join(composite, ", "))
+			indexes = append(indexes, IndexSuggestion{Table: indexTable(node), Columns: composite})
+			if literal := ExtractLiteralValue(node.Filter); literal != "" && len(missingCols) == 1 {
+				suggestion += fmt.Sprintf(" or partial index WHERE %s = '%s'", missingCols[0], literal)
+				suggestion += lowCardinalityNote(rel, missingCols[0])
+				indexes = append(indexes, IndexSuggestion{Table: indexTable(node), Columns: indexCols, Where: equalsPredicate(missingCols[0], literal)})
+			}
 		}
 	} else {
 		suggestion = fmt.Sprintf("Add an index on %s covering the filter condition", node.RelationName)
 	}
 
 	return []Finding{{
+		Rule:        "Index Scan Filter Inefficiency",
 		Severity:    severity,
 		NodeType:    node.NodeType,
 		Relation:    node.RelationName,
 		Description: desc,
 		Suggestion:  suggestion,
+		Indexes:     indexes,
 	}}
 }
 
@@ -151,24 +170,32 @@ func checkSeqScanInJoin(node, parent *plan.PlanNode, childIdx int, ctx *PlanCont
 	}
 
 	suggestion := "Consider index on join column to enable index lookup instead of full scan"
+	var indexes []IndexSuggestion
 	if joinCol := extractJoinColumnForTable(parent, node.RelationName, node.Alias); joinCol != "" {
 		joinCond := parent.HashCond
 		if joinCond == "" {
 			joinCond = parent.MergeCond
 		}
 		if strings.Contains(strings.ToLower(joinCond), "lower(") {
-			suggestion = fmt.Sprintf("Consider index on lower(%s) to enable index lookup instead of full scan", joinCol)
+			joinCol = "lower(" + joinCol + ")"
+		}
+		rel := ctx.Relation(node)
+		if idx := servingIndex(rel, []string{joinCol}); idx != nil {
+			suggestion = unusedIndexSuggestion(ctx, rel, idx, "a full scan")
 		} else {
 			suggestion = fmt.Sprintf("Consider index on %s to enable index lookup instead of full scan", joinCol)
+			indexes = []IndexSuggestion{{Table: indexTable(node), Columns: []string{joinCol}}}
 		}
 	}
 
 	return []Finding{{
+		Rule:        "Seq Scan in Join",
 		Severity:    severity,
 		NodeType:    node.NodeType,
 		Relation:    node.RelationName,
 		Description: desc,
 		Suggestion:  suggestion,
+		Indexes:     indexes,
 	}}
 }
 
@@ -214,20 +241,31 @@ func checkSeqScanStandalone(node, parent *plan.PlanNode, childIdx int, ctx *Plan
 		node.RelationName, removedPct, node.RowsRemovedByFilter, total)
 
 	suggestion := fmt.Sprintf("Add an index on %s covering the filter condition", node.RelationName)
+	var indexes []IndexSuggestion
 	if filterCols := ExtractConditionColumns(node.Filter); len(filterCols) > 0 {
 
-		suggestion = fmt.Sprintf("Consider index on %s(%s)", node.RelationName, strings.Join(filterCols, ", "))
-		if literal := ExtractLiteralValue(node.Filter); literal != "" && len(filterCols) == 1 {
-			suggestion += fmt.Sprintf(" or partial index WHERE %s = '%s'", filterCols[0], literal)
+		rel := ctx.Relation(node)
+		if idx := servingIndex(rel, filterCols); idx != nil {
+			suggestion = unusedIndexSuggestion(ctx, rel, idx, "a Seq Scan")
+		} else {
+			suggestion = fmt.Sprintf("Consider index on %s(%s)", node.RelationName, strings.Join(filterCols, ", "))
+			indexes = []IndexSuggestion{{Table: indexTable(node), Columns: filterCols}}
+			if literal := ExtractLiteralValue(node.Filter); literal != "" && len(filterCols) == 1 {
+				suggestion += fmt.Sprintf(" or partial index WHERE %s = '%s'", filterCols[0], literal)
+				suggestion += lowCardinalityNote(rel, filterCols[0])
+				indexes = append(indexes, IndexSuggestion{Table: indexTable(node), Columns: filterCols, Where: equalsPredicate(filterCols[0], literal)})
+			}
 		}
 	}
 
 	return []Finding{{
+		Rule:        "Seq Scan with Filter",
 		Severity:    severity,
 		NodeType:    node.NodeType,
 		Relation:    node.RelationName,
 		Description: desc,
 		Suggestion:  suggestion,
+		Indexes:     indexes,
 	}}
 }
 
@@ -252,6 +290,7 @@ func checkBitmapHeapRecheck(node, parent *plan.PlanNode, childIdx int, ctx *Plan
 	}
 
 	return []Finding{{
+		Rule:     "Bitmap Heap Recheck",
 		Severity: severity,
 		NodeType: node.NodeType,
 		Relation: node.RelationName,
@@ -293,6 +332,7 @@ func checkNestedLoopHighLoops(node, parent *plan.PlanNode, childIdx int, ctx *Pl
 	}
 
 	return []Finding{{
+		Rule:        "Nested Loop High Loops",
 		Severity:    severity,
 		NodeType:    node.NodeType,
 		Relation:    inner.RelationName,
@@ -306,6 +346,7 @@ func checkSortSpill(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext)
 		return nil
 	}
 	return []Finding{{
+		Rule:        "Sort Spill to Disk",
 		Severity:    Critical,
 		NodeType:    node.NodeType,
 		Relation:    node.RelationName,
@@ -323,6 +364,7 @@ func checkHashSpill(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext)
 		severity = Critical
 	}
 	return []Finding{{
+		Rule:        "Hash Spill to Disk",
 		Severity:    severity,
 		NodeType:    node.NodeType,
 		Relation:    node.RelationName,
@@ -359,6 +401,7 @@ func checkTempBlocks(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext
 
 	blockSize := ctx.BlockSizeOrDefault()
 	return []Finding{{
+		Rule:     "Temp Block I/O",
 		Severity: Warning,
 		NodeType: node.NodeType,
 		Relation: node.RelationName,
@@ -371,10 +414,15 @@ func checkTempBlocks(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext
 }
 
 func checkWorkerMismatch(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext) []Finding {
+	// Workers Launched is only reported with ANALYZE.
+	if !ctx.Analyzed {
+		return nil
+	}
 	if node.WorkersPlanned == 0 || node.WorkersLaunched >= node.WorkersPlanned {
 		return nil
 	}
 	return []Finding{{
+		Rule:        "Worker Launch Mismatch",
 		Severity:    Warning,
 		NodeType:    node.NodeType,
 		Relation:    node.RelationName,
@@ -392,6 +440,7 @@ func checkLargeJoinFilterRemoval(node, parent *plan.PlanNode, childIdx int, ctx
 		severity = Critical
 	}
 	return []Finding{{
+		Rule:        "Large Join Filter Removal",
 		Severity:    severity,
 		NodeType:    node.NodeType,
 		Relation:    node.RelationName,
@@ -416,6 +465,7 @@ func checkMaterializeHighLoops(node, parent *plan.PlanNode, childIdx int, ctx *P
 	totalTime := node.ActualTotalTime * float64(node.ActualLoops)
 
 	return []Finding{{
+		Rule:     "Excessive Materialization",
 		Severity: severity,
 		NodeType: node.NodeType,
 		Relation: node.RelationName,
@@ -453,13 +503,15 @@ func checkIndexScanLowSelectivity(node, parent *plan.PlanNode, childIdx int, ctx
 	}
 
 	return []Finding{{
+		Rule:     "Low Selectivity Index Scan",
 		Severity: Info,
 		NodeType: node.NodeType,
 		Relation: node.RelationName,
 		Description: fmt.Sprintf("%s on %s using %s returned %.0f rows reading %d blocks (%s, %d%% from disk)",
 			node.NodeType, node.RelationName, node.IndexName,
 			node.ActualRows, totalBlocks, plan.FormatBytes(totalBlocks*ctx.BlockSizeOrDefault()), int(readPct)),
-		Suggestion: "Index has low selectivity for this query; a Seq Scan may be cheaper, or the query may benefit from a more selective condition",
+		Suggestion: "Index has low selectivity for this query; a Seq Scan may be cheaper, or the query may benefit from a more selective condition" +
+			correlationNote(ctx.Relation(node), node.IndexName),
 	}}
 }
 
@@ -479,6 +531,7 @@ func checkSubPlanHighLoops(node, parent *plan.PlanNode, childIdx int, ctx *PlanC
 	totalTime := node.ActualTotalTime * float64(node.ActualLoops)
 
 	return []Finding{{
+		Rule:     "Correlated Subplan",
 		Severity: severity,
 		NodeType: node.NodeType,
 		Relation: node.RelationName,
@@ -502,6 +555,7 @@ func checkWideRows(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext)
 	}
 
 	return []Finding{{
+		Rule:        "Wide Row Output",
 		Severity:    Info,
 		NodeType:    node.NodeType,
 		Relation:    node.RelationName,
@@ -535,6 +589,7 @@ func checkParallelOverhead(node, parent *plan.PlanNode, childIdx int, ctx *PlanC
 	overhead := gatherTime - workerTime
 
 	return []Finding{{
+		Rule:     "Parallel Overhead",
 		Severity: Info,
 		NodeType: node.NodeType,
 		Relation: node.RelationName,
@@ -544,6 +599,86 @@ func checkParallelOverhead(node, parent *plan.PlanNode, childIdx int, ctx *PlanC
 	}}
 }
 
+// checkEstimatedSeqScanCost stands in for the actual-row scan rules on
+// plans without ANALYZE data: with no rows-removed counts to go on, a Seq
+// Scan that dominates the plan's estimated cost is the closest signal.
+func checkEstimatedSeqScanCost(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext) []Finding {
+	if ctx.Analyzed || node.NodeType != "Seq Scan" || len(ctx.AllNodes) == 0 {
+		return nil
+	}
+
+	rootCost := ctx.AllNodes[0].Node.TotalCost
+	if rootCost < MinCostForEstimatedScan || node.TotalCost < MinCostForEstimatedScan {
+		return nil
+	}
+	costPct := node.TotalCost / rootCost * 100
+	if costPct < EstimatedCostWarningPct {
+		return nil
+	}
+
+	severity := Warning
+	if costPct > EstimatedCostCriticalPct && node.Filter != "" {
+		severity = Critical
+	}
+
+	desc := fmt.Sprintf("Seq Scan on %s accounts for an estimated %.0f%% of plan cost (%.2f of %.2f), returning ~%d rows",
+		node.RelationName, min(costPct, 100), node.TotalCost, rootCost, node.PlanRows)
+
+	suggestion := fmt.Sprintf("Scan reads all of %s; check whether the query needs every row", node.RelationName)
+	var indexes []IndexSuggestion
+	if filterCols := ExtractConditionColumns(node.Filter); len(filterCols) > 0 {
+		rel := ctx.Relation(node)
+		if idx := servingIndex(rel, filterCols); idx != nil {
+			suggestion = unusedIndexSuggestion(ctx, rel, idx, "a Seq Scan")
+		} else {
+			suggestion = fmt.Sprintf("Consider index on %s(%s); re-run with ANALYZE to confirm how many rows the filter removes",
+				node.RelationName, strings.Join(filterCols, ", "))
+			indexes = []IndexSuggestion{{Table: indexTable(node), Columns: filterCols}}
+		}
+	} else if node.Filter != "" {
+		suggestion = fmt.Sprintf("Add an index on %s covering the filter condition; re-run with ANALYZE to confirm how many rows it removes", node.RelationName)
+	}
+
+	return []Finding{{
+		Rule:        "Costly Seq Scan (estimated)",
+		Severity:    severity,
+		NodeType:    node.NodeType,
+		Relation:    node.RelationName,
+		Description: desc,
+		Suggestion:  suggestion,
+		Indexes:     indexes,
+	}}
+}
+
+// checkEstimatedNestedLoopSeqScan is the estimate-only counterpart of
+// checkNestedLoopHighLoops: the outer side's estimated row count is how many
+// times the planner expects to re-run the inner side.
+func checkEstimatedNestedLoopSeqScan(node, parent *plan.PlanNode, childIdx int, ctx *PlanContext) []Finding {
+	if ctx.Analyzed || node.NodeType != "Nested Loop" || len(node.Plans) < 2 {
+		return nil
+	}
+
+	outer, inner := &node.Plans[0], &node.Plans[1]
+	if inner.NodeType != "Seq Scan" || outer.PlanRows < NestedLoopWarningLoops {
+		return nil
+	}
+
+	severity := Warning
+	if outer.PlanRows > NestedLoopCriticalLoops {
+		severity = Critical
+	}
+
+	return []Finding{{
+		Rule:     "Nested Loop over Seq Scan (estimated)",
+		Severity: severity,
+		NodeType: node.NodeType,
+		Relation: inner.RelationName,
+		Description: fmt.Sprintf("Nested Loop is estimated to run Seq Scan on %s ~%d times (%.2f cost per scan)",
+			inner.RelationName, outer.PlanRows, inner.TotalCost),
+		Suggestion: "Consider an index on the inner side join columns, or check whether the outer row estimate is accurate",
+	}}
+}
+
 func ConsolidateEstimateMismatches(root *plan.PlanNode, ctx *PlanContext) []Finding {
 	var findings []Finding
 
@@ -588,6 +723,7 @@ func ConsolidateEstimateMismatches(root *plan.PlanNode, ctx *PlanContext) []Find
 		}
 
 		findings = append(findings, Finding{
+			Rule:          "CTE Estimate Mismatch",
 			Severity:      Info,
 			NodeType:      "CTE",
 			Relation:      cte.Name,
@@ -13,6 +13,12 @@ func emptyCtx() *PlanContext {
 	return &PlanContext{CTEs: make(map[string]*CTEInfo)}
 }
 
+func analyzedCtx() *PlanContext {
+	ctx := emptyCtx()
+	ctx.Analyzed = true
+	return ctx
+}
+
 func findBySeverity(findings []Finding, sev Severity) []Finding {
 	var result []Finding
 	for _, f := range findings {
@@ -68,6 +74,15 @@ func TestIndexScanFilterInefficiency_HighRemoval(t *testing.T) {
 	if !strings.Contains(f.Suggestion, "partial index") {
 		t.Errorf("expected partial index suggestion, got: %s", f.Suggestion)
 	}
+	if len(f.Indexes) != 2 {
+		t.Fatalf("got %d index suggestions, want composite and partial: %+v", len(f.Indexes), f.Indexes)
+	}
+	if got := f.Indexes[0].Definition(); got != "CREATE INDEX ON scores (updated_at, type)" {
+		t.Errorf("composite index = %q", got)
+	}
+	if got := f.Indexes[1].Definition(); got != "CREATE INDEX ON scores (updated_at) WHERE type = '4'" {
+		t.Errorf("partial index = %q", got)
+	}
 }
 
 func TestIndexScanFilterInefficiency_LowRemoval(t *testing.T) {
@@ -158,6 +173,9 @@ func TestSeqScanInJoin_LargeOuter(t *testing.T) {
 	if !strings.Contains(f.Suggestion, "lower(") {
 		t.Errorf("expected lower() in suggestion, got: %s", f.Suggestion)
 	}
+	if len(f.Indexes) != 1 || !strings.HasSuffix(f.Indexes[0].Definition(), "((lower(testing_service_candidate_id)))") {
+		t.Errorf("index suggestions = %+v, want an expression index on lower()", f.Indexes)
+	}
 }
 
 func TestSeqScanInJoin_SmallTable(t *testing.T) {
@@ -478,10 +496,21 @@ func TestWorkerMismatch_FewerLaunched(t *testing.T) {
 		WorkersLaunched: 2,
 	}
 
-	findings := checkWorkerMismatch(node, nil, -1, emptyCtx())
+	findings := checkWorkerMismatch(node, nil, -1, analyzedCtx())
 	requireFindings(t, findings, 1)
 }
 
+func TestWorkerMismatch_EstimateOnly(t *testing.T) {
+	// Without ANALYZE, Workers Launched is absent rather than 0.
+	node := &plan.PlanNode{
+		NodeType:       "Gather",
+		WorkersPlanned: 4,
+	}
+
+	findings := checkWorkerMismatch(node, nil, -1, emptyCtx())
+	requireNoFindings(t, findings)
+}
+
 func TestWorkerMismatch_AllLaunched(t *testing.T) {
 	node := &plan.PlanNode{
 		NodeType:        "Gather",
@@ -778,3 +807,60 @@ func TestAnalyze_FullPlan(t *testing.T) {
 		}
 	}
 }
+
+func TestEstimatedSeqScanCost_Dominant(t *testing.T) {
+	root := plan.PlanNode{
+		NodeType:  "Aggregate",
+		TotalCost: 20000.0,
+		Plans: []plan.PlanNode{{
+			NodeType:     "Seq Scan",
+			RelationName: "orders",
+			TotalCost:    19000.0,
+			PlanRows:     5,
+			Filter:       "(orders.customer_id = 42)",
+		}},
+	}
+	ctx := BuildContext(&root)
+
+	findings := checkEstimatedSeqScanCost(&root.Plans[0], &root, 0, &ctx)
+	requireFindings(t, findings, 1)
+	if findings[0].Severity != Critical {
+		t.Errorf("Severity = %v, want critical (95%% of cost, filtered)", findings[0].Severity)
+	}
+	if !strings.Contains(findings[0].Suggestion, "orders(customer_id)") {
+		t.Errorf("Suggestion = %q, want index on orders(customer_id)", findings[0].Suggestion)
+	}
+}
+
+func TestEstimatedSeqScanCost_SkippedWhenAnalyzed(t *testing.T) {
+	root := plan.PlanNode{NodeType: "Seq Scan", RelationName: "orders", TotalCost: 19000.0}
+	ctx := BuildContext(&root)
+	ctx.Analyzed = true
+
+	requireNoFindings(t, checkEstimatedSeqScanCost(&root, nil, -1, &ctx))
+}
+
+func TestEstimatedSeqScanCost_CheapPlan(t *testing.T) {
+	root := plan.PlanNode{NodeType: "Seq Scan", RelationName: "small", TotalCost: 35.5}
+	ctx := BuildContext(&root)
+
+	requireNoFindings(t, checkEstimatedSeqScanCost(&root, nil, -1, &ctx))
+}
+
+func TestEstimatedNestedLoopSeqScan(t *testing.T) {
+	node := &plan.PlanNode{
+		NodeType: "Nested Loop",
+		Plans: []plan.PlanNode{
+			{NodeType: "Seq Scan", RelationName: "orders", PlanRows: 50000},
+			{NodeType: "Seq Scan", RelationName: "customers", TotalCost: 12.5},
+		},
+	}
+
+	findings := checkEstimatedNestedLoopSeqScan(node, nil, -1, emptyCtx())
+	requireFindings(t, findings, 1)
+	if findings[0].Severity != Critical || findings[0].Relation != "customers" {
+		t.Errorf("got %v on %q, want critical on customers", findings[0].Severity, findings[0].Relation)
+	}
+
+	requireNoFindings(t, checkEstimatedNestedLoopSeqScan(node, nil, -1, analyzedCtx()))
+}
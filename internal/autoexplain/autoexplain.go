@@ -0,0 +1,310 @@
+// Package autoexplain extracts the plans PostgreSQL's auto_explain module
+// writes to the server log.
+package autoexplain
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+type Format string
+
+const (
+	FormatAuto   Format = "auto"
+	FormatStderr Format = "stderr"
+	FormatCSV    Format = "csvlog"
+	FormatJSON   Format = "jsonlog"
+)
+
+// maxLineSize bounds a single stderr/jsonlog line. jsonlog puts a whole plan
+// on one line, so this is far above bufio.Scanner's 64 kB default.
+const maxLineSize = 64 << 20
+
+// csvlog column positions, stable since PostgreSQL 9.0.
+const (
+	csvLogTime = 0
+	csvMessage = 13
+)
+
+var (
+	durationPlanRe  = regexp.MustCompile(`(?s)^duration: (\d+(?:\.\d+)?) ms\s+plan:\s*(.*)$`)
+	stderrMessageRe = regexp.MustCompile(`(?:^|\s)(?:LOG|INFO|NOTICE|WARNING|DEBUG[1-5]?):\s+`)
+	csvLineRe       = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)? [^,]*,`)
+	timestampRe     = regexp.MustCompile(`(\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(?:\.\d+)?)(?: ?([A-Z]{2,5}\b|[+-]\d{2}(?::?\d{2})?))?`)
+	yamlPlanKeyRe   = regexp.MustCompile(`(?m)^Plan:\s*$`)
+	textQueryRe     = regexp.MustCompile(`(?m)^\s*\S.*\((?:cost=|actual |never executed)`)
+)
+
+// Entry is one auto_explain plan from the log.
+type Entry struct {
+	Line      int       // line the log record starts on
+	Timestamp time.Time // zero when the log line prefix carries no time
+	Duration  float64   // ms, as logged by auto_explain
+	Query     string
+	Plan      plan.ExplainOutput
+}
+
+// Skipped is an auto_explain record whose plan couldn't be parsed, e.g.
+// because the log line was truncated.
+type Skipped struct {
+	Line   int
+	Reason string
+}
+
+type Log struct {
+	Format  Format
+	Entries []Entry
+	Skipped []Skipped
+}
+
+// record is a single log message, before it is known to be auto_explain's.
+type record struct {
+	line      int
+	timestamp string
+	message   string
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatAuto, FormatStderr, FormatCSV, FormatJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid log format %q: must be \"auto\", \"stderr\", \"csvlog\" or \"jsonlog\"", s)
+}
+
+// Parse reads a PostgreSQL server log and returns every auto_explain entry
+// in it. Other log messages are ignored. With FormatAuto, the format is
+// detected from the first non-blank line.
+func Parse(r io.Reader, format Format) (*Log, error) {
+	br := bufio.NewReader(r)
+	if format == FormatAuto {
+		format = detectFormat(br)
+	}
+
+	log := &Log{Format: format}
+	emit := func(rec record) {
+		m := durationPlanRe.FindStringSubmatch(rec.message)
+		if m == nil {
+			return
+		}
+
+		output, err := parsePlan(m[2])
+		if err != nil {
+			log.Skipped = append(log.Skipped, Skipped{Line: rec.line, Reason: err.Error()})
+			return
+		}
+
+		duration, _ := strconv.ParseFloat(m[1], 64)
+		log.Entries = append(log.Entries, Entry{
+			Line:      rec.line,
+			Timestamp: parseTimestamp(rec.timestamp),
+			Duration:  duration,
+			Query:     output.QueryText,
+			Plan:      output,
+		})
+	}
+
+	var err error
+	switch format {
+	case FormatStderr:
+		err = readStderr(br, emit)
+	case FormatCSV:
+		err = readCSV(br, emit)
+	case FormatJSON:
+		err = readJSON(br, emit)
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s log: %w", format, err)
+	}
+
+	return log, nil
+}
+
+func detectFormat(br *bufio.Reader) Format {
+	head, _ := br.Peek(4096)
+	for line := range strings.SplitSeq(string(head), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "{"):
+			return FormatJSON
+		case csvLineRe.MatchString(line):
+			return FormatCSV
+		}
+		break
+	}
+	return FormatStderr
+}
+
+// readStderr reads the plain-text log. Each message starts on a line with
+// the log_line_prefix and a severity; continuation lines of a multi-line
+// message (such as a plan) are indented with a tab.
+func readStderr(r io.Reader, emit func(record)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var cur *record
+	var msg strings.Builder
+	flush := func() {
+		if cur != nil {
+			cur.message = msg.String()
+			emit(*cur)
+			cur = nil
+		}
+		msg.Reset()
+	}
+
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := sc.Text()
+		if rest, ok := strings.CutPrefix(line, "\t"); ok && cur != nil {
+			msg.WriteString("\n")
+			msg.WriteString(rest)
+			continue
+		}
+
+		flush()
+		loc := stderrMessageRe.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		cur = &record{line: lineNo, timestamp: line[:loc[0]]}
+		msg.WriteString(line[loc[1]:])
+	}
+	flush()
+
+	return sc.Err()
+}
+
+func readCSV(r io.Reader, emit func(record)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	for {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(fields) <= csvMessage {
+			continue
+		}
+
+		line, _ := cr.FieldPos(0)
+		emit(record{line: line, timestamp: fields[csvLogTime], message: fields[csvMessage]})
+	}
+}
+
+// readJSON reads jsonlog output (PostgreSQL 15+): one JSON object per line.
+// Lines that aren't valid JSON are ignored.
+func readJSON(r io.Reader, emit func(record)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		var rec struct {
+			Timestamp string `json:"timestamp"`
+			Message   string `json:"message"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue
+		}
+		emit(record{line: lineNo, timestamp: rec.Timestamp, message: rec.Message})
+	}
+
+	return sc.Err()
+}
+
+// parsePlan parses the plan part of an auto_explain message, in whichever
+// auto_explain.log_format produced it.
+func parsePlan(body string) (plan.ExplainOutput, error) {
+	body = strings.TrimSpace(body)
+
+	var plans []plan.ExplainOutput
+	var err error
+	switch {
+	case strings.HasPrefix(body, "{"):
+		plans, err = plan.ParseJSONPlan([]byte(body))
+	case strings.HasPrefix(body, "<"):
+		plans, err = plan.ParseXMLPlan([]byte(body))
+	case yamlPlanKeyRe.MatchString(body):
+		plans, err = plan.ParseYAMLPlan([]byte(body))
+	default:
+		var query string
+		query, body = splitTextQuery(body)
+		plans, err = plan.ParseTextPlan([]byte(body))
+		if err == nil {
+			plans[0].QueryText = query
+		}
+	}
+	if err != nil {
+		return plan.ExplainOutput{}, err
+	}
+
+	return plans[0], nil
+}
+
+// splitTextQuery separates the "Query Text: ..." preamble auto_explain puts
+// before a text-format plan. The query may span several lines, so it runs
+// up to the first plan node line.
+func splitTextQuery(body string) (query, planText string) {
+	rest, ok := strings.CutPrefix(body, "Query Text:")
+	if !ok {
+		return "", body
+	}
+	loc := textQueryRe.FindStringIndex(rest)
+	if loc == nil {
+		return strings.TrimSpace(rest), ""
+	}
+	return strings.TrimSpace(rest[:loc[0]]), rest[loc[0]:]
+}
+
+// parseTimestamp finds a PostgreSQL log timestamp (%t/%m in
+// log_line_prefix, or csvlog/jsonlog's own) in s. Zone abbreviations other
+// than UTC/GMT are kept as names without resolving their offset.
+func parseTimestamp(s string) time.Time {
+	m := timestampRe.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}
+	}
+
+	value := strings.Replace(m[1], "T", " ", 1)
+	layout := "2006-01-02 15:04:05.999999999"
+	switch zone := m[2]; {
+	case zone == "":
+	case strings.HasPrefix(zone, "+"), strings.HasPrefix(zone, "-"):
+		value += zone
+		switch len(zone) {
+		case 3:
+			layout += "-07"
+		case 5:
+			layout += "-0700"
+		default:
+			layout += "-07:00"
+		}
+	default:
+		value += " " + zone
+		layout += " MST"
+	}
+
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
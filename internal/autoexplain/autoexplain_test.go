@@ -0,0 +1,168 @@
+package autoexplain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const stderrLog = `2026-05-01 10:00:00.123 UTC [4242] LOG:  connection received: host=10.0.0.1
+2026-05-01 10:00:01.500 UTC [4242] LOG:  duration: 1523.417 ms  plan:
+	{
+	  "Query Text": "SELECT * FROM orders WHERE customer_id = 42",
+	  "Plan": {
+	    "Node Type": "Seq Scan",
+	    "Relation Name": "orders",
+	    "Total Cost": 18334.00,
+	    "Actual Total Time": 1523.1,
+	    "Actual Rows": 7,
+	    "Actual Loops": 1
+	  }
+	}
+2026-05-01 10:05:00.000 UTC [4243] LOG:  duration: 12.0 ms  plan:
+	Query Text: SELECT * FROM orders
+	  WHERE customer_id = 7
+	Seq Scan on orders  (cost=0.00..18334.00 rows=5 width=64) (actual time=0.020..12.000 rows=2 loops=1)
+	  Filter: (customer_id = 7)
+	  Rows Removed by Filter: 999998
+2026-05-01 10:06:00.000 UTC [4244] ERROR:  relation "nope" does not exist
+2026-05-01 10:07:00.000 UTC [4245] LOG:  duration: 5.0 ms  plan:
+	{ "Query Text": "SELECT 2", "Plan": {"Node Type"
+`
+
+func TestParse_Stderr(t *testing.T) {
+	log, err := Parse(strings.NewReader(stderrLog), FormatAuto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if log.Format != FormatStderr {
+		t.Errorf("Format = %q, want stderr", log.Format)
+	}
+	if len(log.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(log.Entries))
+	}
+
+	json := log.Entries[0]
+	if json.Line != 2 || json.Duration != 1523.417 {
+		t.Errorf("entry 0 line/duration = %d/%f, want 2/1523.417", json.Line, json.Duration)
+	}
+	if want := time.Date(2026, 5, 1, 10, 0, 1, 500_000_000, time.UTC); !json.Timestamp.Equal(want) {
+		t.Errorf("entry 0 Timestamp = %v, want %v", json.Timestamp, want)
+	}
+	if json.Query != "SELECT * FROM orders WHERE customer_id = 42" {
+		t.Errorf("entry 0 Query = %q", json.Query)
+	}
+	if json.Plan.Plan.NodeType != "Seq Scan" || json.Plan.Plan.ActualTotalTime != 1523.1 {
+		t.Errorf("entry 0 plan = %s %f, want Seq Scan 1523.1", json.Plan.Plan.NodeType, json.Plan.Plan.ActualTotalTime)
+	}
+
+	text := log.Entries[1]
+	if text.Query != "SELECT * FROM orders\n  WHERE customer_id = 7" {
+		t.Errorf("entry 1 Query = %q", text.Query)
+	}
+	if text.Plan.Plan.RowsRemovedByFilter != 999998 {
+		t.Errorf("entry 1 RowsRemovedByFilter = %d, want 999998", text.Plan.Plan.RowsRemovedByFilter)
+	}
+
+	if len(log.Skipped) != 1 || log.Skipped[0].Line != 21 {
+		t.Errorf("Skipped = %+v, want the truncated plan at line 21", log.Skipped)
+	}
+}
+
+func TestParse_CSVLog(t *testing.T) {
+	input := `2026-05-01 10:00:00.123 UTC,"app","db",4242,"10.0.0.1:5000",6632a0f0.1092,1,"idle",2026-05-01 09:59:59 UTC,,0,LOG,00000,"connection authorized",,,,,,,,,"psql","client backend",,0
+2026-05-01 10:00:01.500 UTC,"app","db",4242,"10.0.0.1:5000",6632a0f0.1092,2,"SELECT",2026-05-01 09:59:59 UTC,3/7,0,LOG,00000,"duration: 8.250 ms  plan:
+{
+  ""Query Text"": ""SELECT * FROM users WHERE id = 1"",
+  ""Plan"": {
+    ""Node Type"": ""Index Scan"",
+    ""Relation Name"": ""users"",
+    ""Index Name"": ""users_pkey"",
+    ""Total Cost"": 8.29
+  }
+}",,,,,,,,,"psql","client backend",,0
+`
+	log, err := Parse(strings.NewReader(input), FormatAuto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if log.Format != FormatCSV {
+		t.Errorf("Format = %q, want csvlog", log.Format)
+	}
+	if len(log.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(log.Entries))
+	}
+	e := log.Entries[0]
+	if e.Line != 2 || e.Duration != 8.25 || e.Plan.Plan.IndexName != "users_pkey" {
+		t.Errorf("entry = line %d, duration %f, index %q", e.Line, e.Duration, e.Plan.Plan.IndexName)
+	}
+	if e.Query != "SELECT * FROM users WHERE id = 1" {
+		t.Errorf("Query = %q", e.Query)
+	}
+}
+
+func TestParse_JSONLog(t *testing.T) {
+	input := `{"timestamp":"2026-05-01 10:00:00.123 UTC","error_severity":"LOG","message":"checkpoint starting: time"}
+{"timestamp":"2026-05-01 10:00:01.500 UTC","error_severity":"LOG","message":"duration: 2.5 ms  plan:\n{\n  \"Query Text\": \"SELECT 1\",\n  \"Plan\": {\"Node Type\": \"Result\", \"Total Cost\": 0.01}\n}"}
+not json at all
+`
+	log, err := Parse(strings.NewReader(input), FormatAuto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if log.Format != FormatJSON {
+		t.Errorf("Format = %q, want jsonlog", log.Format)
+	}
+	if len(log.Entries) != 1 || log.Entries[0].Line != 2 || log.Entries[0].Plan.Plan.NodeType != "Result" {
+		t.Errorf("Entries = %+v, want one Result plan at line 2", log.Entries)
+	}
+}
+
+func TestParse_YAMLAndXMLPlans(t *testing.T) {
+	input := "2026-05-01 10:00:00 UTC [1] LOG:  duration: 1.0 ms  plan:\n" +
+		"\tQuery Text: \"SELECT 1\"\n" +
+		"\tPlan: \n" +
+		"\t  Node Type: \"Result\"\n" +
+		"2026-05-01 10:00:01 UTC [1] LOG:  duration: 2.0 ms  plan:\n" +
+		"\t<explain xmlns=\"http://www.postgresql.org/2009/explain\">\n" +
+		"\t  <Query-Text>SELECT 2</Query-Text>\n" +
+		"\t  <Plan><Node-Type>Result</Node-Type></Plan>\n" +
+		"\t</explain>\n"
+
+	log, err := Parse(strings.NewReader(input), FormatStderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(log.Entries) != 2 {
+		t.Fatalf("got %d entries (skipped %+v), want 2", len(log.Entries), log.Skipped)
+	}
+	if log.Entries[0].Query != "SELECT 1" || log.Entries[1].Query != "SELECT 2" {
+		t.Errorf("queries = %q, %q", log.Entries[0].Query, log.Entries[1].Query)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("csvlog"); err != nil || f != FormatCSV {
+		t.Errorf("ParseFormat(csvlog) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("syslog"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	cases := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-05-01 10:00:01.5 UTC [42] ", time.Date(2026, 5, 1, 10, 0, 1, 500_000_000, time.UTC)},
+		{"2026-05-01 10:00:01+02 ", time.Date(2026, 5, 1, 8, 0, 1, 0, time.UTC)},
+		{"2026-05-01T10:00:01.000-05:00", time.Date(2026, 5, 1, 15, 0, 1, 0, time.UTC)},
+		{"[42] user@db ", time.Time{}},
+	}
+	for _, tc := range cases {
+		if got := parseTimestamp(tc.in); !got.Equal(tc.want) {
+			t.Errorf("parseTimestamp(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
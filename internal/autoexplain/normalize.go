@@ -0,0 +1,161 @@
+package autoexplain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var inListRe = regexp.MustCompile(`\bin \(\?(?:, \?)*\)`)
+
+// callKeywords are keywords commonly followed by "(" that, unlike function
+// names, keep a space before it.
+var callKeywords = map[string]bool{
+	"in": true, "from": true, "join": true, "as": true, "and": true, "or": true,
+	"not": true, "exists": true, "values": true, "where": true, "on": true,
+	"select": true, "any": true, "all": true, "using": true, "over": true,
+}
+
+// NormalizeQuery reduces query to a fingerprint shared by every execution of
+// the same statement: literals and bind parameters become "?", comments are
+// dropped, spacing is made uniform, unquoted text is lower-cased and IN
+// lists of any length collapse to "in (...)".
+func NormalizeQuery(query string) string {
+	tokens := tokenize(query)
+
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 && spaceBetween(tokens[i-1], tok) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok)
+	}
+
+	normalized := strings.TrimSuffix(b.String(), ";")
+	return inListRe.ReplaceAllString(strings.TrimSpace(normalized), "in (...)")
+}
+
+func tokenize(query string) []string {
+	var tokens []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(query[i:], "--"):
+			i = skipLineComment(query, i)
+			continue
+		case strings.HasPrefix(query[i:], "/*"):
+			i = skipBlockComment(query, i)
+			continue
+		case c == '\'':
+			i = skipQuoted(query, i, '\'')
+			tokens = append(tokens, "?")
+		case c == '"':
+			i = skipQuoted(query, i, '"')
+			tokens = append(tokens, query[start:i])
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			for i++; i < len(query) && isDigit(query[i]); i++ {
+			}
+			tokens = append(tokens, "?")
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			i = skipNumber(query, i)
+			tokens = append(tokens, "?")
+		case isIdentChar(c):
+			for i < len(query) && isIdentChar(query[i]) {
+				i++
+			}
+			tokens = append(tokens, strings.ToLower(query[start:i]))
+		case strings.ContainsRune(operatorChars, rune(c)):
+			for i < len(query) && strings.ContainsRune(operatorChars, rune(query[i])) &&
+				!strings.HasPrefix(query[i:], "--") && !strings.HasPrefix(query[i:], "/*") {
+				i++
+			}
+			tokens = append(tokens, query[start:i])
+		default:
+			i++
+			tokens = append(tokens, query[start:i])
+		}
+	}
+	return tokens
+}
+
+const operatorChars = "+-*/<>=~!@#%^&|:"
+
+func spaceBetween(prev, tok string) bool {
+	switch {
+	case prev == "(" || prev == "[" || prev == "." || prev == "::":
+		return false
+	case tok == ")" || tok == "]" || tok == "," || tok == "." || tok == ";" || tok == "::":
+		return false
+	case tok == "(":
+		return !isIdentChar(prev[len(prev)-1]) || callKeywords[prev]
+	}
+	return true
+}
+
+func skipNumber(s string, i int) int {
+	for i < len(s) && (isDigit(s[i]) || s[i] == '.') {
+		i++
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			for i = j; i < len(s) && isDigit(s[i]); i++ {
+			}
+		}
+	}
+	return i
+}
+
+func skipQuoted(s string, i int, quote byte) int {
+	for i++; i < len(s); i++ {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func skipLineComment(s string, i int) int {
+	if end := strings.IndexByte(s[i:], '\n'); end >= 0 {
+		return i + end + 1
+	}
+	return len(s)
+}
+
+func skipBlockComment(s string, i int) int {
+	depth := 0
+	for i < len(s) {
+		switch {
+		case strings.HasPrefix(s[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(s[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c) || c >= 0x80
+}
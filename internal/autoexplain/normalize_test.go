@@ -0,0 +1,29 @@
+package autoexplain
+
+import "testing"
+
+func TestNormalizeQuery(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"SELECT * FROM t WHERE a = 1 AND b = 'x''y'", "select * from t where a = ? and b = ?"},
+		{"select *\n  from T where a=$1", "select * from t where a = ?"},
+		{"SELECT count( * ) FROM \"Orders\" -- note\nWHERE id = 42;", `select count(*) from "Orders" where id = ?`},
+		{"SELECT 1 FROM t /* a /* nested */ comment */ WHERE x IN (1, 2,3)", "select ? from t where x in (...)"},
+		{"SELECT x::int, t.y FROM t WHERE z > 1.5e3", "select x::int, t.y from t where z > ?"},
+		{"SELECT col1 FROM tab2", "select col1 from tab2"},
+	}
+	for _, tc := range cases {
+		if got := NormalizeQuery(tc.in); got != tc.want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeQuery_SameFingerprintAcrossLiterals(t *testing.T) {
+	a := NormalizeQuery("SELECT * FROM orders WHERE customer_id = 42 AND status IN ('paid')")
+	b := NormalizeQuery("select *\nfrom orders\nwhere customer_id=7 and status in ('paid', 'shipped')")
+	if a != b {
+		t.Errorf("fingerprints differ:\n%q\n%q", a, b)
+	}
+}
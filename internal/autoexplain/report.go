@@ -0,0 +1,124 @@
+package autoexplain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+)
+
+// QueryGroup collects every logged execution of one normalized query.
+// Analysis is the analyzer's result for the slowest execution.
+type QueryGroup struct {
+	Fingerprint string
+	Count       int
+
+	TotalDuration float64 // ms
+	MeanDuration  float64 // ms
+	MaxDuration   float64 // ms
+
+	FirstSeen time.Time
+	LastSeen  time.Time
+
+	// WorstSeverity is the highest finding severity across all executions,
+	// valid only when HasFindings is true.
+	WorstSeverity analyzer.Severity
+	HasFindings   bool
+
+	SlowestQuery string
+	SlowestLine  int
+	SlowestAt    time.Time
+	Analysis     analyzer.AnalysisResult
+}
+
+type Report struct {
+	Format        Format
+	Entries       int
+	Skipped       []Skipped
+	TotalDuration float64 // ms
+	FirstSeen     time.Time
+	LastSeen      time.Time
+
+	// Groups are ordered worst first: by total time spent, then by the
+	// slowest single execution. Queries is the number of groups before any
+	// truncation by the caller.
+	Queries int
+	Groups  []QueryGroup
+}
+
+// Summarize analyzes every entry in log and groups the results by
+// normalized query. blockSize is as for analyzer.Analyze.
+func Summarize(log *Log, blockSize ...int64) Report {
+	report := Report{
+		Format:  log.Format,
+		Entries: len(log.Entries),
+		Skipped: log.Skipped,
+	}
+
+	index := make(map[string]int)
+	for _, entry := range log.Entries {
+		result := analyzer.Analyze(entry.Plan, blockSize...)
+
+		fingerprint := NormalizeQuery(entry.Query)
+		i, ok := index[fingerprint]
+		if !ok {
+			i = len(report.Groups)
+			index[fingerprint] = i
+			report.Groups = append(report.Groups, QueryGroup{Fingerprint: fingerprint})
+		}
+		g := &report.Groups[i]
+
+		g.Count++
+		g.TotalDuration += entry.Duration
+		g.FirstSeen = earliest(g.FirstSeen, entry.Timestamp)
+		g.LastSeen = latest(g.LastSeen, entry.Timestamp)
+		if len(result.Findings) > 0 {
+			// Findings are sorted most severe first.
+			if s := result.Findings[0].Severity; !g.HasFindings || s > g.WorstSeverity {
+				g.WorstSeverity = s
+			}
+			g.HasFindings = true
+		}
+		if g.Count == 1 || entry.Duration > g.MaxDuration {
+			g.MaxDuration = entry.Duration
+			g.SlowestQuery = entry.Query
+			g.SlowestLine = entry.Line
+			g.SlowestAt = entry.Timestamp
+			g.Analysis = result
+		}
+
+		report.TotalDuration += entry.Duration
+		report.FirstSeen = earliest(report.FirstSeen, entry.Timestamp)
+		report.LastSeen = latest(report.LastSeen, entry.Timestamp)
+	}
+
+	report.Queries = len(report.Groups)
+	for i := range report.Groups {
+		g := &report.Groups[i]
+		g.MeanDuration = g.TotalDuration / float64(g.Count)
+	}
+
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.TotalDuration != b.TotalDuration {
+			return a.TotalDuration > b.TotalDuration
+		}
+		return a.MaxDuration > b.MaxDuration
+	})
+
+	return report
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,61 @@
+package autoexplain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func entry(query string, duration float64, minute int) Entry {
+	return Entry{
+		Timestamp: time.Date(2026, 5, 1, 10, minute, 0, 0, time.UTC),
+		Duration:  duration,
+		Query:     query,
+		Plan: plan.ExplainOutput{
+			Plan:          plan.PlanNode{NodeType: "Result", ActualLoops: 1},
+			ExecutionTime: duration,
+		},
+	}
+}
+
+func TestSummarize_GroupsAndRanks(t *testing.T) {
+	log := &Log{
+		Format: FormatStderr,
+		Entries: []Entry{
+			entry("SELECT * FROM t WHERE id = 1", 10, 5),
+			entry("SELECT * FROM u", 50, 1),
+			entry("SELECT * FROM t WHERE id = 2", 100, 2),
+			entry("SELECT * FROM t WHERE id = 3", 30, 9),
+		},
+	}
+
+	report := Summarize(log)
+
+	if report.Entries != 4 || report.Queries != 2 {
+		t.Fatalf("Entries/Queries = %d/%d, want 4/2", report.Entries, report.Queries)
+	}
+	if report.TotalDuration != 190 {
+		t.Errorf("TotalDuration = %f, want 190", report.TotalDuration)
+	}
+
+	g := report.Groups[0]
+	if g.Fingerprint != "select * from t where id = ?" {
+		t.Errorf("Groups[0].Fingerprint = %q, want the t query first", g.Fingerprint)
+	}
+	if g.Count != 3 || g.TotalDuration != 140 || g.MaxDuration != 100 {
+		t.Errorf("Groups[0] count/total/max = %d/%f/%f, want 3/140/100", g.Count, g.TotalDuration, g.MaxDuration)
+	}
+	if g.MeanDuration != 140.0/3 {
+		t.Errorf("Groups[0].MeanDuration = %f, want %f", g.MeanDuration, 140.0/3)
+	}
+	if g.SlowestQuery != "SELECT * FROM t WHERE id = 2" {
+		t.Errorf("Groups[0].SlowestQuery = %q", g.SlowestQuery)
+	}
+	if g.FirstSeen.Minute() != 2 || g.LastSeen.Minute() != 9 {
+		t.Errorf("Groups[0] seen %v → %v, want minutes 2 → 9", g.FirstSeen, g.LastSeen)
+	}
+	if report.FirstSeen.Minute() != 1 {
+		t.Errorf("report FirstSeen = %v, want minute 1", report.FirstSeen)
+	}
+}
@@ -0,0 +1,247 @@
+// Package baseline stores known-good plans for a directory of SQL files and
+// checks fresh plans against them.
+//
+// A baseline directory holds manifest.json, which lists every statement of
+// every SQL file, and one plan file per statement under plans/, named after
+// the SQL file and the statement's 1-based index:
+//
+//	manifest.json
+//	plans/reports/daily.sql/1.json
+//	plans/reports/daily.sql/2.json
+//
+// Plan files are EXPLAIN (FORMAT JSON) documents, so any pgplan command can
+// read them.
+package baseline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// FormatVersion is the version of the baseline layout this package writes.
+// Check refuses baselines written by a newer version.
+const FormatVersion = 1
+
+const (
+	manifestFile = "manifest.json"
+	plansDir     = "plans"
+)
+
+// Manifest describes a baseline directory.
+type Manifest struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is the baseline plan of one statement.
+type Entry struct {
+	// File is the SQL file's path relative to the SQL directory, with
+	// forward slashes.
+	File string `json:"file"`
+	// Statement is the 1-based index of the statement's plan in File.
+	Statement int    `json:"statement"`
+	Query     string `json:"query,omitempty"`
+	// Plan is the plan file's path relative to the baseline directory.
+	Plan string `json:"plan"`
+}
+
+func (e Entry) key() string {
+	return e.File + "#" + strconv.Itoa(e.Statement)
+}
+
+// Options says where the SQL files and the baseline are and how to plan the
+// statements.
+type Options struct {
+	SQLDir      string
+	BaselineDir string
+	DBConn      string
+	Exec        plan.ExecOptions
+}
+
+// Save plans every statement of every .sql file under opts.SQLDir and
+// writes the plans to opts.BaselineDir, replacing any baseline there.
+func Save(ctx context.Context, opts Options) (Manifest, error) {
+	files, err := sqlFiles(opts.SQLDir, opts.BaselineDir)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{Version: FormatVersion, Entries: []Entry{}}
+	planned := make(map[string]plan.ExplainOutput)
+	for _, file := range files {
+		plans, err := plan.ResolveAll(ctx, filepath.Join(opts.SQLDir, filepath.FromSlash(file)), opts.DBConn, file+" ", opts.Exec)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("%s: %w", file, err)
+		}
+		for i, p := range plans {
+			entry := Entry{
+				File:      file,
+				Statement: i + 1,
+				Query:     p.QueryText,
+				Plan:      path.Join(plansDir, file, strconv.Itoa(i+1)+".json"),
+			}
+			manifest.Entries = append(manifest.Entries, entry)
+			planned[entry.Plan] = p
+		}
+	}
+
+	// Only replace the old baseline once every statement has planned.
+	if err := replace(opts.BaselineDir, manifest, planned); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
+
+// replace writes manifest and its plans to dir, replacing the baseline
+// there. Both are written to a temporary directory inside dir first and
+// moved into place once complete, so a failed write leaves the old baseline
+// as it was.
+func replace(dir string, manifest Manifest, planned map[string]plan.ExplainOutput) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating baseline directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(dir, ".new-")
+	if err != nil {
+		return fmt.Errorf("creating baseline directory: %w", err)
+	}
+	// The staging directory is kept if it holds the old plans that couldn't
+	// be moved back.
+	keep := false
+	defer func() {
+		if !keep {
+			_ = os.RemoveAll(staging)
+		}
+	}()
+
+	if err := os.Mkdir(filepath.Join(staging, plansDir), 0o755); err != nil {
+		return fmt.Errorf("creating baseline directory: %w", err)
+	}
+	for _, entry := range manifest.Entries {
+		if err := writeJSON(filepath.Join(staging, filepath.FromSlash(entry.Plan)), []plan.ExplainOutput{planned[entry.Plan]}); err != nil {
+			return err
+		}
+	}
+	if err := writeJSON(filepath.Join(staging, manifestFile), manifest); err != nil {
+		return err
+	}
+
+	// The old plans are moved into the staging directory, to be removed
+	// with it, or moved back if the new baseline can't be put in place.
+	plans, oldPlans := filepath.Join(dir, plansDir), filepath.Join(staging, "old")
+	if err := os.Rename(plans, oldPlans); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("replacing baseline: %w", err)
+	}
+	restore := func(err error) error {
+		err = fmt.Errorf("replacing baseline: %w", err)
+		_ = os.RemoveAll(plans)
+		if rerr := os.Rename(oldPlans, plans); rerr != nil && !errors.Is(rerr, fs.ErrNotExist) {
+			keep = true
+			return errors.Join(err, fmt.Errorf("restoring the old plans, left in %s: %w", oldPlans, rerr))
+		}
+		return err
+	}
+	if err := os.Rename(filepath.Join(staging, plansDir), plans); err != nil {
+		return restore(err)
+	}
+	if err := os.Rename(filepath.Join(staging, manifestFile), filepath.Join(dir, manifestFile)); err != nil {
+		return restore(err)
+	}
+	return nil
+}
+
+// Load reads the manifest of the baseline in dir.
+func Load(dir string) (Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Manifest{}, fmt.Errorf("no baseline in %s: run \"pgplan baseline save\" first", dir)
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("reading baseline manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("parsing baseline manifest: %w", err)
+	}
+	if manifest.Version > FormatVersion {
+		return Manifest{}, fmt.Errorf("baseline in %s has format version %d, newer than this pgplan supports (%d); upgrade pgplan", dir, manifest.Version, FormatVersion)
+	}
+	return manifest, nil
+}
+
+// loadPlan reads an entry's baseline plan.
+func loadPlan(dir string, entry Entry) (plan.ExplainOutput, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Plan)))
+	if err != nil {
+		return plan.ExplainOutput{}, fmt.Errorf("reading baseline plan: %w", err)
+	}
+	plans, err := plan.ParseJSONPlan(data)
+	if err != nil {
+		return plan.ExplainOutput{}, fmt.Errorf("baseline plan %s: %w", entry.Plan, err)
+	}
+	if len(plans) == 0 {
+		return plan.ExplainOutput{}, fmt.Errorf("baseline plan %s is empty", entry.Plan)
+	}
+	return plans[0], nil
+}
+
+// sqlFiles returns the .sql files under dir, relative to it and in path
+// order, skipping the baseline directory itself.
+func sqlFiles(dir, baselineDir string) ([]string, error) {
+	skip, _ := filepath.Abs(baselineDir)
+
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if abs, _ := filepath.Abs(p); abs == skip {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(p), ".sql") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing SQL files: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql files in %s", dir)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating baseline directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing baseline: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package baseline
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "users.sql"), "SELECT 1;")
+	writeFile(t, filepath.Join(dir, "reports", "daily.SQL"), "SELECT 2;")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not sql")
+	writeFile(t, filepath.Join(dir, ".pgplan-baseline", "stale.sql"), "SELECT 3;")
+
+	got, err := sqlFiles(dir, filepath.Join(dir, ".pgplan-baseline"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"reports/daily.SQL", "users.sql"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sqlFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestSQLFiles_Empty(t *testing.T) {
+	if _, err := sqlFiles(t.TempDir(), ""); err == nil {
+		t.Error("expected an error for a directory without SQL files")
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	_, err := Load(t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "pgplan baseline save") {
+		t.Errorf("Load() error = %v, want a hint to save a baseline first", err)
+	}
+}
+
+func TestLoad_NewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, manifestFile), `{"version": 99, "entries": []}`)
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "upgrade pgplan") {
+		t.Errorf("Load() error = %v, want a newer-version error", err)
+	}
+}
+
+func TestManifestAndPlanRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	entry := Entry{File: "reports/daily.sql", Statement: 2, Query: "SELECT 2", Plan: "plans/reports/daily.sql/2.json"}
+	saved := plan.ExplainOutput{Plan: plan.PlanNode{NodeType: "Seq Scan", RelationName: "orders", TotalCost: 42}, ExecutionTime: 1.5}
+
+	if err := writeJSON(filepath.Join(dir, filepath.FromSlash(entry.Plan)), []plan.ExplainOutput{saved}); err != nil {
+		t.Fatalf("writing plan: %v", err)
+	}
+	if err := writeJSON(filepath.Join(dir, manifestFile), Manifest{Version: FormatVersion, Entries: []Entry{entry}}); err != nil {
+		t.Fatalf("writing manifest: %v", err)
+	}
+
+	manifest, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(manifest.Entries) != 1 || manifest.Entries[0] != entry {
+		t.Fatalf("Load() entries = %+v, want [%+v]", manifest.Entries, entry)
+	}
+
+	got, err := loadPlan(dir, manifest.Entries[0])
+	if err != nil {
+		t.Fatalf("loadPlan() error: %v", err)
+	}
+	if got.Plan.NodeType != "Seq Scan" || got.Plan.RelationName != "orders" || got.Plan.TotalCost != 42 || got.ExecutionTime != 1.5 {
+		t.Errorf("loadPlan() = %+v, want the saved plan back", got)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, manifestFile), `{"version": 1, "entries": [{"file": "old.sql", "statement": 1, "plan": "plans/old.sql/1.json"}]}`)
+	writeFile(t, filepath.Join(dir, "plans", "old.sql", "1.json"), `[{"Plan": {"Node Type": "Result"}}]`)
+
+	entry := Entry{File: "new.sql", Statement: 1, Plan: "plans/new.sql/1.json"}
+	planned := map[string]plan.ExplainOutput{entry.Plan: {Plan: plan.PlanNode{NodeType: "Seq Scan", RelationName: "orders"}}}
+	if err := replace(dir, Manifest{Version: FormatVersion, Entries: []Entry{entry}}, planned); err != nil {
+		t.Fatalf("replace() error: %v", err)
+	}
+
+	manifest, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(manifest.Entries) != 1 || manifest.Entries[0] != entry {
+		t.Fatalf("Load() entries = %+v, want [%+v]", manifest.Entries, entry)
+	}
+	if got, err := loadPlan(dir, entry); err != nil || got.Plan.RelationName != "orders" {
+		t.Errorf("loadPlan() = %+v, %v; want the new plan", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "plans", "old.sql")); !os.IsNotExist(err) {
+		t.Errorf("old plan still present: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !slices.Equal(names, []string{manifestFile, plansDir}) {
+		t.Errorf("baseline directory holds %v, want only the manifest and plans", names)
+	}
+}
+
+func TestReplace_RestoresOldPlans(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "plans", "old.sql", "1.json"), `[{"Plan": {"Node Type": "Result"}}]`)
+	// A directory where the manifest goes makes its rename fail after the
+	// plans were swapped.
+	writeFile(t, filepath.Join(dir, manifestFile, "keep"), "")
+
+	entry := Entry{File: "new.sql", Statement: 1, Plan: "plans/new.sql/1.json"}
+	planned := map[string]plan.ExplainOutput{entry.Plan: {Plan: plan.PlanNode{NodeType: "Seq Scan"}}}
+	if err := replace(dir, Manifest{Version: FormatVersion, Entries: []Entry{entry}}, planned); err == nil {
+		t.Fatal("replace() succeeded, want an error")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "plans", "old.sql", "1.json")); err != nil {
+		t.Errorf("old plan not restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "plans", "new.sql")); !os.IsNotExist(err) {
+		t.Errorf("new plan left in place: %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, ".new-*"))
+	if len(matches) != 0 {
+		t.Errorf("staging directory left behind: %v", matches)
+	}
+}
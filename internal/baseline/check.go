@@ -0,0 +1,158 @@
+package baseline
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// Status is the outcome of checking one statement.
+type Status string
+
+const (
+	// StatusPass: the plan has the baseline's shape and no regression
+	// beyond the limits.
+	StatusPass Status = "pass"
+	// StatusFail: the plan's shape changed, or it regressed.
+	StatusFail Status = "fail"
+	// StatusNew: the statement has no baseline plan.
+	StatusNew Status = "new"
+	// StatusMissing: the baseline has a plan for a statement that no
+	// longer exists.
+	StatusMissing Status = "missing"
+)
+
+// StatementCheck is the outcome of checking one statement.
+type StatementCheck struct {
+	File      string
+	Statement int
+	Query     string
+	Status    Status
+
+	// ShapeChanged is true when the planner chose a different plan than
+	// the baseline's, whether or not it is slower.
+	ShapeChanged bool
+	// Reasons explains a failure: the shape change and each limit broken.
+	Reasons []string
+
+	// Comparison compares the baseline plan (old) with the fresh one
+	// (new), for statements in both.
+	Comparison *comparator.ComparisonResult
+}
+
+// Report is the outcome of Check.
+type Report struct {
+	Baseline string
+	Passed   bool
+
+	// Statements are in SQL file and statement order, followed by any
+	// baseline statements that no longer exist.
+	Statements []StatementCheck
+
+	Failed int // statements that didn't pass
+}
+
+// Check plans every statement under opts.SQLDir again and compares each with
+// its baseline plan. A statement fails when its plan shape changed, even if
+// its timing is within threshold, or when it regressed beyond limits.
+// Statements without a baseline plan, and baseline plans without a
+// statement, fail too, so the baseline can't silently fall out of date.
+func Check(ctx context.Context, opts Options, c *comparator.Comparator, limits gate.Limits) (Report, error) {
+	manifest, err := Load(opts.BaselineDir)
+	if err != nil {
+		return Report{}, err
+	}
+	files, err := sqlFiles(opts.SQLDir, opts.BaselineDir)
+	if err != nil {
+		return Report{}, err
+	}
+
+	entries := make(map[string]Entry, len(manifest.Entries))
+	for _, e := range manifest.Entries {
+		entries[e.key()] = e
+	}
+
+	report := Report{Baseline: opts.BaselineDir}
+	seen := make(map[string]bool)
+	for _, file := range files {
+		plans, err := plan.ResolveAll(ctx, filepath.Join(opts.SQLDir, filepath.FromSlash(file)), opts.DBConn, file+" ", opts.Exec)
+		if err != nil {
+			return Report{}, fmt.Errorf("%s: %w", file, err)
+		}
+
+		for i, p := range plans {
+			entry, ok := entries[Entry{File: file, Statement: i + 1}.key()]
+			if !ok {
+				report.Statements = append(report.Statements, StatementCheck{
+					File:      file,
+					Statement: i + 1,
+					Query:     p.QueryText,
+					Status:    StatusNew,
+					Reasons:   []string{"no baseline plan; run \"pgplan baseline save\" to add it"},
+				})
+				continue
+			}
+			seen[entry.key()] = true
+
+			old, err := loadPlan(opts.BaselineDir, entry)
+			if err != nil {
+				return Report{}, err
+			}
+			check := checkStatement(old, p, c, limits)
+			check.File, check.Statement, check.Query = file, i+1, p.QueryText
+			report.Statements = append(report.Statements, check)
+		}
+	}
+
+	for _, e := range manifest.Entries {
+		if !seen[e.key()] {
+			report.Statements = append(report.Statements, StatementCheck{
+				File:      e.File,
+				Statement: e.Statement,
+				Query:     e.Query,
+				Status:    StatusMissing,
+				Reasons:   []string{"baseline plan has no matching statement; run \"pgplan baseline save\" to drop it"},
+			})
+		}
+	}
+
+	for _, s := range report.Statements {
+		if s.Status != StatusPass {
+			report.Failed++
+		}
+	}
+	report.Passed = report.Failed == 0
+	return report, nil
+}
+
+// checkStatement compares a statement's baseline plan (old) with its fresh
+// one.
+func checkStatement(old, new plan.ExplainOutput, c *comparator.Comparator, limits gate.Limits) StatementCheck {
+	result := c.Compare(old, new)
+	check := StatementCheck{
+		Status:       StatusPass,
+		ShapeChanged: !plan.SameShape(&old.Plan, &new.Plan),
+		Comparison:   &result,
+	}
+
+	if check.ShapeChanged {
+		reason := "plan shape changed"
+		if s := result.Summary; s.NodesTypeChanged+s.NodesAdded+s.NodesRemoved+s.NodesMoved > 0 {
+			reason += fmt.Sprintf(": %d node(s) changed type, %d added, %d removed, %d moved", s.NodesTypeChanged, s.NodesAdded, s.NodesRemoved, s.NodesMoved)
+		} else {
+			reason += ": different relations or indexes"
+		}
+		check.Reasons = append(check.Reasons, reason)
+	}
+	for _, v := range limits.Check(result).Violations {
+		check.Reasons = append(check.Reasons, v.Message)
+	}
+	if len(check.Reasons) > 0 {
+		check.Status = StatusFail
+	}
+	return check
+}
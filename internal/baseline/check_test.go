@@ -0,0 +1,76 @@
+package baseline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func scanPlan(nodeType, index string, cost, time float64) plan.ExplainOutput {
+	return plan.ExplainOutput{
+		Plan: plan.PlanNode{
+			NodeType:        nodeType,
+			RelationName:    "orders",
+			IndexName:       index,
+			TotalCost:       cost,
+			ActualTotalTime: time,
+			ActualLoops:     1,
+		},
+		ExecutionTime: time,
+	}
+}
+
+func anyRegression() gate.Limits {
+	zero := 0.0
+	return gate.Limits{Time: &zero, Cost: &zero}
+}
+
+func TestCheckStatement_Pass(t *testing.T) {
+	c := &comparator.Comparator{Threshold: 5}
+	old := scanPlan("Index Scan", "orders_pkey", 10, 1.0)
+	got := checkStatement(old, scanPlan("Index Scan", "orders_pkey", 10, 1.02), c, anyRegression())
+	if got.Status != StatusPass || got.ShapeChanged || len(got.Reasons) != 0 {
+		t.Errorf("checkStatement() = %+v, want a pass", got)
+	}
+}
+
+func TestCheckStatement_ShapeChangeWithinThreshold(t *testing.T) {
+	c := &comparator.Comparator{Threshold: 5}
+	old := scanPlan("Index Scan", "orders_pkey", 10, 1.0)
+	got := checkStatement(old, scanPlan("Index Scan", "orders_created_at_idx", 10, 1.0), c, anyRegression())
+	if got.Status != StatusFail || !got.ShapeChanged {
+		t.Fatalf("checkStatement() = %+v, want a failed shape change", got)
+	}
+	if len(got.Reasons) != 1 || !strings.Contains(got.Reasons[0], "different relations or indexes") {
+		t.Errorf("reasons = %v, want one shape change reason", got.Reasons)
+	}
+}
+
+func TestCheckStatement_NodeTypeChange(t *testing.T) {
+	c := &comparator.Comparator{Threshold: 5}
+	old := scanPlan("Index Scan", "orders_pkey", 10, 1.0)
+	got := checkStatement(old, scanPlan("Seq Scan", "", 10, 1.0), c, anyRegression())
+	if len(got.Reasons) == 0 || !strings.Contains(got.Reasons[0], "1 node(s) changed type") {
+		t.Errorf("reasons = %v, want a node type change", got.Reasons)
+	}
+}
+
+func TestCheckStatement_Regression(t *testing.T) {
+	c := &comparator.Comparator{Threshold: 5}
+	old := scanPlan("Index Scan", "orders_pkey", 10, 1.0)
+	got := checkStatement(old, scanPlan("Index Scan", "orders_pkey", 10, 2.0), c, anyRegression())
+	if got.Status != StatusFail || got.ShapeChanged {
+		t.Fatalf("checkStatement() = %+v, want a failed regression with the same shape", got)
+	}
+	if len(got.Reasons) != 1 || !strings.Contains(got.Reasons[0], "execution time regressed") {
+		t.Errorf("reasons = %v, want an execution time regression", got.Reasons)
+	}
+
+	allowed := 200.0
+	if got := checkStatement(old, scanPlan("Index Scan", "orders_pkey", 10, 2.0), c, gate.Limits{Time: &allowed}); got.Status != StatusPass {
+		t.Errorf("checkStatement() = %+v, want a pass within the limit", got)
+	}
+}
@@ -0,0 +1,382 @@
+// Package batch analyzes many input files at once, a bounded number at a
+// time, and summarizes the results across all of them.
+package batch
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// inputExtensions are the file extensions picked up from directories: SQL
+// scripts and EXPLAIN output in every supported format.
+var inputExtensions = []string{".sql", ".json", ".yaml", ".yml", ".xml", ".txt"}
+
+// IsBatch reports whether args call for a batch: more than one input, a
+// directory, or a glob pattern.
+func IsBatch(args []string) bool {
+	if len(args) > 1 {
+		return true
+	}
+	for _, arg := range args {
+		if isGlob(arg) {
+			return true
+		}
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+func isGlob(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// Expand turns files, directories and glob patterns into the list of files
+// to analyze, in argument order and without duplicates. Directories are
+// walked recursively for SQL and plan files, skipping hidden directories
+// such as .git or a pgplan baseline.
+func Expand(args []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, arg := range args {
+		paths := []string{arg}
+		if isGlob(arg) {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+			paths = matches
+		}
+
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(p)
+				continue
+			}
+			found, err := walk(p)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range found {
+				add(f)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no SQL or plan files in %s", strings.Join(args, ", "))
+	}
+	return files, nil
+}
+
+// walk returns the input files under dir in path order.
+func walk(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if slices.Contains(inputExtensions, strings.ToLower(filepath.Ext(p))) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", dir, err)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// Options says how to plan and analyze each file.
+type Options struct {
+	DBConn    string
+	Exec      plan.ExecOptions
+	BlockSize int64
+
+	// Catalog, with DBConn, loads the catalog of the relations each SQL
+	// file's plans scan, so rules can take indexes and statistics into
+	// account: see plan.LoadCatalog. PlanCatalog loads it for plan files
+	// too, which may have come from another database.
+	Catalog     bool
+	PlanCatalog bool
+
+	// Jobs is the number of files analyzed at a time. Each SQL file runs on
+	// its own connection, so it also bounds the connections open at once.
+	Jobs int
+}
+
+// FileResult is the outcome of analyzing one file: its analysis, or the
+// error that stopped it. Warning reports a problem that didn't, such as a
+// catalog that couldn't be loaded.
+type FileResult struct {
+	File    string
+	Result  *analyzer.MultiAnalysisResult `json:",omitempty"`
+	Error   string                        `json:",omitempty"`
+	Warning string                        `json:",omitempty"`
+}
+
+// Run analyzes files, opts.Jobs at a time, calling each with every file's
+// result as it completes. each is called from one goroutine at a time. A
+// file that fails doesn't stop the others. When ctx is canceled, files not
+// yet started are left out of the report.
+func Run(ctx context.Context, files []string, opts Options, each func(FileResult)) Report {
+	type indexed struct {
+		i      int
+		result FileResult
+	}
+
+	jobs := min(max(opts.Jobs, 1), len(files))
+	pending := make(chan int)
+	done := make(chan indexed)
+
+	go func() {
+		defer close(pending)
+		for i := range files {
+			select {
+			case pending <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pending {
+				done <- indexed{i, analyzeFile(ctx, files[i], opts)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	results := make([]*FileResult, len(files))
+	for d := range done {
+		results[d.i] = &d.result
+		if each != nil {
+			each(d.result)
+		}
+	}
+
+	var completed []FileResult
+	for _, r := range results {
+		if r != nil {
+			completed = append(completed, *r)
+		}
+	}
+	return Summarize(completed)
+}
+
+func analyzeFile(ctx context.Context, file string, opts Options) FileResult {
+	plans, err := plan.ResolveAll(ctx, file, opts.DBConn, file+" ", opts.Exec)
+	if err != nil {
+		return FileResult{File: file, Error: err.Error()}
+	}
+	analysis := analyzer.Options{BlockSize: opts.BlockSize}
+	var warning string
+	if opts.Catalog && opts.DBConn != "" && (opts.PlanCatalog || slices.ContainsFunc(plans, func(p plan.ExplainOutput) bool { return p.FromSQL })) {
+		if analysis.Catalog, err = plan.LoadCatalog(ctx, opts.DBConn, plans, opts.Exec); err != nil {
+			warning = fmt.Sprintf("catalog not loaded: %v", err)
+		}
+	}
+	result := analyzer.AnalyzeAllWith(plans, analysis)
+	return FileResult{File: file, Result: &result, Warning: warning}
+}
+
+// Report is the outcome of a batch: every file's result, in input order,
+// and a summary across them.
+type Report struct {
+	Files   []FileResult
+	Summary Summary
+}
+
+// Summary aggregates the findings and timings of every file in a batch.
+type Summary struct {
+	Files      int
+	Failed     int
+	Statements int
+
+	TotalCost     float64
+	ExecutionTime float64 // ms, summed across statements
+
+	Critical int
+	Warnings int
+	Infos    int
+
+	// Rules are the rules that produced findings, most frequent first.
+	Rules []RuleCount
+	// Relations are the relations findings were about, most affected
+	// first.
+	Relations []RelationCount
+	// Slowest are every statement, slowest first: by execution time, then,
+	// for statements without ANALYZE data, by estimated cost.
+	Slowest []StatementTiming
+}
+
+// RuleCount is how often a rule fired across a batch.
+type RuleCount struct {
+	Rule     string
+	Findings int
+	Files    int
+	// Severity is the highest severity the rule reported.
+	Severity analyzer.Severity
+}
+
+// RelationCount is how many findings a relation drew across a batch.
+type RelationCount struct {
+	Relation string
+	Findings int
+	Critical int
+	Files    int
+}
+
+// StatementTiming locates one statement of a batch and its cost.
+type StatementTiming struct {
+	File          string
+	Statement     int
+	Query         string
+	ExecutionTime float64
+	TotalCost     float64
+	EstimateOnly  bool
+}
+
+// Summarize builds the report for results, which should be in input order.
+func Summarize(results []FileResult) Report {
+	report := Report{Files: results}
+	s := &report.Summary
+	s.Files = len(results)
+
+	rules := make(map[string]*RuleCount)
+	relations := make(map[string]*RelationCount)
+	for _, r := range results {
+		if r.Result == nil {
+			s.Failed++
+			continue
+		}
+
+		fileRules := make(map[string]bool)
+		fileRelations := make(map[string]bool)
+		for _, stmt := range r.Result.Statements {
+			s.Statements++
+			s.TotalCost += stmt.Result.TotalCost
+			s.ExecutionTime += stmt.Result.ExecutionTime
+			s.Slowest = append(s.Slowest, StatementTiming{
+				File:          r.File,
+				Statement:     stmt.Index,
+				Query:         stmt.Query,
+				ExecutionTime: stmt.Result.ExecutionTime,
+				TotalCost:     stmt.Result.TotalCost,
+				EstimateOnly:  stmt.Result.EstimateOnly,
+			})
+
+			for _, f := range stmt.Result.Findings {
+				switch f.Severity {
+				case analyzer.Critical:
+					s.Critical++
+				case analyzer.Warning:
+					s.Warnings++
+				default:
+					s.Infos++
+				}
+
+				rc, ok := rules[f.Rule]
+				if !ok {
+					rc = &RuleCount{Rule: f.Rule, Severity: f.Severity}
+					rules[f.Rule] = rc
+				}
+				rc.Findings++
+				rc.Severity = max(rc.Severity, f.Severity)
+				if !fileRules[f.Rule] {
+					fileRules[f.Rule] = true
+					rc.Files++
+				}
+
+				if f.Relation == "" {
+					continue
+				}
+				rel, ok := relations[f.Relation]
+				if !ok {
+					rel = &RelationCount{Relation: f.Relation}
+					relations[f.Relation] = rel
+				}
+				rel.Findings++
+				if f.Severity == analyzer.Critical {
+					rel.Critical++
+				}
+				if !fileRelations[f.Relation] {
+					fileRelations[f.Relation] = true
+					rel.Files++
+				}
+			}
+		}
+	}
+
+	for _, rc := range rules {
+		s.Rules = append(s.Rules, *rc)
+	}
+	slices.SortFunc(s.Rules, func(a, b RuleCount) int {
+		return cmp.Or(
+			cmp.Compare(b.Findings, a.Findings),
+			cmp.Compare(b.Files, a.Files),
+			cmp.Compare(a.Rule, b.Rule),
+		)
+	})
+
+	for _, rel := range relations {
+		s.Relations = append(s.Relations, *rel)
+	}
+	slices.SortFunc(s.Relations, func(a, b RelationCount) int {
+		return cmp.Or(
+			cmp.Compare(b.Findings, a.Findings),
+			cmp.Compare(b.Critical, a.Critical),
+			cmp.Compare(a.Relation, b.Relation),
+		)
+	})
+
+	// Stable, so ties keep input order.
+	slices.SortStableFunc(s.Slowest, func(a, b StatementTiming) int {
+		return cmp.Or(
+			cmp.Compare(b.ExecutionTime, a.ExecutionTime),
+			cmp.Compare(b.TotalCost, a.TotalCost),
+		)
+	})
+
+	return report
+}
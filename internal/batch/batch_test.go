@@ -0,0 +1,199 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+)
+
+const sortSpillPlan = `[{"Plan": {"Node Type": "Sort", "Total Cost": 500, "Actual Rows": 1000, "Actual Loops": 1, "Actual Total Time": 50,
+  "Sort Method": "external merge", "Sort Space Used": 4000, "Sort Space Type": "Disk"}, "Execution Time": 55.2}]`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsBatch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "q.json")
+	writeFile(t, file, sortSpillPlan)
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"-"}, false},
+		{[]string{file}, false},
+		{[]string{dir}, true},
+		{[]string{filepath.Join(dir, "*.json")}, true},
+		{[]string{file, file}, true},
+	}
+	for _, tt := range tests {
+		if got := IsBatch(tt.args); got != tt.want {
+			t.Errorf("IsBatch(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "b.sql"), "SELECT 1;")
+	writeFile(t, filepath.Join(dir, "a.json"), sortSpillPlan)
+	writeFile(t, filepath.Join(dir, "sub", "c.yaml"), "- Plan: {}")
+	writeFile(t, filepath.Join(dir, "notes.md"), "# notes")
+	writeFile(t, filepath.Join(dir, ".pgplan-baseline", "manifest.json"), "{}")
+
+	got, err := Expand([]string{filepath.Join(dir, "*.sql"), dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "b.sql"),
+		filepath.Join(dir, "a.json"),
+		filepath.Join(dir, "sub", "c.yaml"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expand() = %v, want %v", got, want)
+	}
+}
+
+func TestExpand_NoMatches(t *testing.T) {
+	if _, err := Expand([]string{filepath.Join(t.TempDir(), "*.sql")}); err == nil {
+		t.Error("expected an error for a pattern without matches")
+	}
+	if _, err := Expand([]string{t.TempDir()}); err == nil {
+		t.Error("expected an error for a directory without input files")
+	}
+}
+
+func TestRun_ReportsFailuresWithoutStopping(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.json", "b.sql", "c.json", "d.json"} {
+		path := filepath.Join(dir, name)
+		writeFile(t, path, sortSpillPlan)
+		files = append(files, path)
+	}
+	writeFile(t, files[1], "SELECT 1;") // no connection: fails
+
+	var streamed int
+	report := Run(context.Background(), files, Options{Jobs: 2}, func(FileResult) { streamed++ })
+
+	if streamed != len(files) {
+		t.Errorf("streamed %d results, want %d", streamed, len(files))
+	}
+	if s := report.Summary; s.Files != 4 || s.Failed != 1 || s.Statements != 3 || s.Critical != 3 {
+		t.Errorf("summary = %+v, want 4 files, 1 failed, 3 statements, 3 critical", s)
+	}
+	for i, r := range report.Files {
+		if r.File != files[i] {
+			t.Errorf("result %d is %s, want %s: results must keep input order", i, r.File, files[i])
+		}
+	}
+	if report.Files[1].Error == "" || report.Files[1].Result != nil {
+		t.Errorf("result for SQL without a connection = %+v, want an error", report.Files[1])
+	}
+}
+
+func TestRun_CatalogOnlyForSQLUnlessAsked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	writeFile(t, path, `[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "orders", "Total Cost": 100, "Plan Rows": 10}}]`)
+	opts := Options{DBConn: "postgres://127.0.0.1:1/none?connect_timeout=1", Catalog: true, Jobs: 1}
+
+	report := Run(context.Background(), []string{path}, opts, nil)
+	if r := report.Files[0]; r.Result == nil || r.Error != "" || r.Warning != "" {
+		t.Errorf("plan file result = %+v, want it analyzed without touching the database", r)
+	}
+
+	opts.PlanCatalog = true
+	report = Run(context.Background(), []string{path}, opts, nil)
+	if r := report.Files[0]; r.Result == nil || r.Error != "" || r.Warning == "" {
+		t.Errorf("plan file result = %+v, want it analyzed with a warning for the unreachable catalog", r)
+	}
+}
+
+func TestRun_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := Run(ctx, []string{"a.json", "b.json"}, Options{Jobs: 1}, nil)
+	if len(report.Files) > 1 {
+		t.Errorf("got %d results after cancellation, want at most the one in flight", len(report.Files))
+	}
+}
+
+func statement(index int, query string, time, cost float64, findings ...analyzer.Finding) analyzer.StatementResult {
+	return analyzer.StatementResult{
+		Index: index,
+		Query: query,
+		Result: analyzer.AnalysisResult{
+			ExecutionTime: time,
+			TotalCost:     cost,
+			EstimateOnly:  time == 0,
+			Findings:      findings,
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	seqScan := analyzer.Finding{Rule: "Seq Scan with Filter", Severity: analyzer.Warning, Relation: "orders"}
+	seqScanCritical := analyzer.Finding{Rule: "Seq Scan with Filter", Severity: analyzer.Critical, Relation: "orders"}
+	sortSpill := analyzer.Finding{Rule: "Sort Spill to Disk", Severity: analyzer.Critical}
+	wide := analyzer.Finding{Rule: "Wide Row Output", Severity: analyzer.Info, Relation: "users"}
+
+	report := Summarize([]FileResult{
+		{File: "a.sql", Result: &analyzer.MultiAnalysisResult{Statements: []analyzer.StatementResult{
+			statement(1, "SELECT a", 10, 100, seqScan, sortSpill),
+			statement(2, "SELECT b", 90, 50, seqScanCritical),
+		}}},
+		{File: "b.sql", Error: "connecting to database: refused"},
+		{File: "c.sql", Result: &analyzer.MultiAnalysisResult{Statements: []analyzer.StatementResult{
+			statement(1, "SELECT c", 40, 10, seqScan, wide),
+		}}},
+	})
+
+	s := report.Summary
+	if s.Files != 3 || s.Failed != 1 || s.Statements != 3 {
+		t.Errorf("counts = %d files, %d failed, %d statements; want 3, 1, 3", s.Files, s.Failed, s.Statements)
+	}
+	if s.Critical != 2 || s.Warnings != 2 || s.Infos != 1 {
+		t.Errorf("severities = %d/%d/%d, want 2/2/1", s.Critical, s.Warnings, s.Infos)
+	}
+
+	if len(s.Rules) != 3 || s.Rules[0] != (RuleCount{Rule: "Seq Scan with Filter", Findings: 3, Files: 2, Severity: analyzer.Critical}) {
+		t.Errorf("rules = %+v, want Seq Scan with Filter first: 3 findings in 2 files", s.Rules)
+	}
+	if len(s.Relations) != 2 || s.Relations[0] != (RelationCount{Relation: "orders", Findings: 3, Critical: 1, Files: 2}) {
+		t.Errorf("relations = %+v, want orders first: 3 findings, 1 critical, 2 files", s.Relations)
+	}
+
+	var slowest []string
+	for _, st := range s.Slowest {
+		slowest = append(slowest, st.Query)
+	}
+	if want := []string{"SELECT b", "SELECT c", "SELECT a"}; !slices.Equal(slowest, want) {
+		t.Errorf("slowest = %v, want %v", slowest, want)
+	}
+}
+
+func TestSummarize_EstimateOnlyByCost(t *testing.T) {
+	report := Summarize([]FileResult{
+		{File: "a.sql", Result: &analyzer.MultiAnalysisResult{Statements: []analyzer.StatementResult{
+			statement(1, "cheap", 0, 10),
+			statement(2, "costly", 0, 1000),
+		}}},
+	})
+	if got := report.Summary.Slowest[0].Query; got != "costly" {
+		t.Errorf("slowest = %q, want the costliest statement without ANALYZE data", got)
+	}
+}
@@ -1,13 +1,32 @@
 package comparator
 
-import "github.com/jacobarthurs/pgplan/internal/plan"
+import (
+	"fmt"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
 
 type Comparator struct {
 	Threshold float64
+
+	// Alpha is the significance level for timings aggregated from several
+	// runs on both sides (DefaultAlpha when zero). Such timings only count
+	// as changed when the Mann-Whitney test finds the difference
+	// significant, as well as beyond Threshold.
+	Alpha float64
 }
 
+// Compare diffs old against new. When either plan lacks ANALYZE data, both
+// are reduced to their planner estimates so that missing actuals don't read
+// as improvements: estimated rows stand in for actual rows, time and buffers
+// are left out, and the verdict is based on cost alone.
 func (c *Comparator) Compare(old, new plan.ExplainOutput) ComparisonResult {
-	rootDelta := c.diffNodes(&old.Plan, &new.Plan)
+	estimateOnly := !old.Analyzed() || !new.Analyzed()
+	if estimateOnly {
+		old, new = estimatesOnly(old), estimatesOnly(new)
+	}
+
+	deltas := c.diffTrees(&old.Plan, &new.Plan)
 
 	oldBuffers := plan.AggregateBuffers(&old.Plan)
 	newBuffers := plan.AggregateBuffers(&new.Plan)
@@ -31,6 +50,7 @@ func (c *Comparator) Compare(old, new plan.ExplainOutput) ComparisonResult {
 
 		OldTotalReads: oldBuffers.TotalRead(),
 		NewTotalReads: newBuffers.TotalRead(),
+		ReadsDir:      c.direction(float64(oldBuffers.TotalRead()), float64(newBuffers.TotalRead()), true),
 		OldTotalHits:  oldBuffers.TotalHit(),
 		NewTotalHits:  newBuffers.TotalHit(),
 
@@ -39,17 +59,56 @@ func (c *Comparator) Compare(old, new plan.ExplainOutput) ComparisonResult {
 
 		OldSortSpaceUsed: plan.AggregateSortSpaceUsed(&old.Plan),
 		NewSortSpaceUsed: plan.AggregateSortSpaceUsed(&new.Plan),
+
+		OldSettings: old.Settings,
+		NewSettings: new.Settings,
+
+		OldBenchmark: old.Benchmark,
+		NewBenchmark: new.Benchmark,
+
+		EstimateOnly: estimateOnly,
 	}
 
-	countChanges(&rootDelta, &summary)
+	if old.Benchmark != nil && new.Benchmark != nil {
+		summary.TimeSignificance = c.significance(old.Benchmark.ExecutionTimes, new.Benchmark.ExecutionTimes)
+		if sig := summary.TimeSignificance; sig != nil && !sig.Significant {
+			summary.TimeDir = Unchanged
+		}
+	}
+
+	for i := range deltas {
+		countChanges(&deltas[i], &summary)
+	}
 	summary.Verdict = computeVerdict(summary)
+	if estimateOnly {
+		summary.Verdict += " (estimated cost only)"
+	}
 
 	return ComparisonResult{
-		Deltas:  []NodeDelta{rootDelta},
+		Deltas:  deltas,
+		Changes: changeEvents(deltas),
 		Summary: summary,
 	}
 }
 
+// ComparePairs compares old[i] with new[i] for every statement. Both sides
+// must contain the same number of plans.
+func (c *Comparator) ComparePairs(old, new []plan.ExplainOutput) ([]StatementComparison, error) {
+	if len(old) != len(new) {
+		return nil, fmt.Errorf("cannot pair statements: old input has %d, new input has %d", len(old), len(new))
+	}
+
+	results := make([]StatementComparison, len(old))
+	for i := range old {
+		results[i] = StatementComparison{
+			Index:  i + 1,
+			Query:  coalesce(new[i].QueryText, old[i].QueryText),
+			Result: c.Compare(old[i], new[i]),
+		}
+	}
+	return results, nil
+}
+
 var verdicts = map[[2]Direction]string{
 	{Improved, Improved}:   "faster and cheaper",
 	{Regressed, Regressed}: "slower and more expensive",
@@ -79,8 +138,69 @@ func countChanges(delta *NodeDelta, summary *Summary) {
 	case TypeChanged:
 		summary.NodesTypeChanged++
 	}
+	if delta.Moved || delta.Swapped {
+		summary.NodesMoved++
+	}
 
 	for i := range delta.Children {
 		countChanges(&delta.Children[i], summary)
 	}
 }
+
+// estimatesOnly returns a copy of output with every ANALYZE-only figure
+// cleared and each node's Actual Rows replaced by its Plan Rows estimate.
+// What the plan was made with, its settings and parameters, is kept.
+func estimatesOnly(output plan.ExplainOutput) plan.ExplainOutput {
+	return plan.ExplainOutput{
+		QueryText:       output.QueryText,
+		QueryParameters: output.QueryParameters,
+		Settings:        output.Settings,
+		Plan:            estimatedNode(output.Plan),
+	}
+}
+
+func estimatedNode(node plan.PlanNode) plan.PlanNode {
+	est := plan.PlanNode{
+		NodeType:           node.NodeType,
+		ParentRelationship: node.ParentRelationship,
+		Strategy:           node.Strategy,
+		PartialMode:        node.PartialMode,
+		ParallelAware:      node.ParallelAware,
+		Operation:          node.Operation,
+
+		StartupCost: node.StartupCost,
+		TotalCost:   node.TotalCost,
+		PlanRows:    node.PlanRows,
+		PlanWidth:   node.PlanWidth,
+		ActualRows:  float64(node.PlanRows),
+
+		Schema:        node.Schema,
+		RelationName:  node.RelationName,
+		Alias:         node.Alias,
+		IndexName:     node.IndexName,
+		ScanDirection: node.ScanDirection,
+
+		IndexCond: node.IndexCond,
+		Filter:    node.Filter,
+
+		JoinType:    node.JoinType,
+		JoinFilter:  node.JoinFilter,
+		HashCond:    node.HashCond,
+		MergeCond:   node.MergeCond,
+		InnerUnique: node.InnerUnique,
+
+		SortKey:        node.SortKey,
+		WorkersPlanned: node.WorkersPlanned,
+		CTEName:        node.CTEName,
+		GroupKey:       node.GroupKey,
+		SubplanName:    node.SubplanName,
+	}
+
+	if len(node.Plans) > 0 {
+		est.Plans = make([]plan.PlanNode, len(node.Plans))
+		for i := range node.Plans {
+			est.Plans[i] = estimatedNode(node.Plans[i])
+		}
+	}
+	return est
+}
@@ -2,15 +2,81 @@ package comparator
 
 import (
 	"math"
+	"slices"
 
 	"github.com/jacobarthurs/pgplan/internal/plan"
 )
 
-func (c *Comparator) diffNodes(old, new *plan.PlanNode) NodeDelta {
+// diffTrees diffs the plan trees rooted at old and new. Nodes are paired by
+// what they are rather than where they are (see matchTrees), and the deltas
+// follow the new tree: paired nodes are diffed, new nodes without a partner
+// are added, and old ones removed, under the parent they had. When the
+// roots aren't paired, the old tree's removed root follows the new root.
+func (c *Comparator) diffTrees(old, new *plan.PlanNode) []NodeDelta {
+	oldRoot, newRoot := matchTrees(old, new)
+	deltas := []NodeDelta{c.buildDelta(newRoot)}
+	if oldRoot.match == nil {
+		deltas = append(deltas, removedTree(oldRoot))
+	}
+	return deltas
+}
+
+// buildDelta returns the delta of the new node n and its subtree.
+func (c *Comparator) buildDelta(n *matchNode) NodeDelta {
+	o := n.match
+	if o == nil {
+		delta := addedNode(n.node)
+		for _, child := range n.children {
+			delta.Children = append(delta.Children, c.buildDelta(child))
+		}
+		return delta
+	}
+
+	delta := c.diffPair(o, n)
+	for _, child := range n.children {
+		delta.Children = append(delta.Children, c.buildDelta(child))
+	}
+	// Old children without a partner were removed; keep them near where
+	// they were.
+	for i, child := range o.children {
+		if child.match == nil {
+			at := min(i, len(delta.Children))
+			delta.Children = slices.Insert(delta.Children, at, removedTree(child))
+		}
+	}
+	return delta
+}
+
+// removedTree returns the delta of the removed old node o and of its
+// descendants that were removed too; those with a partner appear in the new
+// tree instead.
+func removedTree(o *matchNode) NodeDelta {
+	delta := removedNode(o.node)
+	for _, child := range o.children {
+		if child.match == nil {
+			delta.Children = append(delta.Children, removedTree(child))
+		}
+	}
+	return delta
+}
+
+// diffPair diffs the paired nodes o and n themselves, without their
+// children.
+func (c *Comparator) diffPair(o, n *matchNode) NodeDelta {
+	old, new := o.node, n.node
 	delta := NodeDelta{
 		Relation: coalesce(old.RelationName, new.RelationName),
 	}
 
+	if isMoved, from := moved(o, n); isMoved {
+		delta.Moved = true
+		delta.MovedFrom = "the top of the plan"
+		if from != nil {
+			delta.MovedFrom = describeNode(from.node)
+		}
+	}
+	delta.Swapped = swapped(o, n)
+
 	if old.NodeType != new.NodeType {
 		delta.ChangeType = TypeChanged
 		delta.OldNodeType = old.NodeType
@@ -32,6 +98,14 @@ func (c *Comparator) diffNodes(old, new *plan.PlanNode) NodeDelta {
 	delta.TimeDelta = new.ActualTotalTime - old.ActualTotalTime
 	delta.TimePct = pctChange(old.ActualTotalTime, new.ActualTotalTime)
 	delta.TimeDir = c.direction(old.ActualTotalTime, new.ActualTotalTime, true)
+	// Actual Total Time is per loop, so the runs are tested per loop too:
+	// with loops differing, loop totals could move the other way.
+	if old.Stats != nil && new.Stats != nil {
+		delta.TimeSignificance = c.significance(old.Stats.LoopTimes, new.Stats.LoopTimes)
+		if sig := delta.TimeSignificance; sig != nil && !sig.Significant {
+			delta.TimeDir = Unchanged
+		}
+	}
 
 	delta.OldRows = old.ActualRows
 	delta.NewRows = new.ActualRows
@@ -82,71 +156,54 @@ func (c *Comparator) diffNodes(old, new *plan.PlanNode) NodeDelta {
 		delta.ChangeType = NoChange
 	}
 
-	delta.Children = c.diffChildren(old.Plans, new.Plans)
-
 	return delta
 }
 
-// diffChildren uses position-based matching. When plan structure changes
-// significantly (e.g., Seq Scan becomes Index Scan + Bitmap Heap Scan),
-// position 0 shows as type_changed and additional nodes show as added.
-func (c *Comparator) diffChildren(oldKids, newKids []plan.PlanNode) []NodeDelta {
-	var deltas []NodeDelta
-
-	for i := range max(len(oldKids), len(newKids)) {
-		if i >= len(oldKids) {
-			deltas = append(deltas, addedNode(&newKids[i]))
-			continue
-		}
-		if i >= len(newKids) {
-			deltas = append(deltas, removedNode(&oldKids[i]))
-			continue
-		}
-		deltas = append(deltas, c.diffNodes(&oldKids[i], &newKids[i]))
-	}
-
-	return deltas
-}
-
 func addedNode(node *plan.PlanNode) NodeDelta {
-	delta := NodeDelta{
+	return NodeDelta{
 		ChangeType: Added,
 		NodeType:   node.NodeType,
 		Relation:   node.RelationName,
 		NewCost:    node.TotalCost,
 		NewTime:    node.ActualTotalTime,
 		NewRows:    node.ActualRows,
-	}
 
-	for _, child := range node.Plans {
-		delta.Children = append(delta.Children, addedNode(&child))
+		NewIndexName:      node.IndexName,
+		NewWorkersPlanned: node.WorkersPlanned,
 	}
-
-	return delta
 }
 
 func removedNode(node *plan.PlanNode) NodeDelta {
-	delta := NodeDelta{
+	return NodeDelta{
 		ChangeType: Removed,
 		NodeType:   node.NodeType,
 		Relation:   node.RelationName,
 		OldCost:    node.TotalCost,
 		OldTime:    node.ActualTotalTime,
 		OldRows:    node.ActualRows,
-	}
 
-	for _, child := range node.Plans {
-		delta.Children = append(delta.Children, removedNode(&child))
+		OldIndexName:      node.IndexName,
+		OldWorkersPlanned: node.WorkersPlanned,
 	}
+}
 
-	return delta
+// describeNode names a node for MovedFrom: its type, and its relation or
+// CTE if it reads one.
+func describeNode(n *plan.PlanNode) string {
+	if s := source(n); s != "" {
+		return n.NodeType + " on " + s
+	}
+	return n.NodeType
 }
 
 func (c *Comparator) isSignificant(d NodeDelta) bool {
+	if d.Moved || d.Swapped {
+		return true
+	}
 	if math.Abs(d.CostPct) > c.Threshold {
 		return true
 	}
-	if math.Abs(d.TimePct) > c.Threshold {
+	if math.Abs(d.TimePct) > c.Threshold && (d.TimeSignificance == nil || d.TimeSignificance.Significant) {
 		return true
 	}
 	if d.OldLoops != d.NewLoops && d.OldLoops > 0 {
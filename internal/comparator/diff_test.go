@@ -10,6 +10,12 @@ func defaultComparator() *Comparator {
 	return &Comparator{Threshold: 5.0}
 }
 
+// diffRoot diffs the trees rooted at old and new, returning the new root's
+// delta.
+func diffRoot(c *Comparator, old, new *plan.PlanNode) NodeDelta {
+	return c.diffTrees(old, new)[0]
+}
+
 func TestDiffNodes_SameNode(t *testing.T) {
 	c := defaultComparator()
 	node := plan.PlanNode{
@@ -21,7 +27,7 @@ func TestDiffNodes_SameNode(t *testing.T) {
 		ActualLoops:     1,
 	}
 
-	delta := c.diffNodes(&node, &node)
+	delta := diffRoot(c, &node, &node)
 
 	if delta.ChangeType != NoChange {
 		t.Errorf("ChangeType = %v, want NoChange", delta.ChangeType)
@@ -48,7 +54,7 @@ func TestDiffNodes_CostIncrease(t *testing.T) {
 		ActualLoops:     1,
 	}
 
-	delta := c.diffNodes(&old, &new)
+	delta := diffRoot(c, &old, &new)
 
 	if delta.ChangeType != Modified {
 		t.Errorf("ChangeType = %v, want Modified", delta.ChangeType)
@@ -81,7 +87,7 @@ func TestDiffNodes_TimeImproved(t *testing.T) {
 		ActualLoops:     1,
 	}
 
-	if delta := c.diffNodes(&old, &new); delta.TimeDir != Improved {
+	if delta := diffRoot(c, &old, &new); delta.TimeDir != Improved {
 		t.Errorf("TimeDir = %v, want Improved", delta.TimeDir)
 	}
 }
@@ -103,7 +109,7 @@ func TestDiffNodes_TypeChanged(t *testing.T) {
 		ActualLoops:  1,
 	}
 
-	delta := c.diffNodes(&old, &new)
+	delta := diffRoot(c, &old, &new)
 
 	if delta.ChangeType != TypeChanged {
 		t.Errorf("ChangeType = %v, want TypeChanged", delta.ChangeType)
@@ -131,7 +137,7 @@ func TestDiffNodes_SortSpillChange(t *testing.T) {
 		ActualLoops:   1,
 	}
 
-	delta := c.diffNodes(&old, &new)
+	delta := diffRoot(c, &old, &new)
 
 	if !delta.OldSortSpill {
 		t.Error("OldSortSpill = false, want true")
@@ -158,7 +164,7 @@ func TestDiffNodes_FilterChange(t *testing.T) {
 		ActualLoops: 1,
 	}
 
-	delta := c.diffNodes(&old, &new)
+	delta := diffRoot(c, &old, &new)
 
 	if delta.OldFilter != "" {
 		t.Errorf("OldFilter = %q, want empty", delta.OldFilter)
@@ -183,7 +189,7 @@ func TestDiffNodes_BufferDirection(t *testing.T) {
 		ActualLoops:      1,
 	}
 
-	if delta := c.diffNodes(&old, &new); delta.BufferDir != Improved {
+	if delta := diffRoot(c, &old, &new); delta.BufferDir != Improved {
 		t.Errorf("BufferDir = %v, want Improved", delta.BufferDir)
 	}
 }
@@ -213,7 +219,7 @@ func TestDiffNodes_BufferBreakdown(t *testing.T) {
 		ActualLoops:        1,
 	}
 
-	delta := c.diffNodes(&old, &new)
+	delta := diffRoot(c, &old, &new)
 
 	if delta.OldBuffers.Shared.Read != 1000 || delta.NewBuffers.Shared.Read != 100 {
 		t.Errorf("Shared.Read = %d → %d, want 1000 → 100", delta.OldBuffers.Shared.Read, delta.NewBuffers.Shared.Read)
@@ -249,7 +255,7 @@ func TestDiffNodes_SortSpaceUsedChange(t *testing.T) {
 		ActualLoops:   1,
 	}
 
-	delta := c.diffNodes(&old, &new)
+	delta := diffRoot(c, &old, &new)
 
 	if delta.OldSortSpaceUsed != 4096 || delta.NewSortSpaceUsed != 64 {
 		t.Errorf("SortSpaceUsed = %d → %d, want 4096 → 64", delta.OldSortSpaceUsed, delta.NewSortSpaceUsed)
@@ -259,6 +265,13 @@ func TestDiffNodes_SortSpaceUsedChange(t *testing.T) {
 	}
 }
 
+// diffChildren diffs two lists of children under the same parent.
+func diffChildren(c *Comparator, oldKids, newKids []plan.PlanNode) []NodeDelta {
+	old := plan.PlanNode{NodeType: "Hash Join", Plans: oldKids}
+	new := plan.PlanNode{NodeType: "Hash Join", Plans: newKids}
+	return diffRoot(c, &old, &new).Children
+}
+
 func TestDiffChildren_MatchedChildren(t *testing.T) {
 	c := defaultComparator()
 	oldKids := []plan.PlanNode{
@@ -270,7 +283,7 @@ func TestDiffChildren_MatchedChildren(t *testing.T) {
 		{NodeType: "Hash", TotalCost: 5.0, ActualLoops: 1},
 	}
 
-	if deltas := c.diffChildren(oldKids, newKids); len(deltas) != 2 {
+	if deltas := diffChildren(c, oldKids, newKids); len(deltas) != 2 {
 		t.Fatalf("expected 2 deltas, got %d", len(deltas))
 	}
 }
@@ -283,7 +296,7 @@ func TestDiffChildren_AddedNode(t *testing.T) {
 		{NodeType: "Hash", TotalCost: 5.0},
 	}
 
-	deltas := c.diffChildren(oldKids, newKids)
+	deltas := diffChildren(c, oldKids, newKids)
 
 	if len(deltas) != 2 {
 		t.Fatalf("expected 2 deltas, got %d", len(deltas))
@@ -301,7 +314,7 @@ func TestDiffChildren_RemovedNode(t *testing.T) {
 	}
 	newKids := []plan.PlanNode{{NodeType: "Seq Scan", TotalCost: 10.0}}
 
-	deltas := c.diffChildren(oldKids, newKids)
+	deltas := diffChildren(c, oldKids, newKids)
 
 	if len(deltas) != 2 {
 		t.Fatalf("expected 2 deltas, got %d", len(deltas))
@@ -314,7 +327,7 @@ func TestDiffChildren_RemovedNode(t *testing.T) {
 func TestDiffChildren_EmptyBoth(t *testing.T) {
 	c := defaultComparator()
 
-	if deltas := c.diffChildren(nil, nil); len(deltas) != 0 {
+	if deltas := diffChildren(c, nil, nil); len(deltas) != 0 {
 		t.Errorf("expected 0 deltas, got %d", len(deltas))
 	}
 }
@@ -575,3 +588,110 @@ func TestIsSignificant_SortSpillChange(t *testing.T) {
 		t.Error("sort spill change should be significant")
 	}
 }
+
+func TestComparePairs(t *testing.T) {
+	c := defaultComparator()
+	old := []plan.ExplainOutput{
+		{QueryText: "SELECT 1", Plan: plan.PlanNode{TotalCost: 10.0, ActualLoops: 1}, ExecutionTime: 5.0},
+		{QueryText: "SELECT 2", Plan: plan.PlanNode{TotalCost: 10.0, ActualLoops: 1}, ExecutionTime: 5.0},
+	}
+	new := []plan.ExplainOutput{
+		{Plan: plan.PlanNode{TotalCost: 100.0, ActualLoops: 1}, ExecutionTime: 50.0},
+		{QueryText: "SELECT 2 -- v2", Plan: plan.PlanNode{TotalCost: 10.0, ActualLoops: 1}, ExecutionTime: 5.0},
+	}
+
+	results, err := c.ComparePairs(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Index != 1 || results[0].Query != "SELECT 1" {
+		t.Errorf("results[0] = {%d, %q}, want {1, SELECT 1} (old query text as fallback)", results[0].Index, results[0].Query)
+	}
+	if results[0].Result.Summary.Verdict != "slower and more expensive" {
+		t.Errorf("results[0] Verdict = %q, want 'slower and more expensive'", results[0].Result.Summary.Verdict)
+	}
+	if results[1].Query != "SELECT 2 -- v2" {
+		t.Errorf("results[1] Query = %q, want the new side's query text", results[1].Query)
+	}
+}
+
+func TestComparePairs_CountMismatch(t *testing.T) {
+	c := defaultComparator()
+	if _, err := c.ComparePairs(make([]plan.ExplainOutput, 2), make([]plan.ExplainOutput, 3)); err == nil {
+		t.Fatal("expected error for mismatched statement counts")
+	}
+}
+
+func TestCompare_EstimateOnly(t *testing.T) {
+	c := defaultComparator()
+	old := plan.ExplainOutput{
+		Plan: plan.PlanNode{NodeType: "Seq Scan", RelationName: "orders", TotalCost: 1000.0, PlanRows: 500},
+	}
+	// Analyzed on the new side only: its actuals must not be compared
+	// against the missing ones.
+	new := plan.ExplainOutput{
+		Plan: plan.PlanNode{
+			NodeType: "Index Scan", RelationName: "orders", TotalCost: 10.0, PlanRows: 5,
+			ActualTotalTime: 0.5, ActualRows: 4, ActualLoops: 1, SharedHitBlocks: 4,
+		},
+		ExecutionTime: 0.6,
+	}
+
+	result := c.Compare(old, new)
+	s := result.Summary
+
+	if !s.EstimateOnly {
+		t.Error("EstimateOnly = false, want true")
+	}
+	if s.NewExecutionTime != 0 || s.TimeDir != Unchanged {
+		t.Errorf("execution time compared: new %f, dir %v", s.NewExecutionTime, s.TimeDir)
+	}
+	if s.NewBuffers.Shared.Hit != 0 {
+		t.Errorf("NewBuffers.Shared.Hit = %d, want 0 (buffers not compared)", s.NewBuffers.Shared.Hit)
+	}
+	if s.Verdict != "cheaper (estimated cost only)" {
+		t.Errorf("Verdict = %q, want 'cheaper (estimated cost only)'", s.Verdict)
+	}
+
+	root := result.Deltas[0]
+	if root.OldRows != 500 || root.NewRows != 5 {
+		t.Errorf("rows = %.0f → %.0f, want estimates 500 → 5", root.OldRows, root.NewRows)
+	}
+	if root.NewTime != 0 {
+		t.Errorf("NewTime = %f, want 0", root.NewTime)
+	}
+}
+
+func TestCompare_EstimateOnlyKeepsSettings(t *testing.T) {
+	c := defaultComparator()
+	old := plan.ExplainOutput{
+		Plan:     plan.PlanNode{NodeType: "Seq Scan", RelationName: "orders", TotalCost: 1000.0, PlanRows: 500},
+		Settings: map[string]string{"work_mem": "4MB"},
+	}
+	new := plan.ExplainOutput{
+		Plan:     plan.PlanNode{NodeType: "Seq Scan", RelationName: "orders", TotalCost: 900.0, PlanRows: 500},
+		Settings: map[string]string{"work_mem": "256MB"},
+	}
+
+	s := c.Compare(old, new).Summary
+
+	if !s.EstimateOnly {
+		t.Error("EstimateOnly = false, want true")
+	}
+	if s.OldSettings["work_mem"] != "4MB" || s.NewSettings["work_mem"] != "256MB" {
+		t.Errorf("settings = %v → %v, want work_mem 4MB → 256MB", s.OldSettings, s.NewSettings)
+	}
+}
+
+func TestCompare_AnalyzedWithoutTimingIsNotEstimateOnly(t *testing.T) {
+	// auto_explain output carries actuals but no Planning/Execution Time.
+	c := defaultComparator()
+	p := plan.ExplainOutput{Plan: plan.PlanNode{NodeType: "Result", ActualLoops: 1}}
+
+	if c.Compare(p, p).Summary.EstimateOnly {
+		t.Error("EstimateOnly = true for plans with Actual Loops, want false")
+	}
+}
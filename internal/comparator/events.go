@@ -0,0 +1,187 @@
+package comparator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ChangeKind classifies a plan-shape change.
+type ChangeKind string
+
+const (
+	// ScanMethodChanged: a relation is read another way, e.g. Seq Scan →
+	// Index Scan using orders_pkey.
+	ScanMethodChanged ChangeKind = "scan_method_changed"
+	// JoinAlgorithmChanged: a join changed type, e.g. Hash Join → Nested
+	// Loop.
+	JoinAlgorithmChanged ChangeKind = "join_algorithm_changed"
+	// JoinOrderChanged: a join's inputs swapped sides, or a relation is
+	// joined at a different point of the plan.
+	JoinOrderChanged ChangeKind = "join_order_changed"
+	// ParallelismAdded and ParallelismRemoved: a Gather or Gather Merge
+	// node appeared or disappeared.
+	ParallelismAdded   ChangeKind = "parallelism_added"
+	ParallelismRemoved ChangeKind = "parallelism_removed"
+	// SortAdded and SortEliminated: a Sort or Incremental Sort node
+	// appeared or disappeared.
+	SortAdded      ChangeKind = "sort_added"
+	SortEliminated ChangeKind = "sort_eliminated"
+	// PartitionPruningChanged: an Append or Merge Append scans a different
+	// number of partitions.
+	PartitionPruningChanged ChangeKind = "partition_pruning_changed"
+)
+
+// ChangeEvent is one plan-shape change, derived from the node deltas.
+type ChangeEvent struct {
+	Kind ChangeKind
+	// Relation is the relation the change is about, or the relations a
+	// join or append reads, comma-separated.
+	Relation string `json:",omitempty"`
+	// Old and New describe the changed part before and after, e.g. "Seq
+	// Scan" and "Index Scan using orders_pkey", when there's a before and
+	// after.
+	Old string `json:",omitempty"`
+	New string `json:",omitempty"`
+	// Description is a one-line summary of the change.
+	Description string
+}
+
+// changeEvents derives the plan-shape changes in deltas, in plan order.
+func changeEvents(deltas []NodeDelta) []ChangeEvent {
+	var events []ChangeEvent
+	var walk func(d *NodeDelta, parent *NodeDelta)
+	walk = func(d *NodeDelta, parent *NodeDelta) {
+		events = append(events, nodeEvents(d, parent)...)
+		for i := range d.Children {
+			walk(&d.Children[i], d)
+		}
+	}
+	for i := range deltas {
+		walk(&deltas[i], nil)
+	}
+	return events
+}
+
+// nodeEvents returns the changes d itself stands for. parent is d's parent
+// in the delta tree, or nil at the top.
+func nodeEvents(d *NodeDelta, parent *NodeDelta) []ChangeEvent {
+	var events []ChangeEvent
+	add := func(kind ChangeKind, relation, old, new, format string, args ...any) {
+		events = append(events, ChangeEvent{Kind: kind, Relation: relation, Old: old, New: new, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if d.ChangeType == Added || d.ChangeType == Removed {
+		relations := strings.Join(deltaRelations(d), ", ")
+		switch nodeFamilies[d.NodeType] {
+		case "gather":
+			kind, verb, workers := ParallelismAdded, "added", d.NewWorkersPlanned
+			if d.ChangeType == Removed {
+				kind, verb, workers = ParallelismRemoved, "removed", d.OldWorkersPlanned
+			}
+			desc := fmt.Sprintf("Parallelism %s: %s", verb, d.NodeType)
+			if workers > 0 {
+				desc += fmt.Sprintf(" with %d worker(s) planned", workers)
+			}
+			add(kind, relations, "", "", "%s", desc)
+		case "sort":
+			kind, verb := SortAdded, "added"
+			if d.ChangeType == Removed {
+				kind, verb = SortEliminated, "eliminated"
+			}
+			desc := fmt.Sprintf("%s %s", d.NodeType, verb)
+			if relations != "" {
+				desc += " over " + relations
+			}
+			add(kind, relations, "", "", "%s", desc)
+		}
+		return events
+	}
+
+	family := nodeFamilies[d.NodeType]
+	oldFamily := nodeFamilies[coalesce(d.OldNodeType, d.NodeType)]
+
+	switch {
+	case d.Relation != "" && (family == "scan" || oldFamily == "scan"):
+		old := scanMethod(coalesce(d.OldNodeType, d.NodeType), d.OldIndexName, d, false)
+		new := scanMethod(coalesce(d.NewNodeType, d.NodeType), d.NewIndexName, d, true)
+		if old != new {
+			add(ScanMethodChanged, d.Relation, old, new, "Scan on %s: %s → %s", d.Relation, old, new)
+		}
+	case d.ChangeType == TypeChanged && family == "join" && oldFamily == "join":
+		relations := strings.Join(deltaRelations(d), ", ")
+		add(JoinAlgorithmChanged, relations, d.OldNodeType, d.NewNodeType, "Join of %s: %s → %s", relations, d.OldNodeType, d.NewNodeType)
+	}
+
+	if d.Swapped && family == "join" {
+		relations := deltaRelations(d)
+		add(JoinOrderChanged, strings.Join(relations, ", "), "", "", "Join of %s: outer and inner sides swapped", strings.Join(relations, ", "))
+	}
+	if d.Moved {
+		if relations := deltaRelations(d); len(relations) > 0 {
+			to := "the top of the plan"
+			if parent != nil {
+				to = parent.NodeType
+			}
+			add(JoinOrderChanged, strings.Join(relations, ", "), d.MovedFrom, to, "%s moved from under %s to under %s", strings.Join(relations, ", "), d.MovedFrom, to)
+		}
+	}
+
+	if family == "append" {
+		var oldCount, newCount int
+		for _, c := range d.Children {
+			if c.ChangeType != Added {
+				oldCount++
+			}
+			if c.ChangeType != Removed {
+				newCount++
+			}
+		}
+		if oldCount != newCount {
+			relations := strings.Join(deltaRelations(d), ", ")
+			add(PartitionPruningChanged, relations, fmt.Sprint(oldCount), fmt.Sprint(newCount),
+				"%s scans %d → %d partition(s)", d.NodeType, oldCount, newCount)
+		}
+	}
+	return events
+}
+
+// scanMethod describes how a scan node reads its relation: its type, and
+// the index it uses, if any. A Bitmap Heap Scan's index is on its Bitmap
+// Index Scan child.
+func scanMethod(nodeType, index string, d *NodeDelta, new bool) string {
+	if nodeType == "Bitmap Heap Scan" && index == "" {
+		for _, c := range d.Children {
+			if c.NodeType != "Bitmap Index Scan" && c.NewNodeType != "Bitmap Index Scan" && c.OldNodeType != "Bitmap Index Scan" {
+				continue
+			}
+			if new && c.ChangeType != Removed {
+				index = c.NewIndexName
+			} else if !new && c.ChangeType != Added {
+				index = c.OldIndexName
+			}
+		}
+	}
+	if index == "" {
+		return nodeType
+	}
+	return nodeType + " using " + index
+}
+
+// deltaRelations returns the relations read under d, sorted and without
+// duplicates.
+func deltaRelations(d *NodeDelta) []string {
+	var relations []string
+	var walk func(d *NodeDelta)
+	walk = func(d *NodeDelta) {
+		if d.Relation != "" && !slices.Contains(relations, d.Relation) {
+			relations = append(relations, d.Relation)
+		}
+		for i := range d.Children {
+			walk(&d.Children[i])
+		}
+	}
+	walk(d)
+	slices.Sort(relations)
+	return relations
+}
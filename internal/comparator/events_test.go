@@ -0,0 +1,114 @@
+package comparator
+
+import (
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func eventsOf(t *testing.T, old, new plan.PlanNode) []ChangeEvent {
+	t.Helper()
+	return changeEvents(defaultComparator().diffTrees(&old, &new))
+}
+
+func wantEvent(t *testing.T, events []ChangeEvent, kind ChangeKind, description string) {
+	t.Helper()
+	for _, e := range events {
+		if e.Kind == kind && e.Description == description {
+			return
+		}
+	}
+	t.Errorf("missing %s event %q, got %+v", kind, description, events)
+}
+
+func TestChangeEvents_ScanMethodChanged(t *testing.T) {
+	old := scan("Seq Scan", "orders")
+	new := scan("Index Scan", "orders")
+	new.IndexName = "orders_pkey"
+
+	events := eventsOf(t, old, new)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1: %+v", len(events), events)
+	}
+	wantEvent(t, events, ScanMethodChanged, "Scan on orders: Seq Scan → Index Scan using orders_pkey")
+	if e := events[0]; e.Relation != "orders" || e.Old != "Seq Scan" || e.New != "Index Scan using orders_pkey" {
+		t.Errorf("event = %+v, want orders: Seq Scan → Index Scan using orders_pkey", e)
+	}
+}
+
+func TestChangeEvents_BitmapScanIndex(t *testing.T) {
+	old := scan("Seq Scan", "orders")
+	new := scan("Bitmap Heap Scan", "orders", plan.PlanNode{NodeType: "Bitmap Index Scan", IndexName: "orders_customer_id_idx", TotalCost: 10, ActualLoops: 1})
+
+	wantEvent(t, eventsOf(t, old, new), ScanMethodChanged, "Scan on orders: Seq Scan → Bitmap Heap Scan using orders_customer_id_idx")
+}
+
+func TestChangeEvents_IndexChanged(t *testing.T) {
+	old := scan("Index Scan", "orders")
+	old.IndexName = "orders_pkey"
+	new := scan("Index Scan", "orders")
+	new.IndexName = "orders_created_at_idx"
+
+	wantEvent(t, eventsOf(t, old, new), ScanMethodChanged, "Scan on orders: Index Scan using orders_pkey → Index Scan using orders_created_at_idx")
+}
+
+func TestChangeEvents_JoinAlgorithmChanged(t *testing.T) {
+	old := node("Hash Join", scan("Seq Scan", "orders"), node("Hash", scan("Seq Scan", "customers")))
+	new := node("Nested Loop", scan("Seq Scan", "orders"), scan("Seq Scan", "customers"))
+
+	events := eventsOf(t, old, new)
+	wantEvent(t, events, JoinAlgorithmChanged, "Join of customers, orders: Hash Join → Nested Loop")
+	for _, e := range events {
+		if e.Kind == ScanMethodChanged {
+			t.Errorf("unexpected scan event %+v: no scan changed", e)
+		}
+	}
+}
+
+func TestChangeEvents_JoinSidesSwapped(t *testing.T) {
+	old := node("Hash Join", scan("Seq Scan", "orders"), node("Hash", scan("Seq Scan", "customers")))
+	new := node("Hash Join", scan("Seq Scan", "customers"), node("Hash", scan("Seq Scan", "orders")))
+
+	wantEvent(t, eventsOf(t, old, new), JoinOrderChanged, "Join of customers, orders: outer and inner sides swapped")
+}
+
+func TestChangeEvents_Parallelism(t *testing.T) {
+	serial := node("Aggregate", scan("Seq Scan", "orders"))
+	gather := node("Gather", scan("Seq Scan", "orders"))
+	gather.WorkersPlanned = 2
+	parallel := node("Aggregate", gather)
+
+	wantEvent(t, eventsOf(t, serial, parallel), ParallelismAdded, "Parallelism added: Gather with 2 worker(s) planned")
+	wantEvent(t, eventsOf(t, parallel, serial), ParallelismRemoved, "Parallelism removed: Gather with 2 worker(s) planned")
+}
+
+func TestChangeEvents_Sort(t *testing.T) {
+	sorted := node("Limit", node("Sort", scan("Seq Scan", "orders")))
+	indexed := node("Limit", scan("Index Scan", "orders"))
+
+	wantEvent(t, eventsOf(t, sorted, indexed), SortEliminated, "Sort eliminated")
+	wantEvent(t, eventsOf(t, indexed, sorted), SortAdded, "Sort added over orders")
+}
+
+func TestChangeEvents_PartitionPruning(t *testing.T) {
+	old := node("Append",
+		scan("Seq Scan", "events_2025"),
+		scan("Seq Scan", "events_2026"),
+		scan("Seq Scan", "events_2027"),
+	)
+	new := node("Append", scan("Seq Scan", "events_2026"))
+
+	events := eventsOf(t, old, new)
+	wantEvent(t, events, PartitionPruningChanged, "Append scans 3 → 1 partition(s)")
+	if len(events) != 1 {
+		t.Errorf("got %d events, want only the pruning change: %+v", len(events), events)
+	}
+}
+
+func TestChangeEvents_IdenticalPlans(t *testing.T) {
+	p := node("Hash Join", scan("Seq Scan", "orders"), node("Hash", scan("Seq Scan", "customers")))
+
+	if events := eventsOf(t, p, p); len(events) != 0 {
+		t.Errorf("got events for identical plans: %+v", events)
+	}
+}
@@ -0,0 +1,259 @@
+package comparator
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// matchNode is a plan node with its place in the tree, as the matcher sees
+// it.
+type matchNode struct {
+	node     *plan.PlanNode
+	parent   *matchNode
+	children []*matchNode
+
+	// pos is the node's preorder position scaled to [0, 1], so positions
+	// in trees of different sizes can be compared.
+	pos   float64
+	depth int
+
+	// sources are the relations and CTEs read in the node's subtree.
+	sources map[string]bool
+
+	match *matchNode
+}
+
+// nodeFamilies groups node types that do the same job in different ways,
+// so that a node replaced by one of its family is still paired with it.
+var nodeFamilies = map[string]string{
+	"Seq Scan":         "scan",
+	"Index Scan":       "scan",
+	"Index Only Scan":  "scan",
+	"Bitmap Heap Scan": "scan",
+	"Tid Scan":         "scan",
+	"Tid Range Scan":   "scan",
+	"Sample Scan":      "scan",
+	"Nested Loop":      "join",
+	"Hash Join":        "join",
+	"Merge Join":       "join",
+	"Aggregate":        "aggregate",
+	"Group":            "aggregate",
+	"Sort":             "sort",
+	"Incremental Sort": "sort",
+	"Gather":           "gather",
+	"Gather Merge":     "gather",
+	"Append":           "append",
+	"Merge Append":     "append",
+	"Materialize":      "cache",
+	"Memoize":          "cache",
+}
+
+// source names what a node reads itself: its relation or CTE.
+func source(n *plan.PlanNode) string {
+	switch {
+	case n.RelationName != "":
+		return n.RelationName
+	case n.CTEName != "":
+		return "CTE " + n.CTEName
+	}
+	return ""
+}
+
+// matchTrees pairs the nodes of old with those of new by what they are
+// rather than where they are, so that a node keeps its partner when the
+// plan around it changes shape: see similarity. Each node is paired at most
+// once; pairs are chosen best first.
+func matchTrees(old, new *plan.PlanNode) (oldRoot, newRoot *matchNode) {
+	oldRoot, oldNodes := indexTree(old)
+	newRoot, newNodes := indexTree(new)
+
+	type candidate struct {
+		o, n  *matchNode
+		score float64
+	}
+	var candidates []candidate
+	for _, o := range oldNodes {
+		for _, n := range newNodes {
+			if s := similarity(o, n); s > 0 {
+				candidates = append(candidates, candidate{o, n, s})
+			}
+		}
+	}
+
+	// Among equally similar pairs, prefer those at the same depth and
+	// position, which keeps identical plans paired one to one.
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(abs(a.o.depth-a.n.depth), abs(b.o.depth-b.n.depth)),
+			cmp.Compare(math.Abs(a.o.pos-a.n.pos), math.Abs(b.o.pos-b.n.pos)),
+		)
+	})
+	for _, c := range candidates {
+		if c.o.match == nil && c.n.match == nil {
+			c.o.match, c.n.match = c.n, c.o
+		}
+	}
+	return oldRoot, newRoot
+}
+
+// indexTree wraps root's tree in matchNodes, returning the root and every
+// node in preorder.
+func indexTree(root *plan.PlanNode) (*matchNode, []*matchNode) {
+	var nodes []*matchNode
+	var walk func(n *plan.PlanNode, parent *matchNode, depth int) *matchNode
+	walk = func(n *plan.PlanNode, parent *matchNode, depth int) *matchNode {
+		m := &matchNode{node: n, parent: parent, depth: depth, sources: make(map[string]bool)}
+		nodes = append(nodes, m)
+		if s := source(n); s != "" {
+			m.sources[s] = true
+		}
+		for i := range n.Plans {
+			child := walk(&n.Plans[i], m, depth+1)
+			m.children = append(m.children, child)
+			for s := range child.sources {
+				m.sources[s] = true
+			}
+		}
+		return m
+	}
+	top := walk(root, nil, 0)
+
+	for i, m := range nodes {
+		m.pos = float64(i) / float64(max(len(nodes)-1, 1))
+	}
+	return top, nodes
+}
+
+// similarity scores how likely o and n are the same step of the plan, or 0
+// when they can't be. Nodes that read a relation or CTE only pair with
+// nodes reading the same one, whatever the scan method; a matching alias
+// tells self-joins apart. Other nodes (joins, sorts, aggregates, ...) pair
+// with nodes of the same type or family over overlapping relations.
+func similarity(o, n *matchNode) float64 {
+	on, nn := o.node, n.node
+	if on.SubplanName != "" && nn.SubplanName != "" && on.SubplanName != nn.SubplanName {
+		return 0
+	}
+
+	var typeScore float64
+	switch {
+	case on.NodeType == nn.NodeType:
+		typeScore = 2
+	case nodeFamilies[on.NodeType] != "" && nodeFamilies[on.NodeType] == nodeFamilies[nn.NodeType]:
+		typeScore = 1
+	}
+
+	var score float64
+	if oldSource, newSource := source(on), source(nn); oldSource != "" || newSource != "" {
+		if oldSource != newSource {
+			return 0
+		}
+		score = 4 + typeScore
+		if on.Alias == nn.Alias {
+			score++
+		}
+	} else {
+		overlap := jaccard(o.sources, n.sources)
+		if typeScore == 0 || overlap == 0 {
+			return 0
+		}
+		score = 3*overlap + typeScore
+	}
+
+	if on.SubplanName != "" && on.SubplanName == nn.SubplanName {
+		score += 2
+	}
+	if on.IndexName != "" && on.IndexName == nn.IndexName {
+		score++
+	}
+	if o.parent == nil && n.parent == nil ||
+		o.parent != nil && n.parent != nil && o.parent.node.NodeType == n.parent.node.NodeType {
+		score += 0.5
+	}
+	return score
+}
+
+// jaccard returns the share of a's and b's members they have in common, or
+// 1 when both are empty.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	common := 0
+	for k := range a {
+		if b[k] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// matchedAncestor returns m's nearest ancestor that has a partner, or nil.
+func matchedAncestor(m *matchNode) *matchNode {
+	for p := m.parent; p != nil; p = p.parent {
+		if p.match != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// moved reports whether the paired nodes o and n sit under different
+// parents, looking past nodes that were only added or removed around them.
+// It returns the old parent, nil for the top of the plan.
+func moved(o, n *matchNode) (bool, *matchNode) {
+	oldParent, newParent := matchedAncestor(o), matchedAncestor(n)
+	if oldParent == nil || newParent == nil {
+		return oldParent != newParent, oldParent
+	}
+	return oldParent.match != newParent, oldParent
+}
+
+// swapped reports whether the children of the paired nodes o and n come in
+// a different order: each of n's children is placed by the old child its
+// first paired descendant came from.
+func swapped(o, n *matchNode) bool {
+	last := -1
+	for _, child := range n.children {
+		from := oldChildIndex(o, child)
+		if from < 0 {
+			continue
+		}
+		if from < last {
+			return true
+		}
+		last = from
+	}
+	return false
+}
+
+// oldChildIndex returns the index of o's child whose subtree holds the
+// partner of the first paired node of newChild's subtree, in preorder, or
+// -1 when there's none.
+func oldChildIndex(o, newChild *matchNode) int {
+	if m := newChild.match; m != nil {
+		for ; m.parent != nil; m = m.parent {
+			if m.parent == o {
+				return slices.Index(o.children, m)
+			}
+		}
+		return -1
+	}
+	for _, c := range newChild.children {
+		if i := oldChildIndex(o, c); i >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,209 @@
+package comparator
+
+import (
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func scan(nodeType, relation string, children ...plan.PlanNode) plan.PlanNode {
+	return plan.PlanNode{NodeType: nodeType, RelationName: relation, TotalCost: 10, ActualLoops: 1, Plans: children}
+}
+
+func node(nodeType string, children ...plan.PlanNode) plan.PlanNode {
+	return plan.PlanNode{NodeType: nodeType, TotalCost: 10, ActualLoops: 1, Plans: children}
+}
+
+// flattenDeltas returns every delta in the tree, depth first.
+func flattenDeltas(deltas []NodeDelta) []NodeDelta {
+	var all []NodeDelta
+	for _, d := range deltas {
+		all = append(all, d)
+		all = append(all, flattenDeltas(d.Children)...)
+	}
+	return all
+}
+
+func changeTypes(deltas []NodeDelta) map[ChangeType]int {
+	counts := make(map[ChangeType]int)
+	for _, d := range flattenDeltas(deltas) {
+		counts[d.ChangeType]++
+	}
+	return counts
+}
+
+func TestDiffTrees_ScanReplacedByBitmapScan(t *testing.T) {
+	c := defaultComparator()
+	old := node("Nested Loop",
+		scan("Index Scan", "customers"),
+		scan("Seq Scan", "orders"),
+	)
+	bitmap := scan("Bitmap Heap Scan", "orders", plan.PlanNode{NodeType: "Bitmap Index Scan", IndexName: "orders_customer_id_idx", TotalCost: 10, ActualLoops: 1})
+	new := node("Nested Loop",
+		scan("Index Scan", "customers"),
+		bitmap,
+	)
+
+	deltas := c.diffTrees(&old, &new)
+	counts := changeTypes(deltas)
+	if counts[TypeChanged] != 1 || counts[Added] != 1 || counts[Removed] != 0 {
+		t.Fatalf("change types = %v, want 1 type changed and 1 added", counts)
+	}
+	orders := deltas[0].Children[1]
+	if orders.OldNodeType != "Seq Scan" || orders.NewNodeType != "Bitmap Heap Scan" || orders.Relation != "orders" {
+		t.Errorf("orders delta = %s → %s on %s, want Seq Scan → Bitmap Heap Scan on orders", orders.OldNodeType, orders.NewNodeType, orders.Relation)
+	}
+	if len(orders.Children) != 1 || orders.Children[0].ChangeType != Added {
+		t.Errorf("want the Bitmap Index Scan added under orders, got %+v", orders.Children)
+	}
+}
+
+func TestDiffTrees_ReplacementInFirstPosition(t *testing.T) {
+	// Positionally, the new Hash would pair with the old Seq Scan.
+	c := defaultComparator()
+	old := node("Hash Join",
+		scan("Seq Scan", "orders"),
+		node("Hash", scan("Seq Scan", "customers")),
+	)
+	new := node("Hash Join",
+		node("Hash", scan("Seq Scan", "customers")),
+	)
+
+	counts := changeTypes(c.diffTrees(&old, &new))
+	if counts[TypeChanged] != 0 || counts[Removed] != 1 {
+		t.Errorf("change types = %v, want only the orders scan removed", counts)
+	}
+}
+
+func TestDiffTrees_JoinSidesSwapped(t *testing.T) {
+	c := defaultComparator()
+	old := node("Hash Join",
+		scan("Seq Scan", "orders"),
+		node("Hash", scan("Seq Scan", "customers")),
+	)
+	new := node("Hash Join",
+		scan("Seq Scan", "customers"),
+		node("Hash", scan("Seq Scan", "orders")),
+	)
+
+	result := c.Compare(plan.ExplainOutput{Plan: old, ExecutionTime: 1}, plan.ExplainOutput{Plan: new, ExecutionTime: 1})
+	join := result.Deltas[0]
+	if !join.Swapped {
+		t.Errorf("Hash Join Swapped = false, want true")
+	}
+	for _, d := range flattenDeltas(result.Deltas) {
+		if d.ChangeType == TypeChanged {
+			t.Errorf("unexpected type change %s → %s", d.OldNodeType, d.NewNodeType)
+		}
+		if d.Relation != "" && (d.Moved || d.ChangeType == Added || d.ChangeType == Removed) {
+			t.Errorf("scan on %s: change %s, moved %v; want it paired in place", d.Relation, d.ChangeType, d.Moved)
+		}
+	}
+	if result.Summary.NodesMoved != 1 {
+		t.Errorf("NodesMoved = %d, want 1 (the swapped join)", result.Summary.NodesMoved)
+	}
+}
+
+func TestDiffTrees_NodeMoved(t *testing.T) {
+	c := defaultComparator()
+	old := node("Hash Join",
+		scan("Seq Scan", "a"),
+		node("Hash", node("Nested Loop",
+			scan("Seq Scan", "b"),
+			scan("Index Scan", "c"),
+		)),
+	)
+	new := node("Hash Join",
+		node("Nested Loop",
+			scan("Seq Scan", "a"),
+			scan("Index Scan", "c"),
+		),
+		node("Hash", scan("Seq Scan", "b")),
+	)
+
+	var a *NodeDelta
+	all := flattenDeltas(c.diffTrees(&old, &new))
+	for i := range all {
+		if all[i].Relation == "a" {
+			a = &all[i]
+		}
+	}
+	if a == nil || !a.Moved || a.MovedFrom != "Hash Join" {
+		t.Fatalf("scan on a = %+v, want moved from under Hash Join", a)
+	}
+	if a.ChangeType != Modified {
+		t.Errorf("moved node ChangeType = %s, want modified", a.ChangeType)
+	}
+}
+
+func TestDiffTrees_RootAdded(t *testing.T) {
+	c := defaultComparator()
+	old := node("Sort", scan("Seq Scan", "orders"))
+	new := node("Limit", node("Sort", scan("Seq Scan", "orders")))
+
+	deltas := c.diffTrees(&old, &new)
+	if len(deltas) != 1 || deltas[0].ChangeType != Added || deltas[0].NodeType != "Limit" {
+		t.Fatalf("deltas = %+v, want the Limit added on top", deltas)
+	}
+	if counts := changeTypes(deltas); counts[Added] != 1 || counts[NoChange] != 2 {
+		t.Errorf("change types = %v, want the Sort and scan unchanged", counts)
+	}
+}
+
+func TestDiffTrees_RootsUnrelated(t *testing.T) {
+	c := defaultComparator()
+	old := scan("Seq Scan", "orders")
+	new := scan("Seq Scan", "customers")
+
+	deltas := c.diffTrees(&old, &new)
+	if len(deltas) != 2 || deltas[0].ChangeType != Added || deltas[1].ChangeType != Removed {
+		t.Errorf("deltas = %+v, want customers added and orders removed", deltas)
+	}
+}
+
+func TestDiffTrees_SelfJoinPairsByAlias(t *testing.T) {
+	c := defaultComparator()
+	o1 := scan("Seq Scan", "orders")
+	o1.Alias = "o1"
+	o2 := scan("Index Scan", "orders")
+	o2.Alias = "o2"
+	o2.TotalCost = 50
+
+	newO2 := o2
+	newO2.TotalCost = 5
+
+	old := node("Nested Loop", o1, o2)
+	new := node("Nested Loop", newO2, o1)
+
+	deltas := c.diffTrees(&old, &new)
+	if counts := changeTypes(deltas); counts[TypeChanged] != 0 {
+		t.Errorf("change types = %v, want the scans paired by alias", counts)
+	}
+	if first := deltas[0].Children[0]; first.OldCost != 50 || first.NewCost != 5 {
+		t.Errorf("o2 cost = %.0f → %.0f, want 50 → 5", first.OldCost, first.NewCost)
+	}
+	if !deltas[0].Swapped {
+		t.Error("Nested Loop Swapped = false, want true")
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	_, orders := indexTree(&plan.PlanNode{NodeType: "Seq Scan", RelationName: "orders"})
+	_, ordersIndex := indexTree(&plan.PlanNode{NodeType: "Index Scan", RelationName: "orders"})
+	_, customers := indexTree(&plan.PlanNode{NodeType: "Seq Scan", RelationName: "customers"})
+	_, sort := indexTree(&plan.PlanNode{NodeType: "Sort", Plans: []plan.PlanNode{{NodeType: "Seq Scan", RelationName: "orders"}}})
+	_, hash := indexTree(&plan.PlanNode{NodeType: "Hash", Plans: []plan.PlanNode{{NodeType: "Seq Scan", RelationName: "orders"}}})
+
+	if s := similarity(orders[0], ordersIndex[0]); s <= 0 {
+		t.Error("scans of the same relation should pair")
+	}
+	if s := similarity(orders[0], customers[0]); s != 0 {
+		t.Errorf("scans of different relations scored %.1f, want 0", s)
+	}
+	if s := similarity(sort[0], hash[0]); s != 0 {
+		t.Errorf("Sort and Hash scored %.1f, want 0", s)
+	}
+	if same, replaced := similarity(orders[0], orders[0]), similarity(orders[0], ordersIndex[0]); same <= replaced {
+		t.Errorf("same node type scored %.1f, not above a replacement's %.1f", same, replaced)
+	}
+}
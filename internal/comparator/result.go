@@ -56,6 +56,15 @@ type NodeDelta struct {
 	OldNodeType string
 	NewNodeType string
 
+	// Moved is true when the node sits under a different parent than the
+	// old node it was paired with; MovedFrom describes the old parent,
+	// e.g. "Hash Join" or "Nested Loop".
+	Moved     bool
+	MovedFrom string `json:",omitempty"`
+	// Swapped is true when the node's children come in a different order
+	// than the old node's, e.g. a join's outer and inner sides swapped.
+	Swapped bool
+
 	OldCost   float64
 	NewCost   float64
 	CostDelta float64
@@ -67,6 +76,9 @@ type NodeDelta struct {
 	TimeDelta float64
 	TimePct   float64
 	TimeDir   Direction
+	// TimeSignificance tests the node's time across runs when both plans
+	// were aggregated from several; see Summary.TimeSignificance.
+	TimeSignificance *Significance
 
 	OldRows   float64
 	NewRows   float64
@@ -119,11 +131,19 @@ type NodeDelta struct {
 }
 
 type ComparisonResult struct {
-	Deltas  []NodeDelta
+	Deltas []NodeDelta
+	// Changes are the plan-shape changes the deltas add up to, such as a
+	// scan method or join algorithm changing, in plan order.
+	Changes []ChangeEvent
 	Summary Summary
 }
 
 type Summary struct {
+	// OldLabel and NewLabel name the two sides when they are more than
+	// before and after, e.g. the profiles one query was planned on.
+	OldLabel string `json:",omitempty"`
+	NewLabel string `json:",omitempty"`
+
 	OldTotalCost float64
 	NewTotalCost float64
 	CostDelta    float64
@@ -135,6 +155,10 @@ type Summary struct {
 	TimeDelta        float64
 	TimePct          float64
 	TimeDir          Direction
+	// TimeSignificance tests the execution times of every run when both
+	// plans were aggregated from several: see plan.Aggregate. TimeDir is
+	// Unchanged when the difference is within noise.
+	TimeSignificance *Significance
 
 	OldPlanningTime float64
 	NewPlanningTime float64
@@ -144,11 +168,17 @@ type Summary struct {
 	NodesRemoved     int
 	NodesModified    int
 	NodesTypeChanged int
+	// NodesMoved counts nodes under a different parent than before, or
+	// whose children swapped places. They are also counted as modified or
+	// type changed.
+	NodesMoved int
 
 	OldTotalReads int64 // Shared + Local + Temp reads
 	NewTotalReads int64
-	OldTotalHits  int64 // Shared + Local hits
-	NewTotalHits  int64
+	// ReadsDir is Unchanged when reads moved by less than the threshold.
+	ReadsDir     Direction
+	OldTotalHits int64 // Shared + Local hits
+	NewTotalHits int64
 
 	// Buffers (full shared/local/temp x hit/read/dirtied/written breakdown
 	// for the whole plan). PostgreSQL's per-node counters are cumulative
@@ -160,5 +190,30 @@ type Summary struct {
 	OldSortSpaceUsed int64 // kB, summed across all sort nodes
 	NewSortSpaceUsed int64 // kB
 
+	// Settings are the configuration parameters each plan was made with,
+	// if known: see plan.ExplainOutput.Settings.
+	OldSettings map[string]string
+	NewSettings map[string]string
+
+	// OldBenchmark and NewBenchmark describe the runs each plan was
+	// aggregated from, when it was: see plan.Aggregate. The times and
+	// counts above are then medians.
+	OldBenchmark *plan.Benchmark
+	NewBenchmark *plan.Benchmark
+
+	// EstimateOnly is true when either plan lacks ANALYZE data. Rows are
+	// then planner estimates, time and buffers are not compared, and the
+	// verdict reflects estimated cost only.
+	EstimateOnly bool
+
 	Verdict string
 }
+
+// StatementComparison is the comparison of one pair of statements from two
+// multi-statement inputs. Index is 1-based; Query is the new side's
+// statement text when known, falling back to the old side's.
+type StatementComparison struct {
+	Index  int
+	Query  string
+	Result ComparisonResult
+}
@@ -0,0 +1,202 @@
+package comparator
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// DefaultAlpha is the significance level used when Comparator.Alpha is
+// unset.
+const DefaultAlpha = 0.05
+
+// exactMaxSamples bounds the combined sample count for which the exact
+// Mann-Whitney distribution is computed; larger samples use the normal
+// approximation.
+const exactMaxSamples = 60
+
+// Significance is the outcome of testing whether a timing's old and new
+// samples come from different distributions, using the two-sided
+// Mann-Whitney U test. Unlike a percent change between two single values, it
+// accounts for how much the samples vary from run to run.
+type Significance struct {
+	OldSamples int
+	NewSamples int
+
+	PValue float64
+	// Significant is true when PValue is below the comparator's alpha;
+	// otherwise the difference is within noise. Verdict says which:
+	// "significant" or "within noise".
+	Significant bool
+	Verdict     string
+
+	// EffectSize is Cliff's delta: the share of (old, new) sample pairs in
+	// which new is slower, minus the share in which it is faster. It runs
+	// from -1 (every new sample faster than every old one) to 1.
+	EffectSize float64
+	// Magnitude classifies |EffectSize| as negligible, small, medium or
+	// large.
+	Magnitude string
+}
+
+func (c *Comparator) alpha() float64 {
+	if c.Alpha > 0 {
+		return c.Alpha
+	}
+	return DefaultAlpha
+}
+
+// significance tests old against new, or returns nil when either side has
+// fewer than two samples to estimate the noise from.
+func (c *Comparator) significance(old, new []float64) *Significance {
+	if len(old) < 2 || len(new) < 2 {
+		return nil
+	}
+	p := mannWhitneyP(old, new)
+	delta := cliffsDelta(old, new)
+	sig := &Significance{
+		OldSamples:  len(old),
+		NewSamples:  len(new),
+		PValue:      p,
+		Significant: p < c.alpha(),
+		Verdict:     "within noise",
+		EffectSize:  delta,
+		Magnitude:   effectMagnitude(delta),
+	}
+	if sig.Significant {
+		sig.Verdict = "significant"
+	}
+	return sig
+}
+
+// cliffsDelta returns P(y > x) - P(y < x) over all pairs.
+func cliffsDelta(x, y []float64) float64 {
+	var greater, less int
+	for _, a := range x {
+		for _, b := range y {
+			switch {
+			case b > a:
+				greater++
+			case b < a:
+				less++
+			}
+		}
+	}
+	return float64(greater-less) / float64(len(x)*len(y))
+}
+
+// effectMagnitude uses the usual thresholds for Cliff's delta (Romano et
+// al., 2006).
+func effectMagnitude(delta float64) string {
+	switch d := math.Abs(delta); {
+	case d < 0.147:
+		return "negligible"
+	case d < 0.33:
+		return "small"
+	case d < 0.474:
+		return "medium"
+	}
+	return "large"
+}
+
+// mannWhitneyP returns the two-sided p-value of the Mann-Whitney U test.
+// Small samples without ties use the exact distribution of U; otherwise the
+// normal approximation with tie and continuity corrections.
+func mannWhitneyP(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	n := n1 + n2
+
+	type sample struct {
+		value float64
+		fromX bool
+	}
+	all := make([]sample, 0, n)
+	for _, v := range x {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range y {
+		all = append(all, sample{v, false})
+	}
+	slices.SortFunc(all, func(a, b sample) int { return cmp.Compare(a.value, b.value) })
+
+	// Rank with ties sharing their average rank.
+	var rankSumX, tieTerm float64
+	ties := false
+	for i := 0; i < n; {
+		j := i
+		for j < n && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromX {
+				rankSumX += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+	u := rankSumX - float64(n1*(n1+1))/2
+	mean := float64(n1*n2) / 2
+
+	if !ties && n <= exactMaxSamples {
+		return exactMannWhitneyP(n1, n2, u)
+	}
+
+	variance := float64(n1*n2) / 12 * (float64(n+1) - tieTerm/float64(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z <= 0 {
+		return 1
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// exactMannWhitneyP returns the two-sided p-value of observing u, from the
+// number of ways each U value arises among all arrangements of n1 and n2
+// distinct samples.
+func exactMannWhitneyP(n1, n2 int, u float64) float64 {
+	maxU := n1 * n2
+	// counts[i][j][k] is the number of arrangements of i x-samples and j
+	// y-samples with U = k; only two rows of i are kept at a time.
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = make([]float64, maxU+1)
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			cur[j] = make([]float64, maxU+1)
+			for k := 0; k <= i*j; k++ {
+				// The largest sample is either an x, which beats all j
+				// y-samples, or a y, which beats none of the x-samples.
+				if k >= j {
+					cur[j][k] += prev[j][k-j]
+				}
+				cur[j][k] += cur[j-1][k]
+			}
+		}
+		prev = cur
+	}
+	counts := prev[n2]
+
+	var total, lower, upper float64
+	for k, c := range counts {
+		total += c
+		if float64(k) <= u {
+			lower += c
+		}
+		if float64(k) >= u {
+			upper += c
+		}
+	}
+	return min(1, 2*min(lower, upper)/total)
+}
@@ -0,0 +1,163 @@
+package comparator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func TestMannWhitneyP(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+		want float64
+	}{
+		// Exact: 2 of the C(6,3) = 20 arrangements are as extreme.
+		{"separated 3v3", []float64{1, 2, 3}, []float64{4, 5, 6}, 0.1},
+		// 2 of C(10,5) = 252.
+		{"separated 5v5", []float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 2.0 / 252},
+		{"interleaved", []float64{1, 3, 5, 7}, []float64{2, 4, 6, 8}, 0.6857},
+		{"all tied", []float64{5, 5, 5}, []float64{5, 5, 5}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mannWhitneyP(tt.x, tt.y); math.Abs(got-tt.want) > 1e-3 {
+				t.Errorf("mannWhitneyP(%v, %v) = %.4f, want %.4f", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+
+	// Symmetric in direction.
+	if a, b := mannWhitneyP([]float64{1, 2, 3}, []float64{4, 5, 6}), mannWhitneyP([]float64{4, 5, 6}, []float64{1, 2, 3}); a != b {
+		t.Errorf("p-value depends on direction: %v vs %v", a, b)
+	}
+}
+
+func TestMannWhitneyP_NormalApproximation(t *testing.T) {
+	// Ties force the normal approximation; a clear shift is still
+	// significant and a tie-heavy overlap is not.
+	x := []float64{10, 10, 11, 11, 12, 12, 13, 13}
+	y := []float64{20, 20, 21, 21, 22, 22, 23, 23}
+	if p := mannWhitneyP(x, y); p >= 0.01 {
+		t.Errorf("p = %.4f for a clear shift, want < 0.01", p)
+	}
+	if p := mannWhitneyP(x, []float64{10, 11, 11, 12, 12, 13, 13, 10}); p < 0.5 {
+		t.Errorf("p = %.4f for identical samples, want >= 0.5", p)
+	}
+}
+
+func TestCliffsDelta(t *testing.T) {
+	if d := cliffsDelta([]float64{1, 2}, []float64{3, 4}); d != 1 {
+		t.Errorf("cliffsDelta = %v, want 1 (new always larger)", d)
+	}
+	if d := cliffsDelta([]float64{3, 4}, []float64{1, 2}); d != -1 {
+		t.Errorf("cliffsDelta = %v, want -1 (new always smaller)", d)
+	}
+	if d := cliffsDelta([]float64{1, 3}, []float64{1, 3}); d != 0 {
+		t.Errorf("cliffsDelta = %v, want 0", d)
+	}
+}
+
+func benchmarkedPlan(times ...float64) plan.ExplainOutput {
+	runs := make([]plan.ExplainOutput, len(times))
+	for i, ms := range times {
+		runs[i] = plan.ExplainOutput{
+			ExecutionTime: ms,
+			Plan: plan.PlanNode{
+				NodeType:        "Seq Scan",
+				RelationName:    "orders",
+				TotalCost:       100,
+				ActualTotalTime: ms,
+				ActualRows:      10,
+				ActualLoops:     1,
+			},
+		}
+	}
+	return plan.Aggregate(runs)
+}
+
+func TestCompare_BenchmarkWithinNoise(t *testing.T) {
+	// The medians differ by 10%, but the runs overlap heavily.
+	old := benchmarkedPlan(8, 10, 14, 9, 13, 10.5)
+	new := benchmarkedPlan(11, 12, 9.5, 14.5, 10.8, 8.5)
+
+	result := defaultComparator().Compare(old, new)
+	s := result.Summary
+
+	if s.TimeSignificance == nil {
+		t.Fatal("TimeSignificance is nil, want a test result")
+	}
+	if s.TimeSignificance.Significant || s.TimeSignificance.Verdict != "within noise" {
+		t.Errorf("TimeSignificance = %+v, want within noise", *s.TimeSignificance)
+	}
+	if s.TimeDir != Unchanged {
+		t.Errorf("TimeDir = %v, want Unchanged", s.TimeDir)
+	}
+	root := result.Deltas[0]
+	if root.TimeSignificance == nil || root.TimeSignificance.Significant {
+		t.Errorf("node TimeSignificance = %+v, want within noise", root.TimeSignificance)
+	}
+	if root.ChangeType != NoChange {
+		t.Errorf("node ChangeType = %v, want NoChange for a difference within noise", root.ChangeType)
+	}
+}
+
+func TestCompare_BenchmarkSignificant(t *testing.T) {
+	old := benchmarkedPlan(10, 10.2, 9.8, 10.1, 9.9, 10.3)
+	new := benchmarkedPlan(15, 15.4, 14.8, 15.1, 14.9, 15.2)
+
+	s := defaultComparator().Compare(old, new).Summary
+
+	if s.TimeSignificance == nil || !s.TimeSignificance.Significant {
+		t.Fatalf("TimeSignificance = %+v, want significant", s.TimeSignificance)
+	}
+	if s.TimeSignificance.EffectSize != 1 || s.TimeSignificance.Magnitude != "large" {
+		t.Errorf("effect = %v (%s), want 1 (large)", s.TimeSignificance.EffectSize, s.TimeSignificance.Magnitude)
+	}
+	if s.TimeDir != Regressed {
+		t.Errorf("TimeDir = %v, want Regressed", s.TimeDir)
+	}
+}
+
+func TestCompare_BenchmarkNodeTestedPerLoop(t *testing.T) {
+	// Per loop the scan got faster, but it runs four times as often, so
+	// its loop total grew: the test must agree with the per-loop delta.
+	loops := func(n int64, times ...float64) plan.ExplainOutput {
+		runs := make([]plan.ExplainOutput, len(times))
+		for i, ms := range times {
+			runs[i] = plan.ExplainOutput{
+				ExecutionTime: ms * float64(n),
+				Plan: plan.PlanNode{
+					NodeType: "Seq Scan", RelationName: "orders", TotalCost: 100,
+					ActualTotalTime: ms, ActualRows: 10, ActualLoops: n,
+				},
+			}
+		}
+		return plan.Aggregate(runs)
+	}
+	old := loops(1, 10, 10.2, 9.8, 10.1, 9.9, 10.3)
+	new := loops(4, 6, 6.1, 5.9, 6.2, 5.8, 6.05)
+
+	root := defaultComparator().Compare(old, new).Deltas[0]
+
+	if root.TimeDir != Improved {
+		t.Errorf("TimeDir = %v, want Improved per loop", root.TimeDir)
+	}
+	if sig := root.TimeSignificance; sig == nil || !sig.Significant || sig.EffectSize >= 0 {
+		t.Errorf("TimeSignificance = %+v, want significant and pointing down", sig)
+	}
+}
+
+func TestCompare_SingleRunHasNoSignificance(t *testing.T) {
+	old := benchmarkedPlan(10)
+	new := benchmarkedPlan(20)
+
+	s := defaultComparator().Compare(old, new).Summary
+	if s.TimeSignificance != nil {
+		t.Errorf("TimeSignificance = %+v, want nil with one run per side", s.TimeSignificance)
+	}
+	if s.TimeDir != Regressed {
+		t.Errorf("TimeDir = %v, want Regressed from the threshold alone", s.TimeDir)
+	}
+}
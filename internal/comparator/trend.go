@@ -0,0 +1,240 @@
+package comparator
+
+import "github.com/jacobarthurs/pgplan/internal/plan"
+
+// TrendResult follows one query's plan across several versions, e.g. one
+// per release, in order.
+type TrendResult struct {
+	// Labels name the plans, e.g. their file names.
+	Labels []string
+
+	// Summary holds a series per plan-level metric: cost, execution and
+	// planning time, buffer hits and reads.
+	Summary []Series
+
+	// Steps compare each plan with the one before it.
+	Steps []TrendStep
+	// FirstRegression is the index of the first plan that regressed against
+	// the one before it (see TrendStep.Regressed), if any.
+	FirstRegression *int `json:",omitempty"`
+
+	// Nodes follow each plan node through the plans it appears in, in
+	// order of first appearance.
+	Nodes []NodeTrend
+}
+
+// Series is one metric's value in every plan of a trend.
+type Series struct {
+	Metric string
+	Unit   string `json:",omitempty"`
+	// Values holds one value per plan, nil where the plan doesn't have the
+	// metric: no ANALYZE data, or no such node.
+	Values []*float64
+	// FirstRegression is the index of the first plan whose value is worse
+	// than the previous plan's by more than the threshold, if any. Row
+	// counts have none: more rows aren't worse.
+	FirstRegression *int `json:",omitempty"`
+}
+
+// TrendStep compares plan From with plan To = From+1.
+type TrendStep struct {
+	From, To int
+	Verdict  string
+	CostDir  Direction
+	TimeDir  Direction
+	// Regressed is true when execution time regressed, or cost when either
+	// plan lacks ANALYZE data.
+	Regressed bool
+	Changes   []ChangeEvent
+}
+
+// NodeTrend follows one plan node, paired from plan to plan as Compare
+// pairs them.
+type NodeTrend struct {
+	NodeType string
+	Relation string `json:",omitempty"`
+	// NodeTypes is the node's type in each plan, "" where it is absent.
+	NodeTypes []string
+	// Series holds the node's cost, time, rows and blocks read.
+	Series []Series
+}
+
+// Trend compares plans in order. labels name the plans; there must be one
+// per plan.
+func (c *Comparator) Trend(labels []string, plans []plan.ExplainOutput) TrendResult {
+	result := TrendResult{
+		Labels: labels,
+		Summary: []Series{
+			c.series("Cost", "", true, plans, func(p plan.ExplainOutput) *float64 {
+				return &p.Plan.TotalCost
+			}),
+			c.series("Execution Time", "ms", true, plans, func(p plan.ExplainOutput) *float64 {
+				if !p.Analyzed() {
+					return nil
+				}
+				return &p.ExecutionTime
+			}),
+			c.series("Planning Time", "ms", true, plans, func(p plan.ExplainOutput) *float64 {
+				if p.PlanningTime == 0 {
+					return nil
+				}
+				return &p.PlanningTime
+			}),
+			c.series("Buffer Hits", "blocks", false, plans, func(p plan.ExplainOutput) *float64 {
+				if !p.Analyzed() {
+					return nil
+				}
+				hits := float64(plan.AggregateBuffers(&p.Plan).TotalHit())
+				return &hits
+			}),
+			c.series("Buffer Reads", "blocks", true, plans, func(p plan.ExplainOutput) *float64 {
+				if !p.Analyzed() {
+					return nil
+				}
+				reads := float64(plan.AggregateBuffers(&p.Plan).TotalRead())
+				return &reads
+			}),
+		},
+	}
+
+	for i := 1; i < len(plans); i++ {
+		cmp := c.Compare(plans[i-1], plans[i])
+		s := cmp.Summary
+		step := TrendStep{
+			From:    i - 1,
+			To:      i,
+			Verdict: s.Verdict,
+			CostDir: s.CostDir,
+			TimeDir: s.TimeDir,
+			Changes: cmp.Changes,
+		}
+		step.Regressed = s.TimeDir == Regressed || s.EstimateOnly && s.CostDir == Regressed
+		if step.Regressed && result.FirstRegression == nil {
+			result.FirstRegression = &step.To
+		}
+		result.Steps = append(result.Steps, step)
+	}
+
+	result.Nodes = c.nodeTrends(plans)
+	return result
+}
+
+// series builds a metric's series from value, which returns nil when a plan
+// lacks the metric. Only metrics where lower is better get a
+// FirstRegression.
+func (c *Comparator) series(metric, unit string, lowerIsBetter bool, plans []plan.ExplainOutput, value func(plan.ExplainOutput) *float64) Series {
+	s := Series{Metric: metric, Unit: unit, Values: make([]*float64, len(plans))}
+	for i, p := range plans {
+		if v := value(p); v != nil {
+			val := *v
+			s.Values[i] = &val
+		}
+	}
+	if lowerIsBetter {
+		s.FirstRegression = c.firstRegression(s.Values)
+	}
+	return s
+}
+
+// firstRegression returns the index of the first value worse than the one
+// before it, or nil.
+func (c *Comparator) firstRegression(values []*float64) *int {
+	for i := 1; i < len(values); i++ {
+		if values[i-1] == nil || values[i] == nil {
+			continue
+		}
+		if c.direction(*values[i-1], *values[i], true) == Regressed {
+			return &i
+		}
+	}
+	return nil
+}
+
+// nodeTrends pairs the nodes of each plan with those of the plan before it,
+// and follows each chain of paired nodes through the plans.
+func (c *Comparator) nodeTrends(plans []plan.ExplainOutput) []NodeTrend {
+	type nodeValues struct {
+		types                   []string
+		cost, time, rows, reads []*float64
+		relation                string
+	}
+	var tracks []*nodeValues
+	trackOf := make(map[*plan.PlanNode]*nodeValues)
+
+	record := func(i int, n *plan.PlanNode, from *plan.PlanNode) {
+		t := trackOf[from]
+		if from == nil || t == nil {
+			t = &nodeValues{
+				types: make([]string, len(plans)),
+				cost:  make([]*float64, len(plans)),
+				time:  make([]*float64, len(plans)),
+				rows:  make([]*float64, len(plans)),
+				reads: make([]*float64, len(plans)),
+			}
+			tracks = append(tracks, t)
+		}
+		trackOf[n] = t
+
+		analyzed := plans[i].Analyzed()
+		t.types[i] = n.NodeType
+		t.relation = coalesce(n.RelationName, t.relation)
+		cost, rows := n.TotalCost, float64(n.PlanRows)
+		t.cost[i] = &cost
+		if analyzed {
+			rows = n.ActualRows
+			time := n.ActualTotalTime
+			reads := float64(plan.NodeBufferBreakdown(n).TotalRead())
+			t.time[i], t.reads[i] = &time, &reads
+		}
+		t.rows[i] = &rows
+	}
+
+	var walkFirst func(n *plan.PlanNode)
+	walkFirst = func(n *plan.PlanNode) {
+		record(0, n, nil)
+		for i := range n.Plans {
+			walkFirst(&n.Plans[i])
+		}
+	}
+	if len(plans) > 0 {
+		walkFirst(&plans[0].Plan)
+	}
+
+	for i := 1; i < len(plans); i++ {
+		_, newRoot := matchTrees(&plans[i-1].Plan, &plans[i].Plan)
+		var walk func(m *matchNode)
+		walk = func(m *matchNode) {
+			var from *plan.PlanNode
+			if m.match != nil {
+				from = m.match.node
+			}
+			record(i, m.node, from)
+			for _, child := range m.children {
+				walk(child)
+			}
+		}
+		walk(newRoot)
+	}
+
+	trends := make([]NodeTrend, 0, len(tracks))
+	for _, t := range tracks {
+		nodeType := ""
+		for _, typ := range t.types {
+			if typ != "" {
+				nodeType = typ
+			}
+		}
+		trends = append(trends, NodeTrend{
+			NodeType:  nodeType,
+			Relation:  t.relation,
+			NodeTypes: t.types,
+			Series: []Series{
+				{Metric: "Cost", Values: t.cost, FirstRegression: c.firstRegression(t.cost)},
+				{Metric: "Time", Unit: "ms", Values: t.time, FirstRegression: c.firstRegression(t.time)},
+				{Metric: "Rows", Values: t.rows},
+				{Metric: "Reads", Unit: "blocks", Values: t.reads, FirstRegression: c.firstRegression(t.reads)},
+			},
+		})
+	}
+	return trends
+}
@@ -0,0 +1,119 @@
+package comparator
+
+import (
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func trendPlan(executionTime float64, root plan.PlanNode) plan.ExplainOutput {
+	return plan.ExplainOutput{Plan: root, ExecutionTime: executionTime}
+}
+
+func values(vs []*float64) []any {
+	out := make([]any, len(vs))
+	for i, v := range vs {
+		if v != nil {
+			out[i] = *v
+		}
+	}
+	return out
+}
+
+func TestTrend_FlagsFirstRegression(t *testing.T) {
+	c := defaultComparator()
+	indexed := scan("Index Scan", "orders")
+	indexed.IndexName = "orders_pkey"
+	seq := scan("Seq Scan", "orders")
+	seq.TotalCost = 500
+
+	plans := []plan.ExplainOutput{
+		trendPlan(1.0, node("Limit", indexed)),
+		trendPlan(1.01, node("Limit", indexed)),
+		trendPlan(30, node("Limit", node("Sort", seq))),
+		trendPlan(31, node("Limit", node("Sort", seq))),
+	}
+	result := c.Trend([]string{"v1", "v2", "v3", "v4"}, plans)
+
+	if result.FirstRegression == nil || *result.FirstRegression != 2 {
+		t.Fatalf("FirstRegression = %v, want 2", result.FirstRegression)
+	}
+	if len(result.Steps) != 3 {
+		t.Fatalf("got %d steps, want 3", len(result.Steps))
+	}
+	if step := result.Steps[1]; !step.Regressed || len(step.Changes) == 0 {
+		t.Errorf("step 2 → 3 = %+v, want regressed with plan-shape changes", step)
+	}
+	if result.Steps[0].Regressed || result.Steps[2].Regressed {
+		t.Errorf("only step 2 → 3 should regress: %+v", result.Steps)
+	}
+
+	time := result.Summary[1]
+	if time.Metric != "Execution Time" || time.FirstRegression == nil || *time.FirstRegression != 2 {
+		t.Errorf("execution time series = %+v, want a regression at plan 2", time)
+	}
+	if got := values(time.Values); got[0] != 1.0 || got[3] != 31.0 {
+		t.Errorf("execution time values = %v", got)
+	}
+}
+
+func TestTrend_FollowsNodesAcrossPlans(t *testing.T) {
+	c := defaultComparator()
+	seq := scan("Seq Scan", "orders")
+	idx := scan("Index Scan", "orders")
+
+	plans := []plan.ExplainOutput{
+		trendPlan(1, node("Limit", seq)),
+		trendPlan(1, node("Limit", node("Sort", seq))),
+		trendPlan(1, node("Limit", idx)),
+	}
+	result := c.Trend([]string{"a", "b", "c"}, plans)
+
+	var orders, sort *NodeTrend
+	for i := range result.Nodes {
+		switch result.Nodes[i].NodeType {
+		case "Index Scan":
+			orders = &result.Nodes[i]
+		case "Sort":
+			sort = &result.Nodes[i]
+		}
+	}
+	if orders == nil || sort == nil {
+		t.Fatalf("nodes = %+v, want the orders scan and the sort", result.Nodes)
+	}
+	if len(result.Nodes) != 3 {
+		t.Errorf("got %d nodes, want 3: the scan followed through every plan", len(result.Nodes))
+	}
+	if got := orders.NodeTypes; got[0] != "Seq Scan" || got[1] != "Seq Scan" || got[2] != "Index Scan" || orders.Relation != "orders" {
+		t.Errorf("orders node types = %v on %q", got, orders.Relation)
+	}
+	if got := sort.NodeTypes; got[0] != "" || got[1] != "Sort" || got[2] != "" {
+		t.Errorf("sort node types = %v, want only in plan b", got)
+	}
+	if cost := sort.Series[0]; cost.Values[0] != nil || cost.Values[1] == nil || cost.Values[2] != nil {
+		t.Errorf("sort cost values = %v, want nil where the sort is absent", values(cost.Values))
+	}
+}
+
+func TestTrend_EstimateOnly(t *testing.T) {
+	c := defaultComparator()
+	cheap := scan("Seq Scan", "orders")
+	dear := scan("Seq Scan", "orders")
+	dear.TotalCost = 100
+
+	plans := []plan.ExplainOutput{
+		{Plan: cheap},
+		{Plan: dear},
+	}
+	for i := range plans {
+		plans[i].Plan.ActualLoops = 0
+	}
+	result := c.Trend([]string{"a", "b"}, plans)
+
+	if result.FirstRegression == nil || *result.FirstRegression != 1 {
+		t.Errorf("FirstRegression = %v, want 1 on cost alone", result.FirstRegression)
+	}
+	if v := result.Summary[1].Values; v[0] != nil || v[1] != nil {
+		t.Errorf("execution time values = %v, want none without ANALYZE data", values(v))
+	}
+}
@@ -0,0 +1,185 @@
+// Package gate checks analysis and comparison results against the limits
+// of a CI check: finding severities, budgets for a plan, and how far a
+// comparison may regress.
+package gate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/batch"
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+)
+
+// Violation is one limit a result broke.
+type Violation struct {
+	// Check names the limit, as its command-line flag without dashes:
+	// "fail-on", "max-time", "max-time-regression", ...
+	Check string
+	// File is the input file the violation belongs to, in a batch.
+	File string `json:",omitempty"`
+	// Statement is the 1-based statement the violation belongs to, or 0
+	// for a single-plan input.
+	Statement int `json:",omitempty"`
+	Message   string
+}
+
+// Result is the outcome of a gate: Passed unless there are Violations.
+type Result struct {
+	Passed     bool
+	Violations []Violation
+}
+
+func newResult(violations []Violation) Result {
+	return Result{Passed: len(violations) == 0, Violations: violations}
+}
+
+// Budget limits a single analysis. Zero values are not checked, and FailOn
+// only when set.
+type Budget struct {
+	// FailOn fails on any finding at or above this severity.
+	FailOn *analyzer.Severity
+
+	MaxExecutionTime time.Duration
+	MaxCost          float64
+	// MaxReads limits blocks read (shared, local and temp), and MaxBuffers
+	// blocks read or found in cache.
+	MaxReads   int64
+	MaxBuffers int64
+}
+
+// Check checks one analysis result against b.
+func (b Budget) Check(result analyzer.AnalysisResult) Result {
+	return newResult(b.check(result, 0))
+}
+
+// CheckAll checks every statement's result against b.
+func (b Budget) CheckAll(result analyzer.MultiAnalysisResult) Result {
+	var violations []Violation
+	for _, stmt := range result.Statements {
+		violations = append(violations, b.check(stmt.Result, stmt.Index)...)
+	}
+	return newResult(violations)
+}
+
+// CheckBatch checks every statement of every analyzed file in a batch
+// against b. Files that failed to analyze are not checked.
+func (b Budget) CheckBatch(files []batch.FileResult) Result {
+	var violations []Violation
+	for _, f := range files {
+		if f.Result == nil {
+			continue
+		}
+		for _, stmt := range f.Result.Statements {
+			for _, v := range b.check(stmt.Result, stmt.Index) {
+				v.File = f.File
+				violations = append(violations, v)
+			}
+		}
+	}
+	return newResult(violations)
+}
+
+func (b Budget) check(result analyzer.AnalysisResult, statement int) []Violation {
+	var violations []Violation
+	add := func(check, format string, args ...any) {
+		violations = append(violations, Violation{Check: check, Statement: statement, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if b.FailOn != nil {
+		count := 0
+		for _, f := range result.Findings {
+			if f.Severity >= *b.FailOn {
+				count++
+			}
+		}
+		if count > 0 {
+			add("fail-on", "%d finding(s) at or above %s", count, *b.FailOn)
+		}
+	}
+	if b.MaxExecutionTime > 0 {
+		limit := float64(b.MaxExecutionTime) / float64(time.Millisecond)
+		if result.EstimateOnly {
+			add("max-time", "execution time unknown without ANALYZE data; budget is %.3f ms", limit)
+		} else if result.ExecutionTime > limit {
+			add("max-time", "execution time %.3f ms exceeds budget %.3f ms", result.ExecutionTime, limit)
+		}
+	}
+	if b.MaxCost > 0 && result.TotalCost > b.MaxCost {
+		add("max-cost", "total cost %.2f exceeds budget %.2f", result.TotalCost, b.MaxCost)
+	}
+	if b.MaxReads > 0 && result.Buffers.TotalRead() > b.MaxReads {
+		add("max-reads", "%d blocks read exceed budget %d", result.Buffers.TotalRead(), b.MaxReads)
+	}
+	if touched := result.Buffers.TotalRead() + result.Buffers.TotalHit(); b.MaxBuffers > 0 && touched > b.MaxBuffers {
+		add("max-buffers", "%d blocks read or hit exceed budget %d", touched, b.MaxBuffers)
+	}
+	return violations
+}
+
+// Limits bounds how far a comparison may regress, as the largest allowed
+// increase in percent. A nil limit is not checked; 0 fails on any
+// regression.
+type Limits struct {
+	Time  *float64
+	Cost  *float64
+	Reads *float64
+
+	// IgnoreUnknown passes the time and reads limits of a comparison
+	// without ANALYZE data, which can't tell, instead of failing them.
+	IgnoreUnknown bool
+}
+
+// Check checks one comparison's summary against l. Time, cost and reads
+// only count as regressed when the comparator says so, which takes its
+// threshold and, for time with several runs per side, the significance test
+// into account. Without ANALYZE data, time and reads are unknown, and their
+// limits fail unless IgnoreUnknown is set.
+func (l Limits) Check(result comparator.ComparisonResult) Result {
+	return newResult(l.check(result.Summary, 0))
+}
+
+// CheckAll checks every statement's comparison against l.
+func (l Limits) CheckAll(results []comparator.StatementComparison) Result {
+	var violations []Violation
+	for _, r := range results {
+		violations = append(violations, l.check(r.Result.Summary, r.Index)...)
+	}
+	return newResult(violations)
+}
+
+func (l Limits) check(s comparator.Summary, statement int) []Violation {
+	var violations []Violation
+	add := func(check, format string, args ...any) {
+		violations = append(violations, Violation{Check: check, Statement: statement, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.EstimateOnly && !l.IgnoreUnknown {
+		if l.Time != nil {
+			add("max-time-regression", "execution time unknown without ANALYZE data; limit is %.1f%%", *l.Time)
+		}
+		if l.Reads != nil {
+			add("max-reads-regression", "blocks read unknown without ANALYZE data; limit is %.1f%%", *l.Reads)
+		}
+	}
+	if l.Time != nil && s.TimeDir == comparator.Regressed && s.TimePct > *l.Time {
+		add("max-time-regression", "execution time regressed %+.1f%% (%.3f ms → %.3f ms), limit %.1f%%", s.TimePct, s.OldExecutionTime, s.NewExecutionTime, *l.Time)
+	}
+	if l.Cost != nil && s.CostDir == comparator.Regressed && s.CostPct > *l.Cost {
+		add("max-cost-regression", "cost regressed %+.1f%% (%.2f → %.2f), limit %.1f%%", s.CostPct, s.OldTotalCost, s.NewTotalCost, *l.Cost)
+	}
+	if l.Reads != nil && s.ReadsDir == comparator.Regressed {
+		if pct := readsPct(s.OldTotalReads, s.NewTotalReads); pct > *l.Reads {
+			add("max-reads-regression", "blocks read regressed %+.1f%% (%d → %d), limit %.1f%%", pct, s.OldTotalReads, s.NewTotalReads, *l.Reads)
+		}
+	}
+	return violations
+}
+
+func readsPct(old, new int64) float64 {
+	if old == 0 {
+		return 100
+	}
+	return float64(new-old) / float64(old) * 100
+}
@@ -0,0 +1,164 @@
+package gate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/batch"
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func checks(r Result) []string {
+	var names []string
+	for _, v := range r.Violations {
+		names = append(names, v.Check)
+	}
+	return names
+}
+
+func TestBudget_Check(t *testing.T) {
+	warning := analyzer.Warning
+	result := analyzer.AnalysisResult{
+		TotalCost:     500,
+		ExecutionTime: 250,
+		Buffers:       plan.NodeBuffers{Shared: plan.BlockCounts{Hit: 900, Read: 200}},
+		Findings: []analyzer.Finding{
+			{Severity: analyzer.Info},
+			{Severity: analyzer.Warning},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		budget Budget
+		want   []string
+	}{
+		{"within budget", Budget{MaxExecutionTime: time.Second, MaxCost: 1000, MaxReads: 500, MaxBuffers: 2000}, nil},
+		{"fail on warning", Budget{FailOn: &warning}, []string{"fail-on"}},
+		{"over time and cost", Budget{MaxExecutionTime: 100 * time.Millisecond, MaxCost: 100}, []string{"max-time", "max-cost"}},
+		{"over buffers", Budget{MaxReads: 100, MaxBuffers: 1000}, []string{"max-reads", "max-buffers"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.budget.Check(result)
+			if names := checks(got); len(names) != len(tt.want) || got.Passed != (len(tt.want) == 0) {
+				t.Fatalf("Check() = %+v, want violations %v", got, tt.want)
+			}
+			for i, name := range checks(got) {
+				if name != tt.want[i] {
+					t.Errorf("violation %d = %s, want %s", i, name, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBudget_CheckFailOnIgnoresLowerSeverities(t *testing.T) {
+	critical := analyzer.Critical
+	result := analyzer.AnalysisResult{Findings: []analyzer.Finding{{Severity: analyzer.Warning}}}
+	if got := (Budget{FailOn: &critical}).Check(result); !got.Passed {
+		t.Errorf("Check() = %+v, want passed: no critical findings", got)
+	}
+}
+
+func TestBudget_CheckTimeWithoutAnalyze(t *testing.T) {
+	result := analyzer.AnalysisResult{EstimateOnly: true}
+	if got := (Budget{MaxExecutionTime: time.Second}).Check(result); got.Passed {
+		t.Error("a time budget can't pass without ANALYZE data")
+	}
+}
+
+func TestBudget_CheckAllLabelsStatements(t *testing.T) {
+	multi := analyzer.MultiAnalysisResult{Statements: []analyzer.StatementResult{
+		{Index: 1, Result: analyzer.AnalysisResult{TotalCost: 10}},
+		{Index: 2, Result: analyzer.AnalysisResult{TotalCost: 1000}},
+	}}
+	got := Budget{MaxCost: 100}.CheckAll(multi)
+	if len(got.Violations) != 1 || got.Violations[0].Statement != 2 {
+		t.Errorf("CheckAll() = %+v, want one violation for statement 2", got)
+	}
+}
+
+func TestLimits_Check(t *testing.T) {
+	summary := comparator.Summary{
+		OldTotalCost: 100, NewTotalCost: 130, CostPct: 30, CostDir: comparator.Regressed,
+		OldExecutionTime: 10, NewExecutionTime: 11, TimePct: 10, TimeDir: comparator.Unchanged,
+		OldTotalReads: 100, NewTotalReads: 150, ReadsDir: comparator.Regressed,
+	}
+	result := comparator.ComparisonResult{Summary: summary}
+
+	zero, twenty, hundred := 0.0, 20.0, 100.0
+	tests := []struct {
+		name   string
+		limits Limits
+		want   []string
+	}{
+		{"no limits", Limits{}, nil},
+		// Time is within noise or threshold, so it never counts.
+		{"time unchanged", Limits{Time: &zero}, nil},
+		{"cost over", Limits{Cost: &twenty}, []string{"max-cost-regression"}},
+		{"cost within", Limits{Cost: &hundred}, nil},
+		{"reads over", Limits{Reads: &twenty}, []string{"max-reads-regression"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.limits.Check(result)
+			names := checks(got)
+			if len(names) != len(tt.want) || got.Passed != (len(tt.want) == 0) {
+				t.Fatalf("Check() = %+v, want violations %v", got, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Errorf("violation %d = %s, want %s", i, names[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimits_CheckReadsWithinThreshold(t *testing.T) {
+	// One more block read than none is +100%, but the comparator's
+	// threshold left it unchanged.
+	summary := comparator.Summary{OldTotalReads: 0, NewTotalReads: 1, ReadsDir: comparator.Unchanged}
+	zero := 0.0
+	if got := (Limits{Reads: &zero}).Check(comparator.ComparisonResult{Summary: summary}); !got.Passed {
+		t.Errorf("Check() = %+v, want passed", got)
+	}
+}
+
+func TestLimits_CheckEstimateOnly(t *testing.T) {
+	result := comparator.ComparisonResult{Summary: comparator.Summary{
+		EstimateOnly: true,
+		OldTotalCost: 100, NewTotalCost: 100,
+	}}
+	zero := 0.0
+
+	got := Limits{Time: &zero, Cost: &zero, Reads: &zero}.Check(result)
+	if names := checks(got); got.Passed || strings.Join(names, " ") != "max-time-regression max-reads-regression" {
+		t.Fatalf("Check() = %+v, want time and reads violations", got)
+	}
+	if !strings.Contains(got.Violations[0].Message, "unknown without ANALYZE data") {
+		t.Errorf("message = %q, want it to say time is unknown", got.Violations[0].Message)
+	}
+
+	if got := (Limits{Time: &zero, Reads: &zero, IgnoreUnknown: true}).Check(result); !got.Passed {
+		t.Errorf("Check() with IgnoreUnknown = %+v, want passed", got)
+	}
+}
+
+func TestBudget_CheckBatchLabelsFiles(t *testing.T) {
+	files := []batch.FileResult{
+		{File: "a.sql", Result: &analyzer.MultiAnalysisResult{Statements: []analyzer.StatementResult{
+			{Index: 1, Result: analyzer.AnalysisResult{TotalCost: 10}},
+			{Index: 2, Result: analyzer.AnalysisResult{TotalCost: 1000}},
+		}}},
+		{File: "b.sql", Error: "connecting to database: refused"},
+	}
+	got := Budget{MaxCost: 100}.CheckBatch(files)
+	if len(got.Violations) != 1 || got.Violations[0].File != "a.sql" || got.Violations[0].Statement != 2 {
+		t.Errorf("CheckBatch() = %+v, want one violation for a.sql statement 2", got)
+	}
+}
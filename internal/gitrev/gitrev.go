@@ -0,0 +1,124 @@
+// Package gitrev reads a file as it was at two revisions of the local git
+// repository it belongs to, so a query's plan can be compared across them.
+// It runs git's plumbing commands and never touches the network.
+package gitrev
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Range is a pair of revisions, as given to git: "main..HEAD" compares main
+// with HEAD, and "main...HEAD" compares HEAD with the commit it branched off
+// main at, as a pull request's diff does.
+type Range struct {
+	Old string
+	New string
+	// MergeBase is true for "old...new": the old side is then the merge
+	// base of Old and New rather than Old itself.
+	MergeBase bool
+}
+
+// ParseRange parses "old..new" or "old...new". An empty revision stands for
+// HEAD, as in git, so "main.." is main..HEAD.
+func ParseRange(spec string) (Range, error) {
+	var r Range
+	sep := ".."
+	if strings.Contains(spec, "...") {
+		sep, r.MergeBase = "...", true
+	}
+	old, new, ok := strings.Cut(spec, sep)
+	if !ok || strings.Contains(new, "..") {
+		return Range{}, fmt.Errorf("invalid revision range %q: expected old..new, e.g. main..HEAD", spec)
+	}
+	r.Old = cmp.Or(strings.TrimSpace(old), "HEAD")
+	r.New = cmp.Or(strings.TrimSpace(new), "HEAD")
+	return r, nil
+}
+
+// String returns the range as given to git.
+func (r Range) String() string {
+	if r.MergeBase {
+		return r.Old + "..." + r.New
+	}
+	return r.Old + ".." + r.New
+}
+
+// Versions is a file as it was at both revisions of a range.
+type Versions struct {
+	Path string
+	// OldLabel and NewLabel name the revisions the file was read at.
+	OldLabel string
+	NewLabel string
+	Old      []byte
+	New      []byte
+	// Diff is the unified diff from Old to New, empty when they are the
+	// same.
+	Diff string
+}
+
+// Read reads path as it was at both revisions of r, from the repository
+// that holds it, and diffs the two versions.
+func Read(ctx context.Context, path string, r Range) (Versions, error) {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	// "rev:./name" is relative to git's working directory, set with -C.
+	name = "./" + filepath.ToSlash(name)
+
+	v := Versions{Path: path, OldLabel: r.Old, NewLabel: r.New}
+	old := r.Old
+	if r.MergeBase {
+		base, err := git(ctx, dir, "merge-base", r.Old, r.New)
+		if err != nil {
+			return Versions{}, err
+		}
+		old = strings.TrimSpace(base)
+		v.OldLabel = fmt.Sprintf("%s (merge base %s)", r.Old, shortHash(old))
+	}
+
+	var err error
+	if v.Old, err = gitBytes(ctx, dir, "cat-file", "blob", old+":"+name); err != nil {
+		return Versions{}, err
+	}
+	if v.New, err = gitBytes(ctx, dir, "cat-file", "blob", r.New+":"+name); err != nil {
+		return Versions{}, err
+	}
+	if v.Diff, err = git(ctx, dir, "diff-tree", "-p", "--no-color", old, r.New, "--", name); err != nil {
+		return Versions{}, err
+	}
+	return v, nil
+}
+
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 7)]
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := gitBytes(ctx, dir, args...)
+	return string(out), err
+}
+
+// gitBytes runs git in dir and returns its output, or an error holding
+// what git printed to stderr.
+func gitBytes(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			msg := strings.TrimPrefix(strings.TrimSpace(stderr.String()), "fatal: ")
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("running git: %w", err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,123 @@
+package gitrev
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Range
+		str  string
+	}{
+		{"main..HEAD", Range{Old: "main", New: "HEAD"}, "main..HEAD"},
+		{"v1.2..feature/x", Range{Old: "v1.2", New: "feature/x"}, "v1.2..feature/x"},
+		{"main..", Range{Old: "main", New: "HEAD"}, "main..HEAD"},
+		{"main...HEAD", Range{Old: "main", New: "HEAD", MergeBase: true}, "main...HEAD"},
+	}
+	for _, tt := range tests {
+		got, err := ParseRange(tt.in)
+		if err != nil {
+			t.Errorf("ParseRange(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRange(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if got.String() != tt.str {
+			t.Errorf("ParseRange(%q).String() = %q, want %q", tt.in, got.String(), tt.str)
+		}
+	}
+
+	for _, in := range []string{"main", "a..b..c"} {
+		if _, err := ParseRange(in); err == nil {
+			t.Errorf("ParseRange(%q): expected an error", in)
+		}
+	}
+}
+
+// gitRepo creates a repository whose queries/orders.sql changes from one
+// commit to the next, with a branch "base" at the first.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=pgplan", "-c", "user.email=pgplan@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, "queries"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "queries", "orders.sql"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q")
+	write("SELECT * FROM orders WHERE customer_id = 1;\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "add query")
+	run("branch", "base")
+	write("SELECT * FROM orders WHERE customer_id = 1 ORDER BY created_at;\n")
+	run("commit", "-q", "-am", "sort orders")
+	return dir
+}
+
+func TestRead(t *testing.T) {
+	dir := gitRepo(t)
+	path := filepath.Join(dir, "queries", "orders.sql")
+
+	v, err := Read(context.Background(), path, Range{Old: "base", New: "HEAD"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(v.Old), "customer_id = 1;") || !strings.Contains(string(v.New), "ORDER BY created_at") {
+		t.Errorf("versions = %q, %q", v.Old, v.New)
+	}
+	if v.OldLabel != "base" || v.NewLabel != "HEAD" {
+		t.Errorf("labels = %q, %q", v.OldLabel, v.NewLabel)
+	}
+	for _, want := range []string{"--- a/queries/orders.sql", "-SELECT * FROM orders WHERE customer_id = 1;", "+SELECT * FROM orders WHERE customer_id = 1 ORDER BY created_at;"} {
+		if !strings.Contains(v.Diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, v.Diff)
+		}
+	}
+
+	v, err = Read(context.Background(), path, Range{Old: "HEAD", New: "base", MergeBase: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Diff != "" || string(v.Old) != string(v.New) {
+		t.Errorf("HEAD...base should compare base with itself, got diff:\n%s", v.Diff)
+	}
+	if !strings.HasPrefix(v.OldLabel, "HEAD (merge base ") {
+		t.Errorf("old label = %q, want the merge base", v.OldLabel)
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	dir := gitRepo(t)
+
+	_, err := Read(context.Background(), filepath.Join(dir, "queries", "missing.sql"), Range{Old: "base", New: "HEAD"})
+	if err == nil || !strings.Contains(err.Error(), "does not exist in 'base'") {
+		t.Errorf("err = %v, want a missing path error", err)
+	}
+
+	_, err = Read(context.Background(), filepath.Join(dir, "queries", "orders.sql"), Range{Old: "nope", New: "HEAD"})
+	if err == nil || !strings.Contains(err.Error(), "git cat-file") {
+		t.Errorf("err = %v, want an unknown revision error", err)
+	}
+}
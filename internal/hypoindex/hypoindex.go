@@ -0,0 +1,158 @@
+// Package hypoindex checks whether the planner would use the indexes the
+// analyzer suggests, without building them: each is created hypothetically
+// with the hypopg extension and the SQL is planned again.
+package hypoindex
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// Options says which SQL file the statements were analyzed from, and where
+// to plan it again.
+type Options struct {
+	File   string
+	DBConn string
+	Exec   plan.ExecOptions
+}
+
+// Report is the outcome of Run: one result per distinct suggested index,
+// in the order the findings suggested them.
+type Report struct {
+	File    string
+	Indexes []IndexResult
+	// Picked counts the indexes the planner used in some statement.
+	Picked int
+}
+
+// IndexResult is the outcome of planning with one hypothetical index.
+type IndexResult struct {
+	Index      analyzer.IndexSuggestion
+	Definition string
+	// SuggestedBy lists the findings that suggested the index.
+	SuggestedBy []Source
+
+	// IndexName is the name hypopg gave the index.
+	IndexName string `json:",omitempty"`
+	// Error is why hypopg couldn't create the index, e.g. a column the
+	// suggestion got wrong.
+	Error string `json:",omitempty"`
+
+	// Picked is true when any statement's plan uses the index.
+	Picked     bool
+	Statements []StatementResult `json:",omitempty"`
+}
+
+// Source is a finding that suggested an index.
+type Source struct {
+	Statement int
+	Rule      string
+}
+
+// StatementResult compares a statement's plan with the hypothetical index
+// (new) with its plan without (old). Both are plain EXPLAIN plans, so only
+// estimated cost and rows are compared.
+type StatementResult struct {
+	Statement  int
+	Query      string
+	Picked     bool
+	Comparison comparator.ComparisonResult
+}
+
+// Run creates every index the statements' findings suggest hypothetically,
+// one at a time, and plans opts.File with each: see
+// plan.ExecuteHypothetical. statements are the analysis of opts.File, in
+// order. It fails with plan.ErrNoHypoPG when the database lacks hypopg.
+func Run(ctx context.Context, opts Options, statements []analyzer.StatementResult, c *comparator.Comparator) (Report, error) {
+	report := Report{File: opts.File}
+	if !strings.EqualFold(filepath.Ext(opts.File), ".sql") {
+		return Report{}, fmt.Errorf("%s is not a .sql file: hypothetical indexes are checked by planning the query again", opts.File)
+	}
+	if len(opts.Exec.ParamSets) > 1 {
+		return Report{}, fmt.Errorf("hypothetical indexes are checked with one parameter set, got %d", len(opts.Exec.ParamSets))
+	}
+
+	report.Indexes = suggestions(statements)
+	if len(report.Indexes) == 0 {
+		return report, nil
+	}
+
+	sql, err := os.ReadFile(opts.File)
+	if err != nil {
+		return Report{}, fmt.Errorf("reading SQL file: %w", err)
+	}
+	definitions := make([]string, len(report.Indexes))
+	for i, idx := range report.Indexes {
+		definitions[i] = idx.Definition
+	}
+
+	before, after, err := plan.ExecuteHypothetical(ctx, opts.DBConn, string(sql), definitions, opts.Exec)
+	if err != nil {
+		return Report{}, fmt.Errorf("hypothetical indexes: %w", err)
+	}
+
+	for i := range report.Indexes {
+		idx, hypo := &report.Indexes[i], after[i]
+		if hypo.Err != nil {
+			idx.Error = hypo.Err.Error()
+			continue
+		}
+		idx.IndexName = hypo.IndexName
+		for j := range min(len(before), len(hypo.Plans)) {
+			stmt := StatementResult{
+				Statement:  j + 1,
+				Query:      hypo.Plans[j].QueryText,
+				Picked:     usesIndex(&hypo.Plans[j].Plan, hypo.IndexName),
+				Comparison: c.Compare(before[j], hypo.Plans[j]),
+			}
+			idx.Picked = idx.Picked || stmt.Picked
+			idx.Statements = append(idx.Statements, stmt)
+		}
+		if idx.Picked {
+			report.Picked++
+		}
+	}
+	return report, nil
+}
+
+// suggestions collects the indexes the statements' findings suggest,
+// merging identical definitions.
+func suggestions(statements []analyzer.StatementResult) []IndexResult {
+	var indexes []IndexResult
+	byDefinition := make(map[string]int)
+	for _, s := range statements {
+		for _, f := range s.Result.Findings {
+			for _, idx := range f.Indexes {
+				def := idx.Definition()
+				i, ok := byDefinition[def]
+				if !ok {
+					i = len(indexes)
+					byDefinition[def] = i
+					indexes = append(indexes, IndexResult{Index: idx, Definition: def})
+				}
+				indexes[i].SuggestedBy = append(indexes[i].SuggestedBy, Source{Statement: s.Index, Rule: f.Rule})
+			}
+		}
+	}
+	return indexes
+}
+
+// usesIndex reports whether any node under node scans the index name.
+func usesIndex(node *plan.PlanNode, name string) bool {
+	if node.IndexName == name {
+		return true
+	}
+	for i := range node.Plans {
+		if usesIndex(&node.Plans[i], name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package hypoindex
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func statement(index int, findings ...analyzer.Finding) analyzer.StatementResult {
+	return analyzer.StatementResult{Index: index, Result: analyzer.AnalysisResult{Findings: findings}}
+}
+
+func TestSuggestions_MergesIdenticalIndexes(t *testing.T) {
+	byCustomer := analyzer.IndexSuggestion{Table: "orders", Columns: []string{"customer_id"}}
+	byStatus := analyzer.IndexSuggestion{Table: "orders", Columns: []string{"customer_id"}, Where: "status = 'open'"}
+
+	got := suggestions([]analyzer.StatementResult{
+		statement(1,
+			analyzer.Finding{Rule: "Seq Scan with Filter", Indexes: []analyzer.IndexSuggestion{byCustomer}},
+			analyzer.Finding{Rule: "Sort Spill to Disk"},
+		),
+		statement(2, analyzer.Finding{Rule: "Seq Scan in Join", Indexes: []analyzer.IndexSuggestion{byCustomer, byStatus}}),
+	})
+
+	if len(got) != 2 {
+		t.Fatalf("got %d indexes, want 2: %+v", len(got), got)
+	}
+	if got[0].Definition != "CREATE INDEX ON orders (customer_id)" || len(got[0].SuggestedBy) != 2 {
+		t.Errorf("first index = %+v, want orders (customer_id) suggested twice", got[0])
+	}
+	if src := got[1].SuggestedBy; len(src) != 1 || src[0] != (Source{Statement: 2, Rule: "Seq Scan in Join"}) {
+		t.Errorf("partial index suggested by %+v", src)
+	}
+}
+
+func TestUsesIndex(t *testing.T) {
+	root := plan.PlanNode{NodeType: "Nested Loop", Plans: []plan.PlanNode{
+		{NodeType: "Seq Scan", RelationName: "customers"},
+		{NodeType: "Bitmap Heap Scan", RelationName: "orders", Plans: []plan.PlanNode{
+			{NodeType: "Bitmap Index Scan", IndexName: "<13495>btree_orders_customer_id"},
+		}},
+	}}
+	if !usesIndex(&root, "<13495>btree_orders_customer_id") {
+		t.Error("usesIndex missed the bitmap index scan")
+	}
+	if usesIndex(&root, "orders_pkey") {
+		t.Error("usesIndex found an index the plan doesn't use")
+	}
+}
+
+func TestRun(t *testing.T) {
+	c := &comparator.Comparator{Threshold: 5}
+
+	_, err := Run(context.Background(), Options{File: "plan.json"}, nil, c)
+	if err == nil || !strings.Contains(err.Error(), "not a .sql file") {
+		t.Errorf("err = %v, want a .sql file error", err)
+	}
+
+	// Nothing to create, so no connection is needed.
+	report, err := Run(context.Background(), Options{File: "query.sql"}, []analyzer.StatementResult{statement(1, analyzer.Finding{Rule: "Sort Spill to Disk"})}, c)
+	if err != nil || len(report.Indexes) != 0 {
+		t.Errorf("Run = %+v, %v; want an empty report", report, err)
+	}
+}
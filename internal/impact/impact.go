@@ -0,0 +1,205 @@
+// Package impact checks what a migration would do to a suite of queries: it
+// plans every query, applies the migration in a transaction that is rolled
+// back, plans every query again, and ranks the queries by how much their
+// plans regressed.
+package impact
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// Impact is how a migration affected one query's plan.
+type Impact string
+
+const (
+	// Regressed: the query got slower, or dearer when only planned, or
+	// broke a regression limit.
+	Regressed Impact = "regressed"
+	// Changed: the plan shape changed, but not for better or worse.
+	Changed Impact = "changed"
+	// Improved: the query got faster, or cheaper when only planned.
+	Improved Impact = "improved"
+	// Unchanged: the same plan, within the comparator's threshold.
+	Unchanged Impact = "unchanged"
+)
+
+// rank orders impacts worst first.
+func (i Impact) rank() int {
+	switch i {
+	case Regressed:
+		return 0
+	case Changed:
+		return 1
+	case Improved:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Options says which migration to check against which queries, and where.
+type Options struct {
+	Migration string
+	Files     []string
+	DBConn    string
+	Exec      plan.ExecOptions
+}
+
+// Report is the outcome of Run.
+type Report struct {
+	Migration string
+	// Queries are every statement of every query file, worst first: see
+	// rankQueries.
+	Queries   []QueryImpact
+	Regressed int
+	Changed   int
+	Improved  int
+	Unchanged int
+	// Gate is the outcome of the regression limits, if any were given.
+	Gate *gate.Result `json:",omitempty"`
+}
+
+// QueryImpact compares one statement's plan after the migration (new) with
+// its plan before (old).
+type QueryImpact struct {
+	File      string
+	Statement int
+	Query     string
+	Impact    Impact
+
+	// ShapeChanged is true when the planner chose a different plan after
+	// the migration: see plan.SameShape.
+	ShapeChanged bool
+	// Regression is the change in execution time in percent, or in
+	// estimated cost when the plans have no ANALYZE data. Queries of the
+	// same impact are ranked by it.
+	Regression float64
+	// Violations are the regression limits the statement broke.
+	Violations []string `json:",omitempty"`
+	Comparison comparator.ComparisonResult
+}
+
+// Run reads the migration and query files, plans every query before and
+// after the migration with plan.ExecuteMigration, and compares each
+// statement's plans. The migration is always rolled back. With limits, a
+// statement that breaks one counts as regressed and fails the report's gate.
+func Run(ctx context.Context, opts Options, c *comparator.Comparator, limits *gate.Limits) (Report, error) {
+	migration, err := os.ReadFile(opts.Migration)
+	if err != nil {
+		return Report{}, fmt.Errorf("reading migration: %w", err)
+	}
+	if len(opts.Files) == 0 {
+		return Report{}, fmt.Errorf("no query files to check the migration against")
+	}
+
+	scripts := make([]plan.Script, len(opts.Files))
+	for i, file := range opts.Files {
+		if !strings.EqualFold(filepath.Ext(file), ".sql") {
+			return Report{}, fmt.Errorf("%s is not a .sql file: the queries are planned before and after the migration", file)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return Report{}, fmt.Errorf("reading query file: %w", err)
+		}
+		scripts[i] = plan.Script{Name: file, SQL: string(data)}
+	}
+
+	before, after, err := plan.ExecuteMigration(ctx, opts.DBConn, string(migration), scripts, opts.Exec)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Migration: opts.Migration}
+	var violations []gate.Violation
+	for i, file := range opts.Files {
+		for j := range min(len(before[i]), len(after[i])) {
+			q, broken := compareQuery(before[i][j], after[i][j], c, limits)
+			q.File, q.Statement = file, j+1
+			for _, v := range broken {
+				v.File, v.Statement = file, j+1
+				violations = append(violations, v)
+			}
+			report.Queries = append(report.Queries, q)
+		}
+	}
+	rankQueries(report.Queries)
+
+	for _, q := range report.Queries {
+		switch q.Impact {
+		case Regressed:
+			report.Regressed++
+		case Changed:
+			report.Changed++
+		case Improved:
+			report.Improved++
+		default:
+			report.Unchanged++
+		}
+	}
+	if limits != nil {
+		result := gate.Result{Passed: len(violations) == 0, Violations: violations}
+		report.Gate = &result
+	}
+	return report, nil
+}
+
+// compareQuery compares a statement's plans from before and after the
+// migration and classifies the difference, returning the limits it broke.
+func compareQuery(old, new plan.ExplainOutput, c *comparator.Comparator, limits *gate.Limits) (QueryImpact, []gate.Violation) {
+	result := c.Compare(old, new)
+	q := QueryImpact{
+		Query:        new.QueryText,
+		ShapeChanged: !plan.SameShape(&old.Plan, &new.Plan),
+		Comparison:   result,
+	}
+
+	var violations []gate.Violation
+	if limits != nil {
+		violations = limits.Check(result).Violations
+		for _, v := range violations {
+			q.Violations = append(q.Violations, v.Message)
+		}
+	}
+
+	s := result.Summary
+	dir := s.TimeDir
+	q.Regression = s.TimePct
+	if s.EstimateOnly {
+		dir = s.CostDir
+		q.Regression = s.CostPct
+	}
+	switch {
+	case dir == comparator.Regressed || len(violations) > 0:
+		q.Impact = Regressed
+	case dir == comparator.Improved:
+		q.Impact = Improved
+	case q.ShapeChanged:
+		q.Impact = Changed
+	default:
+		q.Impact = Unchanged
+	}
+	return q, violations
+}
+
+// rankQueries sorts queries worst first: by impact, then by regression,
+// largest first, then in file and statement order.
+func rankQueries(queries []QueryImpact) {
+	slices.SortStableFunc(queries, func(a, b QueryImpact) int {
+		return cmp.Or(
+			cmp.Compare(a.Impact.rank(), b.Impact.rank()),
+			cmp.Compare(b.Regression, a.Regression),
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.Statement, b.Statement),
+		)
+	})
+}
@@ -0,0 +1,116 @@
+package impact
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func analyzed(executionTime, cost float64, root plan.PlanNode) plan.ExplainOutput {
+	root.TotalCost = cost
+	root.ActualLoops = 1
+	return plan.ExplainOutput{Plan: root, ExecutionTime: executionTime}
+}
+
+func seqScan(relation string) plan.PlanNode {
+	return plan.PlanNode{NodeType: "Seq Scan", RelationName: relation, ActualLoops: 1}
+}
+
+func indexScan(relation, index string) plan.PlanNode {
+	return plan.PlanNode{NodeType: "Index Scan", RelationName: relation, IndexName: index, ActualLoops: 1}
+}
+
+func TestCompareQuery(t *testing.T) {
+	c := &comparator.Comparator{Threshold: 5}
+	seq := analyzed(10, 100, seqScan("orders"))
+	idx := analyzed(1, 10, indexScan("orders", "orders_customer_idx"))
+
+	tests := []struct {
+		name     string
+		old, new plan.ExplainOutput
+		want     Impact
+		shape    bool
+	}{
+		{"index dropped", idx, seq, Regressed, true},
+		{"index added", seq, idx, Improved, true},
+		{"same plan", seq, analyzed(10.1, 100, seqScan("orders")), Unchanged, false},
+		{"other index, same time", idx, analyzed(1, 10, indexScan("orders", "orders_created_idx")), Changed, true},
+	}
+	for _, tt := range tests {
+		q, _ := compareQuery(tt.old, tt.new, c, nil)
+		if q.Impact != tt.want || q.ShapeChanged != tt.shape {
+			t.Errorf("%s: impact %s, shape changed %v; want %s, %v", tt.name, q.Impact, q.ShapeChanged, tt.want, tt.shape)
+		}
+	}
+}
+
+func TestCompareQuery_EstimateOnlyByCost(t *testing.T) {
+	c := &comparator.Comparator{Threshold: 5}
+	old := plan.ExplainOutput{Plan: plan.PlanNode{NodeType: "Index Scan", RelationName: "orders", IndexName: "orders_pkey", TotalCost: 10}}
+	new := plan.ExplainOutput{Plan: plan.PlanNode{NodeType: "Seq Scan", RelationName: "orders", TotalCost: 400}}
+
+	q, _ := compareQuery(old, new, c, nil)
+	if q.Impact != Regressed || q.Regression != q.Comparison.Summary.CostPct {
+		t.Errorf("impact %s with regression %.1f%%, want regressed by the cost change", q.Impact, q.Regression)
+	}
+}
+
+func TestCompareQuery_Limits(t *testing.T) {
+	c := &comparator.Comparator{Threshold: 5}
+	zero := 0.0
+	old := analyzed(10, 100, seqScan("orders"))
+	old.Plan.SharedReadBlocks = 10
+	new := analyzed(10, 100, seqScan("orders"))
+	new.Plan.SharedReadBlocks = 50
+
+	q, violations := compareQuery(old, new, c, &gate.Limits{Reads: &zero})
+	if q.Impact != Regressed || len(violations) != 1 || len(q.Violations) != 1 {
+		t.Errorf("impact %s with violations %v, want regressed by the reads limit", q.Impact, q.Violations)
+	}
+}
+
+func TestRankQueries(t *testing.T) {
+	queries := []QueryImpact{
+		{File: "a.sql", Statement: 1, Impact: Unchanged},
+		{File: "a.sql", Statement: 2, Impact: Improved, Regression: -50},
+		{File: "b.sql", Statement: 1, Impact: Regressed, Regression: 20},
+		{File: "c.sql", Statement: 1, Impact: Changed},
+		{File: "d.sql", Statement: 1, Impact: Regressed, Regression: 900},
+	}
+	rankQueries(queries)
+
+	var got []string
+	for _, q := range queries {
+		got = append(got, q.File)
+	}
+	if want := "d.sql b.sql c.sql a.sql a.sql"; strings.Join(got, " ") != want {
+		t.Errorf("ranked %v, want %s", got, want)
+	}
+	if queries[3].Impact != Improved {
+		t.Errorf("fourth query is %s, want the improved one", queries[3].Impact)
+	}
+}
+
+func TestRun_RequiresSQLFiles(t *testing.T) {
+	dir := t.TempDir()
+	migration := filepath.Join(dir, "migration.sql")
+	if err := os.WriteFile(migration, []byte("DROP INDEX orders_customer_idx;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Run(context.Background(), Options{Migration: migration, Files: []string{"plan.json"}}, &comparator.Comparator{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not a .sql file") {
+		t.Errorf("err = %v, want a .sql file error", err)
+	}
+
+	_, err = Run(context.Background(), Options{Migration: filepath.Join(dir, "missing.sql"), Files: []string{"q.sql"}}, &comparator.Comparator{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "reading migration") {
+		t.Errorf("err = %v, want a migration read error", err)
+	}
+}
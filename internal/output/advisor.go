@@ -0,0 +1,34 @@
+package output
+
+import (
+	"io"
+
+	"github.com/jacobarthurs/pgplan/internal/advisor"
+)
+
+// RenderIndexAdviceText renders the advisor's candidates, most valuable
+// first, with the findings behind each, and where the script was written.
+func RenderIndexAdviceText(w io.Writer, advice advisor.Advice, scriptPath string) error {
+	tw := &textWriter{w: w}
+
+	tw.printf("\n%s%sIndex Advisor%s\n\n", colorBold, colorCyan, colorReset)
+	if len(advice.Candidates) == 0 {
+		tw.printf("  %sNo finding suggests an index.%s\n", colorDim, colorReset)
+	}
+	for i, c := range advice.Candidates {
+		tw.printf("  %d. %s%s%s %s(%s)%s\n", i+1, colorBold, c.Definition, colorReset, colorDim, advisor.Describe(c), colorReset)
+		for _, src := range c.Sources {
+			tw.printf("     %s%s: %s on %s%s\n", colorDim, advisor.Location(src), src.Rule, src.Relation, colorReset)
+		}
+		for _, m := range c.Merged {
+			tw.printf("     %sreplaces %s%s\n", colorDim, m, colorReset)
+		}
+		if c.AlternativeTo > 0 {
+			tw.printf("     %salternative to %d, which serves the same nodes%s\n", colorYellow, c.AlternativeTo, colorReset)
+		}
+	}
+	if scriptPath != "" {
+		tw.printf("\n  Script written to %s\n", scriptPath)
+	}
+	return tw.err
+}
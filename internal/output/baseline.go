@@ -0,0 +1,43 @@
+package output
+
+import (
+	"io"
+
+	"github.com/jacobarthurs/pgplan/internal/baseline"
+)
+
+// RenderBaselineReportText renders one line per checked statement, with the
+// reasons any failed, and the overall outcome.
+func RenderBaselineReportText(w io.Writer, report baseline.Report) error {
+	tw := &textWriter{w: w}
+
+	tw.printf("%s%sBaseline Check%s %s(%s, %d statements)%s\n\n", colorBold, colorCyan, colorReset, colorDim, report.Baseline, len(report.Statements), colorReset)
+
+	for _, s := range report.Statements {
+		mark, color := "✓", colorGreen
+		if s.Status != baseline.StatusPass {
+			mark, color = "✗", colorRed
+		}
+		tw.printf("  %s%s%s %s #%d", color, mark, colorReset, s.File, s.Statement)
+		switch {
+		case s.Status == baseline.StatusNew || s.Status == baseline.StatusMissing:
+			tw.printf(" %s(%s)%s", colorYellow, s.Status, colorReset)
+		case s.Comparison != nil:
+			tw.printf(" %s%s%s", colorDim, s.Comparison.Summary.Verdict, colorReset)
+		}
+		tw.printf("\n")
+		if s.Query != "" && s.Status != baseline.StatusPass {
+			tw.printf("    %s%s%s\n", colorDim, querySnippet(s.Query), colorReset)
+		}
+		for _, r := range s.Reasons {
+			tw.printf("    %s→ %s%s\n", color, r, colorReset)
+		}
+	}
+
+	if report.Passed {
+		tw.printf("\n%s%sBaseline check passed.%s\n", colorBold, colorGreen, colorReset)
+	} else {
+		tw.printf("\n%s%sBaseline check failed: %d of %d statements.%s\n", colorBold, colorRed, report.Failed, len(report.Statements), colorReset)
+	}
+	return tw.err
+}
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"io"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/batch"
+)
+
+// RenderBatchFileText renders one file's result in a batch: a line with its
+// statement count, findings and execution time, and its most severe
+// findings, or the error that stopped it.
+func RenderBatchFileText(w io.Writer, r batch.FileResult) error {
+	tw := &textWriter{w: w}
+
+	if r.Result == nil {
+		tw.printf("%s✗%s %s  %s%s%s\n", colorRed, colorReset, r.File, colorRed, r.Error, colorReset)
+		return tw.err
+	}
+
+	var findings []analyzer.Finding
+	for _, stmt := range r.Result.Statements {
+		findings = append(findings, stmt.Result.Findings...)
+	}
+
+	s := r.Result.Summary
+	mark, color := "✓", colorGreen
+	if s.Critical > 0 {
+		mark, color = "!", colorRed
+	} else if s.Warnings > 0 {
+		mark, color = "!", colorYellow
+	}
+	tw.printf("%s%s%s %s  %s%d statement(s), %s", color, mark, colorReset, r.File, colorDim, s.Statements, severityCounts(findings))
+	if s.ExecutionTime > 0 {
+		tw.printf(", %.3f ms", s.ExecutionTime)
+	}
+	tw.printf("%s\n", colorReset)
+	if r.Warning != "" {
+		tw.printf("    %s%s%s\n", colorYellow, r.Warning, colorReset)
+	}
+
+	shown := 0
+	for _, stmt := range r.Result.Statements {
+		for _, f := range stmt.Result.Findings {
+			if shown == maxGroupFindings {
+				break
+			}
+			shown++
+			label, color := severityFormat(f.Severity)
+			tw.printf("    %s%-8s%s ", color, label, colorReset)
+			if s.Statements > 1 {
+				tw.printf("#%d ", stmt.Index)
+			}
+			tw.printf("%s\n", f.Description)
+		}
+	}
+	if rest := len(findings) - shown; rest > 0 {
+		tw.printf("    %s… and %d more%s\n", colorDim, rest, colorReset)
+	}
+	return tw.err
+}
+
+// RenderBatchReportText renders a batch's summary: totals, the top most
+// frequent rules, most affected relations and slowest statements (all of
+// them when top <= 0), and the files that failed.
+func RenderBatchReportText(w io.Writer, report batch.Report, top int) error {
+	tw := &textWriter{w: w}
+	s := report.Summary
+
+	tw.printf("\n%s%sBatch Summary%s\n\n", colorBold, colorCyan, colorReset)
+	tw.printf("  Files:          %d", s.Files)
+	if s.Failed > 0 {
+		tw.printf(" %s(%d failed)%s", colorRed, s.Failed, colorReset)
+	}
+	tw.printf("\n")
+	tw.printf("  Statements:     %d\n", s.Statements)
+	tw.printf("  Total Cost:     %.2f\n", s.TotalCost)
+	if s.ExecutionTime > 0 {
+		tw.printf("  Execution Time: %.3f ms\n", s.ExecutionTime)
+	}
+	tw.printf("  Findings:       %d critical, %d warning, %d info\n", s.Critical, s.Warnings, s.Infos)
+
+	if rules := truncate(s.Rules, top); len(rules) > 0 {
+		tw.printf("\n%s%sMost Frequent Rules%s\n\n", colorBold, colorCyan, colorReset)
+		for _, r := range rules {
+			label, color := severityFormat(r.Severity)
+			tw.printf("  %5d  %s%-8s%s %s %s(%d file(s))%s\n", r.Findings, color, label, colorReset, r.Rule, colorDim, r.Files, colorReset)
+		}
+	}
+
+	if relations := truncate(s.Relations, top); len(relations) > 0 {
+		tw.printf("\n%s%sMost Affected Relations%s\n\n", colorBold, colorCyan, colorReset)
+		for _, r := range relations {
+			tw.printf("  %5d  %s %s(", r.Findings, r.Relation, colorDim)
+			if r.Critical > 0 {
+				tw.printf("%d critical, ", r.Critical)
+			}
+			tw.printf("%d file(s))%s\n", r.Files, colorReset)
+		}
+	}
+
+	if slowest := truncate(s.Slowest, top); len(slowest) > 0 {
+		heading := "Slowest Statements"
+		if s.ExecutionTime == 0 {
+			heading = "Costliest Statements (estimated)"
+		}
+		tw.printf("\n%s%s%s%s\n\n", colorBold, colorCyan, heading, colorReset)
+		for i, st := range slowest {
+			tw.printf("  %s%d.%s ", colorBold, i+1, colorReset)
+			if st.EstimateOnly {
+				tw.printf("cost %.2f", st.TotalCost)
+			} else {
+				tw.printf("%.3f ms", st.ExecutionTime)
+			}
+			tw.printf("  %s #%d\n", st.File, st.Statement)
+			if st.Query != "" {
+				tw.printf("     %s%s%s\n", colorDim, querySnippet(st.Query), colorReset)
+			}
+		}
+	}
+
+	if s.Failed > 0 {
+		tw.printf("\n%s%sFailed Files (%d)%s\n\n", colorBold, colorRed, s.Failed, colorReset)
+		for _, r := range report.Files {
+			if r.Result == nil {
+				tw.printf("  %s✗%s %s: %s\n", colorRed, colorReset, r.File, r.Error)
+			}
+		}
+	}
+	return tw.err
+}
+
+func truncate[T any](items []T, top int) []T {
+	if top > 0 && len(items) > top {
+		return items[:top]
+	}
+	return items
+}
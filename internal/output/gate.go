@@ -0,0 +1,32 @@
+package output
+
+import (
+	"io"
+
+	"github.com/jacobarthurs/pgplan/internal/gate"
+)
+
+// RenderGateText renders the outcome of a CI gate, listing each violation.
+func RenderGateText(w io.Writer, result gate.Result) error {
+	tw := &textWriter{w: w}
+
+	if result.Passed {
+		tw.printf("\n%s%sGate: passed%s\n", colorBold, colorGreen, colorReset)
+		return tw.err
+	}
+
+	tw.printf("\n%s%sGate: failed (%d violation(s))%s\n", colorBold, colorRed, len(result.Violations), colorReset)
+	for _, v := range result.Violations {
+		tw.printf("  %s✗%s ", colorRed, colorReset)
+		switch {
+		case v.File != "" && v.Statement > 0:
+			tw.printf("%s #%d: ", v.File, v.Statement)
+		case v.File != "":
+			tw.printf("%s: ", v.File)
+		case v.Statement > 0:
+			tw.printf("statement %d: ", v.Statement)
+		}
+		tw.printf("%s %s(--%s)%s\n", v.Message, colorDim, v.Check, colorReset)
+	}
+	return tw.err
+}
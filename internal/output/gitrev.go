@@ -0,0 +1,39 @@
+package output
+
+import (
+	"io"
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/gitrev"
+)
+
+// RenderSourceDiffText renders the unified diff of a file across two git
+// revisions, to show ahead of the plan diff. git's own header lines are
+// left out: the heading names the file and revisions instead.
+func RenderSourceDiffText(w io.Writer, v gitrev.Versions) error {
+	tw := &textWriter{w: w}
+
+	tw.printf("%s%sSource Changes: %s%s %s(%s → %s)%s\n\n", colorBold, colorCyan, v.Path, colorReset, colorDim, v.OldLabel, v.NewLabel, colorReset)
+	if v.Diff == "" {
+		tw.printf("  %sUnchanged.%s\n\n", colorDim, colorReset)
+		return tw.err
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(v.Diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "), strings.HasPrefix(line, "index "),
+			strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			tw.printf("  %s%s%s\n", colorCyan, line, colorReset)
+		case strings.HasPrefix(line, "+"):
+			tw.printf("  %s%s%s\n", colorGreen, line, colorReset)
+		case strings.HasPrefix(line, "-"):
+			tw.printf("  %s%s%s\n", colorRed, line, colorReset)
+		default:
+			tw.printf("  %s\n", line)
+		}
+	}
+	tw.printf("\n")
+	return tw.err
+}
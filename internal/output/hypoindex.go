@@ -0,0 +1,65 @@
+package output
+
+import (
+	"io"
+
+	"github.com/jacobarthurs/pgplan/internal/hypoindex"
+)
+
+// RenderHypotheticalIndexesText renders, for each suggested index, whether
+// the planner picked it when created hypothetically, and the estimated cost
+// of each statement with it against without.
+func RenderHypotheticalIndexesText(w io.Writer, report hypoindex.Report) error {
+	tw := &textWriter{w: w}
+
+	tw.printf("%s%sHypothetical Indexes%s\n\n", colorBold, colorCyan, colorReset)
+	if len(report.Indexes) == 0 {
+		tw.printf("  %sNo finding suggests an index to try.%s\n", colorDim, colorReset)
+		return tw.err
+	}
+
+	for _, idx := range report.Indexes {
+		mark, color := "✗", colorDim
+		switch {
+		case idx.Error != "":
+			mark, color = "!", colorYellow
+		case idx.Picked:
+			mark, color = "✓", colorGreen
+		}
+		tw.printf("  %s%s%s %s\n", color, mark, colorReset, idx.Definition)
+
+		for _, src := range idx.SuggestedBy {
+			tw.printf("    %ssuggested by %s", colorDim, src.Rule)
+			if len(idx.Statements) > 1 {
+				tw.printf(" (#%d)", src.Statement)
+			}
+			tw.printf("%s\n", colorReset)
+		}
+		if idx.Error != "" {
+			tw.printf("    %scould not be created: %s%s\n\n", colorYellow, idx.Error, colorReset)
+			continue
+		}
+
+		for _, stmt := range idx.Statements {
+			indent := "    "
+			if len(idx.Statements) > 1 {
+				tw.printf("    %s#%d%s\n", colorDim, stmt.Statement, colorReset)
+				indent = "      "
+			}
+			if stmt.Picked {
+				tw.printf("%s%spicked by the planner%s\n", indent, colorGreen, colorReset)
+			} else {
+				tw.printf("%s%snot used%s\n", indent, colorDim, colorReset)
+			}
+			s := stmt.Comparison.Summary
+			tw.printf("%sCost: %s\n", indent, formatDelta(s.OldTotalCost, s.NewTotalCost, s.CostPct, s.CostDir, "%.2f"))
+			for _, c := range stmt.Comparison.Changes {
+				tw.printf("%s• %s\n", indent, c.Description)
+			}
+		}
+		tw.printf("\n")
+	}
+
+	tw.printf("%s%d of %d suggested index(es) picked by the planner.%s\n", colorBold, report.Picked, len(report.Indexes), colorReset)
+	return tw.err
+}
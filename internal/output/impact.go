@@ -0,0 +1,60 @@
+package output
+
+import (
+	"io"
+
+	"github.com/jacobarthurs/pgplan/internal/impact"
+)
+
+// RenderImpactText renders a migration impact report: every query the
+// migration affected, worst first, with its time and cost before and after
+// and the plan-shape changes, then a count of each impact.
+func RenderImpactText(w io.Writer, report impact.Report) error {
+	tw := &textWriter{w: w}
+
+	tw.printf("%s%sMigration Impact: %s%s %s(%d statements)%s\n\n", colorBold, colorCyan, report.Migration, colorReset, colorDim, len(report.Queries), colorReset)
+
+	for _, q := range report.Queries {
+		if q.Impact == impact.Unchanged {
+			continue
+		}
+		s := q.Comparison.Summary
+
+		color := colorYellow
+		switch q.Impact {
+		case impact.Regressed:
+			color = colorRed
+		case impact.Improved:
+			color = colorGreen
+		}
+		tw.printf("  %s%-9s%s %s #%d", color, q.Impact, colorReset, q.File, q.Statement)
+		if q.ShapeChanged {
+			tw.printf(" %s(plan changed)%s", colorDim, colorReset)
+		}
+		tw.printf("\n")
+		if q.Query != "" {
+			tw.printf("            %s%s%s\n", colorDim, querySnippet(q.Query), colorReset)
+		}
+		if !s.EstimateOnly {
+			tw.printf("            Execution Time: %s (%s)\n",
+				formatDelta(s.OldExecutionTime, s.NewExecutionTime, s.TimePct, s.TimeDir, "%.3f ms"),
+				formatDurationDelta(s.TimeDelta))
+		}
+		tw.printf("            Cost:           %s\n", formatDelta(s.OldTotalCost, s.NewTotalCost, s.CostPct, s.CostDir, "%.2f"))
+		for _, c := range q.Comparison.Changes {
+			tw.printf("            • %s\n", c.Description)
+		}
+		tw.printf("\n")
+	}
+
+	if report.Regressed+report.Changed+report.Improved == 0 {
+		tw.printf("%sThe migration changed no query's plan.%s\n", colorBold, colorReset)
+		return tw.err
+	}
+	tw.printf("%s%s%d regressed%s, %s%d changed%s, %s%d improved%s, %d unchanged\n",
+		colorBold, colorRed, report.Regressed, colorReset,
+		colorYellow, report.Changed, colorReset,
+		colorGreen, report.Improved, colorReset,
+		report.Unchanged)
+	return tw.err
+}
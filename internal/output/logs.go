@@ -0,0 +1,101 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/autoexplain"
+)
+
+// maxGroupFindings caps the findings listed per query group; the rest are
+// summarized as a count.
+const maxGroupFindings = 3
+
+// RenderLogReportText renders the worst top query groups of report (all of
+// them when top <= 0). blockSize is as for RenderAnalysisText.
+func RenderLogReportText(w io.Writer, report autoexplain.Report, top int, blockSize int64) error {
+	tw := &textWriter{w: w, blockSize: blockSize}
+
+	tw.printf("%s%sLog Summary%s\n\n", colorBold, colorCyan, colorReset)
+	tw.printf("  Format:         %s\n", report.Format)
+	tw.printf("  Plans:          %d", report.Entries)
+	if len(report.Skipped) > 0 {
+		tw.printf(" %s(%d unparseable skipped)%s", colorYellow, len(report.Skipped), colorReset)
+	}
+	tw.printf("\n")
+	tw.printf("  Queries:        %d distinct\n", report.Queries)
+	tw.printf("  Total Duration: %.3f ms\n", report.TotalDuration)
+	if !report.FirstSeen.IsZero() {
+		tw.printf("  Time Range:     %s → %s\n", formatLogTime(report.FirstSeen), formatLogTime(report.LastSeen))
+	}
+	tw.printf("\n")
+
+	if len(report.Groups) == 0 {
+		tw.printf("%s%sNo auto_explain plans found.%s\n", colorBold, colorGreen, colorReset)
+		return tw.err
+	}
+
+	groups := report.Groups
+	if top > 0 && len(groups) > top {
+		groups = groups[:top]
+	}
+	tw.printf("%s%sWorst Offenders (%d of %d, by total duration)%s\n\n", colorBold, colorCyan, len(groups), report.Queries, colorReset)
+
+	for i, g := range groups {
+		tw.renderQueryGroup(i+1, g)
+		if i < len(groups)-1 {
+			tw.printf("\n")
+		}
+	}
+
+	return tw.err
+}
+
+func (tw *textWriter) renderQueryGroup(rank int, g autoexplain.QueryGroup) {
+	tw.printf("  %s%d.%s %s%s%s\n", colorBold, rank, colorReset, colorDim, querySnippet(g.Fingerprint), colorReset)
+	tw.printf("     calls %d, total %.3f ms, mean %.3f ms, max %.3f ms\n", g.Count, g.TotalDuration, g.MeanDuration, g.MaxDuration)
+	switch {
+	case g.FirstSeen.IsZero():
+	case g.FirstSeen.Equal(g.LastSeen):
+		tw.printf("     seen %s\n", formatLogTime(g.FirstSeen))
+	default:
+		tw.printf("     seen %s → %s\n", formatLogTime(g.FirstSeen), formatLogTime(g.LastSeen))
+	}
+
+	findings := g.Analysis.Findings
+	if len(findings) == 0 {
+		tw.printf("     %sNo issues found in slowest run (line %d).%s\n", colorGreen, g.SlowestLine, colorReset)
+		return
+	}
+
+	tw.printf("     Slowest run (line %d):\n", g.SlowestLine)
+	for _, f := range findings[:min(len(findings), maxGroupFindings)] {
+		label, color := severityFormat(f.Severity)
+		tw.printf("     %s%-8s%s %s\n", color, label, colorReset, f.Description)
+		tw.printf("     %s→ %s%s\n", colorDim, f.Suggestion, colorReset)
+	}
+	if rest := len(findings) - maxGroupFindings; rest > 0 {
+		tw.printf("     %s… and %d more (%s)%s\n", colorDim, rest, severityCounts(findings[maxGroupFindings:]), colorReset)
+	}
+}
+
+func severityCounts(findings []analyzer.Finding) string {
+	var critical, warnings, infos int
+	for _, f := range findings {
+		switch f.Severity {
+		case analyzer.Critical:
+			critical++
+		case analyzer.Warning:
+			warnings++
+		default:
+			infos++
+		}
+	}
+	return fmt.Sprintf("%d critical, %d warning, %d info", critical, warnings, infos)
+}
+
+func formatLogTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05 MST")
+}
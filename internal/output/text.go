@@ -1,9 +1,12 @@
 package output
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"maps"
 	"math"
+	"slices"
 	"strings"
 
 	"github.com/jacobarthurs/pgplan/internal/analyzer"
@@ -25,6 +28,10 @@ type textWriter struct {
 	w         io.Writer
 	err       error
 	blockSize int64
+
+	// estimateOnly labels node row counts as planner estimates while
+	// rendering an estimate-only comparison.
+	estimateOnly bool
 }
 
 func (tw *textWriter) printf(format string, args ...any) {
@@ -34,6 +41,13 @@ func (tw *textWriter) printf(format string, args ...any) {
 	_, tw.err = fmt.Fprintf(tw.w, format, args...)
 }
 
+func (tw *textWriter) rowsLabel() string {
+	if tw.estimateOnly {
+		return "est. rows"
+	}
+	return "rows"
+}
+
 // bytesOf renders blocks as a human-readable size using tw.blockSize
 // (falling back to plan.DefaultBlockSize when unset).
 func (tw *textWriter) bytesOf(blocks int64) string {
@@ -49,24 +63,97 @@ func (tw *textWriter) bytesOf(blocks int64) string {
 // sizes; pass <= 0 to use plan.DefaultBlockSize.
 func RenderAnalysisText(w io.Writer, result analyzer.AnalysisResult, blockSize int64) error {
 	tw := &textWriter{w: w, blockSize: blockSize}
+	tw.renderAnalysis(result)
+	return tw.err
+}
+
+// RenderMultiAnalysisText renders each statement's analysis under its own
+// heading, followed by a combined summary. blockSize is as for
+// RenderAnalysisText.
+func RenderMultiAnalysisText(w io.Writer, result analyzer.MultiAnalysisResult, blockSize int64) error {
+	tw := &textWriter{w: w, blockSize: blockSize}
+
+	for _, stmt := range result.Statements {
+		tw.renderStatementHeading(stmt.Index, len(result.Statements), stmt.Query, stmt.Parameters)
+		tw.renderAnalysis(stmt.Result)
+		tw.printf("\n")
+	}
+
+	s := result.Summary
+	tw.printf("%s%sCombined Summary%s\n\n", colorBold, colorCyan, colorReset)
+	tw.printf("  Statements:     %d\n", s.Statements)
+	tw.printf("  Total Cost:     %.2f\n", s.TotalCost)
+	if s.ExecutionTime > 0 {
+		tw.printf("  Execution Time: %.3f ms\n", s.ExecutionTime)
+	}
+	if s.PlanningTime > 0 {
+		tw.printf("  Planning Time:  %.3f ms\n", s.PlanningTime)
+	}
+	tw.renderBufferSummary(s.Buffers, s.SortSpaceUsed)
+	tw.printf("  Findings:       %d critical, %d warning, %d info\n", s.Critical, s.Warnings, s.Infos)
+	if s.SlowestStatement > 0 && s.Statements > 1 {
+		tw.printf("  Slowest:        statement %d\n", s.SlowestStatement)
+	}
 
+	return tw.err
+}
+
+// renderStatementHeading prints "Statement i of n" and the first line of
+// the statement's query text and its parameter values, if known.
+func (tw *textWriter) renderStatementHeading(index, total int, query, params string) {
+	tw.printf("%s%s── Statement %d of %d ──%s\n", colorBold, colorCyan, index, total, colorReset)
+	if query != "" {
+		tw.printf("%s%s%s\n", colorDim, querySnippet(query), colorReset)
+	}
+	if params != "" {
+		tw.printf("%swith %s%s\n", colorDim, querySnippet(params), colorReset)
+	}
+	tw.printf("\n")
+}
+
+// querySnippet collapses query to a single line, truncated to keep headings
+// readable.
+func querySnippet(query string) string {
+	const maxLen = 100
+	snippet := strings.Join(strings.Fields(query), " ")
+	if r := []rune(snippet); len(r) > maxLen {
+		snippet = string(r[:maxLen-1]) + "…"
+	}
+	return snippet
+}
+
+func (tw *textWriter) renderAnalysis(result analyzer.AnalysisResult) {
 	tw.printf("%s%sPlan Summary%s\n\n", colorBold, colorCyan, colorReset)
+	if result.EstimateOnly {
+		tw.printf("  %sEstimate only (no ANALYZE data): execution time, actual rows and buffers are\n", colorYellow)
+		tw.printf("  unavailable, and findings are based on planner estimates.%s\n\n", colorReset)
+	}
 	tw.printf("  Total Cost:     %.2f\n", result.TotalCost)
 	if result.HasActualRows {
 		tw.printf("  Actual Rows:    %s\n", formatCount(result.ActualRows))
 	}
-	if result.ExecutionTime > 0 {
-		tw.printf("  Execution Time: %.3f ms\n", result.ExecutionTime)
-	}
-	if result.PlanningTime > 0 {
-		tw.printf("  Planning Time:  %.3f ms\n", result.PlanningTime)
+	if b := result.Benchmark; b != nil {
+		tw.renderBenchmarkSummary(b)
+	} else {
+		if result.ExecutionTime > 0 {
+			tw.printf("  Execution Time: %.3f ms\n", result.ExecutionTime)
+		}
+		if result.PlanningTime > 0 {
+			tw.printf("  Planning Time:  %.3f ms\n", result.PlanningTime)
+		}
 	}
 	tw.renderBufferSummary(result.Buffers, result.SortSpaceUsed)
+	if len(result.Settings) > 0 {
+		tw.printf("  Settings:       %s\n", formatSettings(result.Settings))
+	}
 	tw.printf("\n")
+	if b := result.Benchmark; b != nil {
+		tw.renderNodeTimings(b)
+	}
 
 	if len(result.Findings) == 0 {
 		tw.printf("%s%sNo issues found.%s\n", colorBold, colorGreen, colorReset)
-		return tw.err
+		return
 	}
 
 	tw.printf("%s%sFindings (%d)%s\n\n", colorBold, colorCyan, len(result.Findings), colorReset)
@@ -83,8 +170,59 @@ func RenderAnalysisText(w io.Writer, result analyzer.AnalysisResult, blockSize i
 			tw.printf("\n")
 		}
 	}
+}
 
-	return tw.err
+// maxNodeTimings caps the nodes listed in a benchmark's node timings.
+const maxNodeTimings = 5
+
+// renderBenchmarkSummary prints the run counts and the distribution of
+// execution and planning times across a benchmark's runs.
+func (tw *textWriter) renderBenchmarkSummary(b *plan.Benchmark) {
+	tw.printf("  Runs:           %d measured", b.Runs)
+	if b.Warmup > 0 {
+		tw.printf(" %s(%d warm-up discarded)%s", colorDim, b.Warmup, colorReset)
+	}
+	tw.printf("\n")
+	if b.Excluded > 0 {
+		tw.printf("  %s%d run(s) used a different plan; node figures come from the other %d.%s\n",
+			colorYellow, b.Excluded, b.Runs-b.Excluded, colorReset)
+	}
+	if b.ExecutionTime.Max > 0 {
+		tw.printf("  Execution Time: %s\n", formatStats(b.ExecutionTime))
+	}
+	if b.PlanningTime.Max > 0 {
+		tw.printf("  Planning Time:  %s\n", formatStats(b.PlanningTime))
+	}
+}
+
+// renderNodeTimings lists the nodes with the highest median time across a
+// benchmark's runs, with their spread and median buffer counts.
+func (tw *textWriter) renderNodeTimings(b *plan.Benchmark) {
+	nodes := slices.Clone(b.Nodes)
+	slices.SortStableFunc(nodes, func(x, y plan.NodeStats) int {
+		return cmp.Compare(y.Time.Median, x.Time.Median)
+	})
+	if len(nodes) == 0 || nodes[0].Time.Max == 0 {
+		return
+	}
+	nodes = nodes[:min(len(nodes), maxNodeTimings)]
+
+	tw.printf("%s%sSlowest Nodes (median of %d runs)%s\n\n", colorBold, colorCyan, b.Runs-b.Excluded, colorReset)
+	for _, n := range nodes {
+		tw.printf("  %s\n", n.Node)
+		tw.printf("    %s", formatStats(n.Time))
+		if n.Reads.Median > 0 || n.Hits.Median > 0 {
+			tw.printf(", %.0f read, %.0f hit", n.Reads.Median, n.Hits.Median)
+		}
+		tw.printf("\n")
+	}
+	tw.printf("\n")
+}
+
+// formatStats renders a time distribution in ms: the median, then the
+// spread around it.
+func formatStats(s plan.Stats) string {
+	return fmt.Sprintf("%.3f ms median %s(p95 %.3f, min %.3f, max %.3f)%s", s.Median, colorDim, s.P95, s.Min, s.Max, colorReset)
 }
 
 func (tw *textWriter) renderBufferSummary(b plan.NodeBuffers, sortSpaceUsed int64) {
@@ -105,6 +243,52 @@ func (tw *textWriter) renderBufferSummary(b plan.NodeBuffers, sortSpaceUsed int6
 	}
 }
 
+// formatSettings renders settings as PostgreSQL's EXPLAIN (SETTINGS) does:
+// name = 'value', in name order.
+func formatSettings(settings map[string]string) string {
+	parts := make([]string, 0, len(settings))
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		parts = append(parts, fmt.Sprintf("%s = '%s'", name, settings[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderSettingsDelta prints the settings both plans share on one line, or
+// each setting that differs on a line of its own.
+func (tw *textWriter) renderSettingsDelta(oldS, newS map[string]string) {
+	if maps.Equal(oldS, newS) {
+		if len(oldS) > 0 {
+			tw.printf("  Settings:       %s\n", formatSettings(oldS))
+		}
+		return
+	}
+
+	names := slices.Sorted(maps.Keys(oldS))
+	for name := range newS {
+		if _, ok := oldS[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	tw.printf("  Settings:\n")
+	for _, name := range names {
+		oldV, oldOK := oldS[name]
+		newV, newOK := newS[name]
+		if oldOK && newOK && oldV == newV {
+			continue
+		}
+		tw.printf("    %s: %s → %s%s%s\n", name, settingValue(oldV, oldOK), colorYellow, settingValue(newV, newOK), colorReset)
+	}
+}
+
+func settingValue(v string, ok bool) string {
+	if !ok {
+		return "(default)"
+	}
+	return "'" + v + "'"
+}
+
 func bufferTotal(b plan.NodeBuffers) int64 {
 	return b.TotalRead() + b.TotalWritten() + b.TotalHit() + b.TotalDirtied()
 }
@@ -157,28 +341,82 @@ func severityFormat(s analyzer.Severity) (string, string) {
 // human-readable sizes; pass <= 0 to use plan.DefaultBlockSize.
 func RenderComparisonText(w io.Writer, result comparator.ComparisonResult, blockSize int64) error {
 	tw := &textWriter{w: w, blockSize: blockSize}
+	tw.renderComparison(result)
+	return tw.err
+}
+
+// RenderStatementComparisonsText renders each paired statement's comparison
+// under its own heading, followed by a one-line verdict per statement.
+// blockSize is as for RenderComparisonText.
+func RenderStatementComparisonsText(w io.Writer, results []comparator.StatementComparison, blockSize int64) error {
+	tw := &textWriter{w: w, blockSize: blockSize}
+
+	for _, stmt := range results {
+		tw.renderStatementHeading(stmt.Index, len(results), stmt.Query, "")
+		tw.renderComparison(stmt.Result)
+		tw.printf("\n")
+	}
+
+	tw.printf("%s%sStatement Verdicts%s\n\n", colorBold, colorCyan, colorReset)
+	for _, stmt := range results {
+		s := stmt.Result.Summary
+		tw.printf("  %3d  %s%s%s\n", stmt.Index, verdictColor(s), s.Verdict, colorReset)
+	}
+
+	return tw.err
+}
+
+func (tw *textWriter) renderComparison(result comparator.ComparisonResult) {
 	s := result.Summary
+	tw.estimateOnly = s.EstimateOnly
 
 	tw.printf("%s%sSummary%s\n\n", colorBold, colorCyan, colorReset)
+	if s.OldLabel != "" && s.NewLabel != "" {
+		tw.printf("  Plans:          %s → %s\n\n", s.OldLabel, s.NewLabel)
+	}
+	if s.EstimateOnly {
+		tw.printf("  %sEstimate only (a plan has no ANALYZE data): comparing planner cost and\n", colorYellow)
+		tw.printf("  estimated rows; execution time and buffers are not compared.%s\n\n", colorReset)
+	}
 	tw.printf("  Cost:           %s\n", formatDelta(s.OldTotalCost, s.NewTotalCost, s.CostPct, s.CostDir, "%.2f"))
 	if s.OldExecutionTime > 0 || s.NewExecutionTime > 0 {
 		tw.printf("  Execution Time: %s (%s)\n",
 			formatDelta(s.OldExecutionTime, s.NewExecutionTime, s.TimePct, s.TimeDir, "%.3f ms"),
 			formatDurationDelta(s.TimeDelta))
+		if sig := s.TimeSignificance; sig != nil {
+			tw.printf("                  %s\n", formatSignificance(*sig))
+		}
+	}
+	if s.OldBenchmark != nil || s.NewBenchmark != nil {
+		tw.printf("  Runs:           %s → %s %s(times and buffers are medians)%s\n",
+			benchmarkRuns(s.OldBenchmark), benchmarkRuns(s.NewBenchmark), colorDim, colorReset)
 	}
 	if s.OldPlanningTime > 0 || s.NewPlanningTime > 0 {
 		tw.printf("  Planning Time:  %s\n", formatDelta(s.OldPlanningTime, s.NewPlanningTime, pctChange(s.OldPlanningTime, s.NewPlanningTime), s.PlanningDir, "%.3f ms"))
 	}
 	tw.renderBufferSummaryDelta(s.OldBuffers, s.NewBuffers, s.OldSortSpaceUsed, s.NewSortSpaceUsed)
+	tw.renderSettingsDelta(s.OldSettings, s.NewSettings)
 	tw.printf("\n")
 
 	if changes := s.NodesAdded + s.NodesRemoved + s.NodesModified + s.NodesTypeChanged; changes == 0 {
 		tw.printf("%s%sPlans are identical.%s\n", colorBold, colorGreen, colorReset)
-		return tw.err
+		return
 	}
 
-	tw.printf("  Changes: %d modified, %d type changed, %d added, %d removed\n\n",
+	tw.printf("  Changes: %d modified, %d type changed, %d added, %d removed",
 		s.NodesModified, s.NodesTypeChanged, s.NodesAdded, s.NodesRemoved)
+	if s.NodesMoved > 0 {
+		tw.printf(", %d moved", s.NodesMoved)
+	}
+	tw.printf("\n\n")
+
+	if len(result.Changes) > 0 {
+		tw.printf("%s%sWhat Changed%s\n\n", colorBold, colorCyan, colorReset)
+		for _, c := range result.Changes {
+			tw.printf("  • %s\n", c.Description)
+		}
+		tw.printf("\n")
+	}
 
 	tw.printf("%s%sNode Details%s\n\n", colorBold, colorCyan, colorReset)
 
@@ -187,8 +425,25 @@ func RenderComparisonText(w io.Writer, result comparator.ComparisonResult, block
 	}
 
 	tw.renderVerdict(s)
+}
 
-	return tw.err
+// formatSignificance renders the outcome of a significance test: whether
+// the difference is significant or within noise, the p-value and the
+// effect size.
+func formatSignificance(sig comparator.Significance) string {
+	color := colorDim
+	if sig.Significant {
+		color = colorBold
+	}
+	return fmt.Sprintf("%s%s%s %s(p = %.3f, effect size %+.2f, %s; %d vs %d runs)%s",
+		color, sig.Verdict, colorReset, colorDim, sig.PValue, sig.EffectSize, sig.Magnitude, sig.OldSamples, sig.NewSamples, colorReset)
+}
+
+func benchmarkRuns(b *plan.Benchmark) string {
+	if b == nil {
+		return "1"
+	}
+	return fmt.Sprint(b.Runs)
 }
 
 func (tw *textWriter) renderDelta(d comparator.NodeDelta, depth int) {
@@ -237,13 +492,16 @@ func (tw *textWriter) renderTypeChangedNode(indent string, d comparator.NodeDelt
 	if d.Relation != "" {
 		tw.printf(" on %s", d.Relation)
 	}
-	tw.printf("\n")
+	tw.printf("%s\n", formatMove(d))
 	tw.renderMetricLine(indent, "cost", d.OldCost, d.NewCost, d.CostPct, d.CostDir, "%.2f")
 	if d.OldTime > 0 || d.NewTime > 0 {
 		tw.renderMetricLine(indent, "time", d.OldTime, d.NewTime, d.TimePct, d.TimeDir, "%.3f ms")
+		if sig := d.TimeSignificance; sig != nil {
+			tw.printf("%s    %s\n", indent, formatSignificance(*sig))
+		}
 	}
 	if d.OldRows != d.NewRows {
-		tw.renderMetricLineCount(indent, "rows", d.OldRows, d.NewRows, d.RowsPct)
+		tw.renderMetricLineCount(indent, tw.rowsLabel(), d.OldRows, d.NewRows, d.RowsPct)
 	}
 	tw.renderFilterChange(indent, d)
 	tw.renderIndexCondChange(indent, d)
@@ -252,14 +510,33 @@ func (tw *textWriter) renderTypeChangedNode(indent string, d comparator.NodeDelt
 	tw.renderSpillChanges(indent, d)
 }
 
+// formatMove notes where a node moved from and whether its inputs swapped,
+// or returns "" when neither happened.
+func formatMove(d comparator.NodeDelta) string {
+	var notes []string
+	if d.Moved {
+		notes = append(notes, "moved from under "+d.MovedFrom)
+	}
+	if d.Swapped {
+		notes = append(notes, "inputs swapped")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %s(%s)%s", colorDim, strings.Join(notes, ", "), colorReset)
+}
+
 func (tw *textWriter) renderModifiedNode(indent string, d comparator.NodeDelta) {
-	tw.printf("%s%s~ %s%s\n", indent, colorYellow, nodeLabel(d), colorReset)
+	tw.printf("%s%s~ %s%s%s\n", indent, colorYellow, nodeLabel(d), colorReset, formatMove(d))
 	tw.renderMetricLine(indent, "cost", d.OldCost, d.NewCost, d.CostPct, d.CostDir, "%.2f")
 	if d.OldTime > 0 || d.NewTime > 0 {
 		tw.renderMetricLine(indent, "time", d.OldTime, d.NewTime, d.TimePct, d.TimeDir, "%.3f ms")
+		if sig := d.TimeSignificance; sig != nil {
+			tw.printf("%s    %s\n", indent, formatSignificance(*sig))
+		}
 	}
 	if d.OldRows != d.NewRows {
-		tw.renderMetricLineCount(indent, "rows", d.OldRows, d.NewRows, d.RowsPct)
+		tw.renderMetricLineCount(indent, tw.rowsLabel(), d.OldRows, d.NewRows, d.RowsPct)
 	}
 	if d.OldLoops != d.NewLoops && (d.OldLoops > 1 || d.NewLoops > 1) {
 		tw.renderMetricLineInt(indent, "loops", d.OldLoops, d.NewLoops,
@@ -489,20 +766,23 @@ func (tw *textWriter) renderIndexNameChange(indent string, d comparator.NodeDelt
 }
 
 func (tw *textWriter) renderVerdict(s comparator.Summary) {
-	var color string
+	if color := verdictColor(s); color != "" {
+		tw.printf("\n%sVerdict: %s%s\n", color, s.Verdict, colorReset)
+	} else {
+		tw.printf("\nVerdict: %s\n", s.Verdict)
+	}
+}
+
+func verdictColor(s comparator.Summary) string {
 	switch {
 	case s.TimeDir == comparator.Improved && s.CostDir == comparator.Improved:
-		color = colorGreen
+		return colorGreen
 	case s.TimeDir == comparator.Regressed && s.CostDir == comparator.Regressed:
-		color = colorRed
+		return colorRed
 	case s.TimeDir == comparator.Improved || s.CostDir == comparator.Improved:
-		color = colorYellow
-	}
-	if color != "" {
-		tw.printf("\n%sVerdict: %s%s\n", color, s.Verdict, colorReset)
-	} else {
-		tw.printf("\nVerdict: %s\n", s.Verdict)
+		return colorYellow
 	}
+	return ""
 }
 
 func nodeLabel(d comparator.NodeDelta) string {
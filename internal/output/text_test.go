@@ -5,9 +5,18 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jacobarthurs/pgplan/internal/advisor"
 	"github.com/jacobarthurs/pgplan/internal/analyzer"
+	"github.com/jacobarthurs/pgplan/internal/autoexplain"
+	"github.com/jacobarthurs/pgplan/internal/baseline"
+	"github.com/jacobarthurs/pgplan/internal/batch"
 	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/gate"
+	"github.com/jacobarthurs/pgplan/internal/gitrev"
+	"github.com/jacobarthurs/pgplan/internal/hypoindex"
+	"github.com/jacobarthurs/pgplan/internal/impact"
 	"github.com/jacobarthurs/pgplan/internal/plan"
+	"github.com/jacobarthurs/pgplan/internal/whatif"
 )
 
 func TestFormatIntDelta_LowerIsBetter_Decrease(t *testing.T) {
@@ -202,3 +211,658 @@ func TestRenderAnalysisText_ActualRows_HiddenWithoutAnalyze(t *testing.T) {
 		t.Errorf("finding should not mention actual rows without ANALYZE data\nfull output:\n%s", out)
 	}
 }
+
+func TestRenderMultiAnalysisText(t *testing.T) {
+	result := analyzer.MultiAnalysisResult{
+		Statements: []analyzer.StatementResult{
+			{Index: 1, Query: "SELECT *\n  FROM users", Result: analyzer.AnalysisResult{TotalCost: 20.0}},
+			{Index: 2, Query: "SELECT * FROM orders WHERE id = $1", Parameters: "$1 = '42'", Result: analyzer.AnalysisResult{
+				TotalCost: 80.0,
+				Findings: []analyzer.Finding{
+					{Severity: analyzer.Critical, Description: "some finding", Suggestion: "some suggestion"},
+				},
+			}},
+		},
+		Summary: analyzer.CombinedSummary{
+			Statements:       2,
+			TotalCost:        100.0,
+			Critical:         1,
+			SlowestStatement: 2,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderMultiAnalysisText(&buf, result, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"Statement 1 of 2", "SELECT * FROM users",
+		"Statement 2 of 2", "with $1 = '42'", "some finding",
+		"Combined Summary", "Total Cost:     100.00",
+		"1 critical, 0 warning, 0 info", "Slowest:        statement 2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderStatementComparisonsText_VerdictList(t *testing.T) {
+	results := []comparator.StatementComparison{
+		{Index: 1, Query: "SELECT 1", Result: comparator.ComparisonResult{
+			Summary: comparator.Summary{Verdict: "faster", TimeDir: comparator.Improved},
+		}},
+		{Index: 2, Query: "SELECT 2", Result: comparator.ComparisonResult{
+			Summary: comparator.Summary{Verdict: "no significant change"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderStatementComparisonsText(&buf, results, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"Statement 1 of 2", "Statement Verdicts", "faster", "no significant change"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestQuerySnippet_Truncates(t *testing.T) {
+	got := querySnippet(strings.Repeat("a ", 80))
+	if r := []rune(got); len(r) != 100 || !strings.HasSuffix(got, "…") {
+		t.Errorf("querySnippet = %q (%d runes), want 100 runes ending in …", got, len(r))
+	}
+}
+
+func TestRenderLogReportText_TopLimit(t *testing.T) {
+	report := autoexplain.Report{
+		Format:        autoexplain.FormatStderr,
+		Entries:       3,
+		Queries:       2,
+		TotalDuration: 60,
+		Groups: []autoexplain.QueryGroup{
+			{Fingerprint: "select * from t where id = ?", Count: 2, TotalDuration: 50, MaxDuration: 40, SlowestLine: 7,
+				Analysis: analyzer.AnalysisResult{Findings: []analyzer.Finding{
+					{Severity: analyzer.Critical, Description: "some finding", Suggestion: "some suggestion"},
+				}}},
+			{Fingerprint: "select ?", Count: 1, TotalDuration: 10, MaxDuration: 10},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderLogReportText(&buf, report, 1, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"Queries:        2 distinct", "Worst Offenders (1 of 2", "select * from t where id = ?", "calls 2", "Slowest run (line 7)", "some finding"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "calls 1") {
+		t.Errorf("output should be limited to the top group\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderAnalysisText_EstimateOnlyBanner(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderAnalysisText(&buf, analyzer.AnalysisResult{TotalCost: 10, EstimateOnly: true}, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Estimate only") {
+		t.Errorf("output missing estimate-only banner\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderComparisonText_EstimateOnlyRows(t *testing.T) {
+	result := comparator.ComparisonResult{
+		Summary: comparator.Summary{EstimateOnly: true, NodesModified: 1, Verdict: "cheaper (estimated cost only)"},
+		Deltas: []comparator.NodeDelta{{
+			NodeType: "Seq Scan", ChangeType: comparator.Modified,
+			OldCost: 100, NewCost: 50, OldRows: 500, NewRows: 5,
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderComparisonText(&buf, result, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Estimate only", "est. rows: 500 → 5"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderAnalysisText_Settings(t *testing.T) {
+	result := analyzer.AnalysisResult{TotalCost: 10, Settings: map[string]string{"work_mem": "64MB", "jit": "off"}}
+
+	var buf bytes.Buffer
+	if err := RenderAnalysisText(&buf, result, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Settings:       jit = 'off', work_mem = '64MB'") {
+		t.Errorf("output missing sorted settings\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderComparisonText_SettingsDelta(t *testing.T) {
+	result := comparator.ComparisonResult{
+		Deltas: []comparator.NodeDelta{{ChangeType: comparator.NoChange}},
+		Summary: comparator.Summary{
+			OldSettings: map[string]string{"work_mem": "4MB", "jit": "off"},
+			NewSettings: map[string]string{"work_mem": "64MB", "jit": "off", "enable_seqscan": "off"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderComparisonText(&buf, result, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"enable_seqscan: (default) → ", "work_mem: '4MB' → "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "jit") {
+		t.Errorf("unchanged setting should not be listed\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderAnalysisText_Benchmark(t *testing.T) {
+	result := analyzer.AnalysisResult{
+		TotalCost: 10,
+		Benchmark: &plan.Benchmark{
+			Runs:          5,
+			Warmup:        2,
+			ExecutionTime: plan.Stats{Min: 9, Median: 10, P95: 14, Max: 14},
+			Nodes: []plan.NodeStats{
+				{Node: "Aggregate", Time: plan.Stats{Median: 10, Max: 14}},
+				{Node: "Seq Scan on orders", Time: plan.Stats{Median: 8, Max: 12}, Reads: plan.Stats{Median: 120}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderAnalysisText(&buf, result, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Runs:           5 measured",
+		"2 warm-up discarded",
+		"Execution Time: 10.000 ms median",
+		"p95 14.000, min 9.000, max 14.000",
+		"Slowest Nodes (median of 5 runs)",
+		"120 read",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "Aggregate") > strings.Index(out, "Seq Scan on orders") {
+		t.Errorf("nodes should be listed slowest first\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderComparisonText_Significance(t *testing.T) {
+	result := comparator.ComparisonResult{
+		Deltas: []comparator.NodeDelta{{ChangeType: comparator.NoChange}},
+		Summary: comparator.Summary{
+			OldExecutionTime: 10,
+			NewExecutionTime: 11,
+			TimeSignificance: &comparator.Significance{
+				OldSamples: 10, NewSamples: 10,
+				PValue: 0.42, Verdict: "within noise",
+				EffectSize: 0.12, Magnitude: "negligible",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderComparisonText(&buf, result, plan.DefaultBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"within noise", "p = 0.420", "effect size +0.12, negligible", "10 vs 10 runs"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderGateText(t *testing.T) {
+	var buf bytes.Buffer
+	result := gate.Result{Violations: []gate.Violation{{Check: "max-time", Statement: 2, Message: "execution time 120.000 ms exceeds budget 100.000 ms"}}}
+	if err := RenderGateText(&buf, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Gate: failed (1 violation(s))", "statement 2: execution time 120.000 ms", "(--max-time)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := RenderGateText(&buf, gate.Result{Passed: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Gate: passed") {
+		t.Errorf("output missing pass line\nfull output:\n%s", buf.String())
+	}
+}
+
+func TestRenderBaselineReportText(t *testing.T) {
+	var buf bytes.Buffer
+	report := baseline.Report{
+		Baseline: ".pgplan-baseline",
+		Failed:   2,
+		Statements: []baseline.StatementCheck{
+			{File: "users.sql", Statement: 1, Status: baseline.StatusPass, Comparison: &comparator.ComparisonResult{Summary: comparator.Summary{Verdict: "no significant change"}}},
+			{File: "orders.sql", Statement: 1, Query: "SELECT * FROM orders", Status: baseline.StatusFail, ShapeChanged: true,
+				Comparison: &comparator.ComparisonResult{}, Reasons: []string{"plan shape changed: different relations or indexes"}},
+			{File: "orders.sql", Statement: 2, Status: baseline.StatusNew, Reasons: []string{"no baseline plan"}},
+		},
+	}
+	if err := RenderBaselineReportText(&buf, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"users.sql #1",
+		"SELECT * FROM orders",
+		"plan shape changed: different relations or indexes",
+		"(new)",
+		"Baseline check failed: 2 of 3 statements.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderBatchFileText(t *testing.T) {
+	var buf bytes.Buffer
+	r := batch.FileResult{File: "reports/daily.sql", Result: &analyzer.MultiAnalysisResult{
+		Statements: []analyzer.StatementResult{{Index: 1, Result: analyzer.AnalysisResult{Findings: []analyzer.Finding{
+			{Severity: analyzer.Critical, Description: "Sort spilled to disk (4000kB) on Sort"},
+		}}}},
+		Summary: analyzer.CombinedSummary{Statements: 1, Critical: 1, ExecutionTime: 55.2},
+	}}
+	if err := RenderBatchFileText(&buf, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"reports/daily.sql", "1 statement(s), 1 critical, 0 warning, 0 info, 55.200 ms", "Sort spilled to disk"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := RenderBatchFileText(&buf, batch.FileResult{File: "broken.sql", Error: "connecting to database: refused"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "broken.sql  "+colorRed+"connecting to database: refused") {
+		t.Errorf("output missing the file's error\nfull output:\n%s", buf.String())
+	}
+}
+
+func TestRenderBatchReportText(t *testing.T) {
+	var buf bytes.Buffer
+	report := batch.Report{
+		Files: []batch.FileResult{{File: "broken.sql", Error: "connecting to database: refused"}},
+		Summary: batch.Summary{
+			Files: 3, Failed: 1, Statements: 4, ExecutionTime: 120,
+			Rules: []batch.RuleCount{
+				{Rule: "Seq Scan with Filter", Findings: 5, Files: 2, Severity: analyzer.Warning},
+				{Rule: "Sort Spill to Disk", Findings: 1, Files: 1, Severity: analyzer.Critical},
+			},
+			Relations: []batch.RelationCount{{Relation: "orders", Findings: 5, Critical: 1, Files: 2}},
+			Slowest:   []batch.StatementTiming{{File: "a.sql", Statement: 2, Query: "SELECT * FROM orders", ExecutionTime: 90}},
+		},
+	}
+	if err := RenderBatchReportText(&buf, report, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"(1 failed)",
+		"Seq Scan with Filter",
+		"orders " + colorDim + "(1 critical, 2 file(s))",
+		"90.000 ms  a.sql #2",
+		"broken.sql: connecting to database: refused",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Sort Spill to Disk") {
+		t.Errorf("output lists more rules than top\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderComparisonText_MovedAndSwapped(t *testing.T) {
+	var buf bytes.Buffer
+	result := comparator.ComparisonResult{
+		Deltas: []comparator.NodeDelta{{
+			NodeType: "Hash Join", ChangeType: comparator.Modified, Swapped: true,
+			Children: []comparator.NodeDelta{
+				{NodeType: "Seq Scan", Relation: "a", ChangeType: comparator.Modified, Moved: true, MovedFrom: "Nested Loop"},
+			},
+		}},
+		Summary: comparator.Summary{NodesModified: 2, NodesMoved: 2},
+	}
+	if err := RenderComparisonText(&buf, result, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Hash Join" + colorReset + " " + colorDim + "(inputs swapped)", "(moved from under Nested Loop)", ", 2 moved"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderComparisonText_WhatChanged(t *testing.T) {
+	var buf bytes.Buffer
+	result := comparator.ComparisonResult{
+		Deltas: []comparator.NodeDelta{{
+			NodeType: "Index Scan", OldNodeType: "Seq Scan", NewNodeType: "Index Scan", Relation: "orders", ChangeType: comparator.TypeChanged,
+		}},
+		Changes: []comparator.ChangeEvent{{
+			Kind: comparator.ScanMethodChanged, Relation: "orders", Old: "Seq Scan", New: "Index Scan using orders_pkey",
+			Description: "Scan on orders: Seq Scan → Index Scan using orders_pkey",
+		}},
+		Summary: comparator.Summary{NodesTypeChanged: 1},
+	}
+	if err := RenderComparisonText(&buf, result, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	changed := strings.Index(out, "What Changed")
+	details := strings.Index(out, "Node Details")
+	if changed < 0 || details < 0 || changed > details {
+		t.Fatalf("want What Changed above Node Details\nfull output:\n%s", out)
+	}
+	if !strings.Contains(out, "  • Scan on orders: Seq Scan → Index Scan using orders_pkey\n") {
+		t.Errorf("output missing the change event\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderTrendText(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	second := 1
+	result := comparator.TrendResult{
+		Labels: []string{"v1.json", "v2.json", "v3.json"},
+		Summary: []comparator.Series{
+			{Metric: "Cost", Values: []*float64{f(10), f(500), f(500)}, FirstRegression: &second},
+			{Metric: "Execution Time", Unit: "ms", Values: []*float64{nil, nil, nil}},
+		},
+		Steps: []comparator.TrendStep{
+			{From: 0, To: 1, Verdict: "more expensive", Regressed: true, Changes: []comparator.ChangeEvent{{Description: "Scan on orders: Index Scan → Seq Scan"}}},
+			{From: 1, To: 2, Verdict: "no significant change"},
+		},
+		FirstRegression: &second,
+		Nodes: []comparator.NodeTrend{
+			{NodeType: "Limit", NodeTypes: []string{"Limit", "Limit", "Limit"}, Series: []comparator.Series{{Metric: "Cost", Values: []*float64{f(11), f(501), f(501)}}}},
+			{NodeType: "Sort", NodeTypes: []string{"", "Sort", ""}, Series: []comparator.Series{{Metric: "Cost", Values: []*float64{nil, f(2), nil}}}},
+			{NodeType: "Seq Scan", Relation: "orders", NodeTypes: []string{"Index Scan", "Seq Scan", "Seq Scan"}, Series: []comparator.Series{{Metric: "Cost", Values: []*float64{f(10), f(500), f(500)}, FirstRegression: &second}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderTrendText(&buf, result, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"v2.json  " + colorRed + "← first regression",
+		"Cost            ▁██  ",
+		"+4900.0%",
+		colorRed + "plan 2" + colorReset,
+		"• Scan on orders: Index Scan → Seq Scan",
+		"Nodes by Peak Cost",
+		"Seq Scan on orders  " + colorRed + "(regressed at plan 2)",
+		"Index Scan → Seq Scan" + colorReset,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Execution Time") {
+		t.Errorf("output shows a metric without values\nfull output:\n%s", out)
+	}
+	if strings.Contains(out, "  Sort") {
+		t.Errorf("output lists more nodes than top\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderComparisonText_SideLabels(t *testing.T) {
+	var buf bytes.Buffer
+	result := comparator.ComparisonResult{
+		Summary: comparator.Summary{OldLabel: "staging", NewLabel: "prod", OldTotalCost: 10, NewTotalCost: 10},
+	}
+	if err := RenderComparisonText(&buf, result, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "  Plans:          staging → prod\n") {
+		t.Errorf("output missing the side labels\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderWhatIfText(t *testing.T) {
+	report := whatif.Report{
+		File: "slow.sql",
+		Variants: []whatif.VariantResult{
+			{
+				Name:         "no-nestloop",
+				Settings:     []plan.Setting{{Name: "enable_nestloop", Value: "off"}},
+				ShapeChanged: true,
+				Statements: []whatif.StatementResult{{
+					Statement:    1,
+					ShapeChanged: true,
+					Comparison: comparator.ComparisonResult{
+						Changes: []comparator.ChangeEvent{{Description: "Join of a, b: Nested Loop → Hash Join"}},
+						Summary: comparator.Summary{
+							OldExecutionTime: 100, NewExecutionTime: 20, TimeDelta: -80, TimePct: -80, TimeDir: comparator.Improved,
+							OldTotalCost: 50, NewTotalCost: 80, CostPct: 60, CostDir: comparator.Regressed,
+						},
+					},
+				}},
+			},
+			{
+				Name:     "jit=off",
+				Settings: []plan.Setting{{Name: "jit", Value: "off"}},
+				Statements: []whatif.StatementResult{{
+					Statement:  1,
+					Comparison: comparator.ComparisonResult{Summary: comparator.Summary{OldExecutionTime: 100, NewExecutionTime: 99, OldTotalCost: 50, NewTotalCost: 50}},
+				}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderWhatIfText(&buf, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"no-nestloop" + colorReset + " " + colorDim + "(enable_nestloop=off)",
+		"plan changed",
+		"• Join of a, b: Nested Loop → Hash Join",
+		"same plan",
+		"1 of 2 variant(s) changed the plan",
+		"  no-nestloop " + colorGreen + "(-00:00:00.080, -80.0%)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "jit=off"+colorReset+" "+colorDim) {
+		t.Errorf("unnamed variant repeats its settings\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderImpactText(t *testing.T) {
+	report := impact.Report{
+		Migration: "drop_index.sql",
+		Queries: []impact.QueryImpact{
+			{
+				File: "orders.sql", Statement: 1, Query: "SELECT * FROM orders WHERE customer_id = 1",
+				Impact: impact.Regressed, ShapeChanged: true, Regression: 900,
+				Violations: []string{"execution time regressed +900.0% (1.000 ms → 10.000 ms), limit 10.0%"},
+				Comparison: comparator.ComparisonResult{
+					Changes: []comparator.ChangeEvent{{Description: "orders: Index Scan → Seq Scan"}},
+					Summary: comparator.Summary{
+						OldExecutionTime: 1, NewExecutionTime: 10, TimeDelta: 9, TimePct: 900, TimeDir: comparator.Regressed,
+						OldTotalCost: 10, NewTotalCost: 100, CostPct: 900, CostDir: comparator.Regressed,
+					},
+				},
+			},
+			{File: "customers.sql", Statement: 1, Impact: impact.Unchanged},
+		},
+		Regressed: 1,
+		Unchanged: 1,
+	}
+
+	var buf bytes.Buffer
+	if err := RenderImpactText(&buf, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Migration Impact: drop_index.sql",
+		"regressed" + colorReset + " orders.sql #1",
+		"(plan changed)",
+		"• orders: Index Scan → Seq Scan",
+		"1 regressed",
+		"1 unchanged",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "customers.sql") {
+		t.Errorf("unchanged queries should only be counted\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderSourceDiffText(t *testing.T) {
+	v := gitrev.Versions{
+		Path:     "queries/orders.sql",
+		OldLabel: "main",
+		NewLabel: "HEAD",
+		Diff: "diff --git a/queries/orders.sql b/queries/orders.sql\n" +
+			"index e0ac49d..e7f8100 100644\n" +
+			"--- a/queries/orders.sql\n" +
+			"+++ b/queries/orders.sql\n" +
+			"@@ -1 +1 @@\n" +
+			"-SELECT * FROM orders;\n" +
+			"+SELECT * FROM orders ORDER BY id;\n",
+	}
+
+	var buf bytes.Buffer
+	if err := RenderSourceDiffText(&buf, v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Source Changes: queries/orders.sql",
+		"(main → HEAD)",
+		colorRed + "-SELECT * FROM orders;",
+		colorGreen + "+SELECT * FROM orders ORDER BY id;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "index e0ac49d") || strings.Contains(out, "+++") {
+		t.Errorf("output repeats git's header lines\nfull output:\n%s", out)
+	}
+}
+
+func TestRenderHypotheticalIndexesText(t *testing.T) {
+	report := hypoindex.Report{
+		File:   "slow.sql",
+		Picked: 1,
+		Indexes: []hypoindex.IndexResult{
+			{
+				Definition:  "CREATE INDEX ON orders (customer_id)",
+				SuggestedBy: []hypoindex.Source{{Statement: 1, Rule: "Seq Scan with Filter"}},
+				IndexName:   "<13495>btree_orders_customer_id",
+				Picked:      true,
+				Statements: []hypoindex.StatementResult{{
+					Statement: 1,
+					Picked:    true,
+					Comparison: comparator.ComparisonResult{
+						Changes: []comparator.ChangeEvent{{Description: "orders: Seq Scan → Index Scan using <13495>btree_orders_customer_id"}},
+						Summary: comparator.Summary{OldTotalCost: 1000, NewTotalCost: 12, CostPct: -98.8, CostDir: comparator.Improved, EstimateOnly: true},
+					},
+				}},
+			},
+			{
+				Definition:  "CREATE INDEX ON orders (custmer_id)",
+				SuggestedBy: []hypoindex.Source{{Statement: 1, Rule: "Seq Scan in Join"}},
+				Error:       `column "custmer_id" does not exist`,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHypotheticalIndexesText(&buf, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"✓" + colorReset + " CREATE INDEX ON orders (customer_id)",
+		"suggested by Seq Scan with Filter",
+		"picked by the planner",
+		"• orders: Seq Scan → Index Scan",
+		`could not be created: column "custmer_id" does not exist`,
+		"1 of 2 suggested index(es) picked by the planner.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderIndexAdviceText(t *testing.T) {
+	advice := advisor.Advice{Candidates: []advisor.Candidate{{
+		Definition: "CREATE INDEX CONCURRENTLY ON orders (customer_id, status)",
+		Time:       450,
+		Sources: []advisor.Source{
+			{File: "a.sql", Statement: 1, Rule: "Seq Scan with Filter", Relation: "orders", Time: 150},
+			{File: "b.sql", Statement: 2, Rule: "Index Scan Filter Inefficiency", Relation: "orders", Time: 300},
+		},
+		Merged: []string{"CREATE INDEX CONCURRENTLY ON orders (customer_id)"},
+	}}}
+
+	var buf bytes.Buffer
+	if err := RenderIndexAdviceText(&buf, advice, "indexes.sql"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Index Advisor",
+		"1. " + colorBold + "CREATE INDEX CONCURRENTLY ON orders (customer_id, status)",
+		"(2 nodes, 450.0 ms)",
+		"b.sql #2: Index Scan Filter Inefficiency on orders",
+		"replaces CREATE INDEX CONCURRENTLY ON orders (customer_id)",
+		"Script written to indexes.sql",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package output
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+)
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// RenderTrendText renders a trend: the plans, a sparkline per summary
+// metric with its first and last value, each step's verdict and plan-shape
+// changes, and the top nodes by peak time (by peak cost without ANALYZE
+// data; all of them when top <= 0).
+func RenderTrendText(w io.Writer, t comparator.TrendResult, top int) error {
+	tw := &textWriter{w: w}
+
+	tw.printf("%s%sPlans%s\n\n", colorBold, colorCyan, colorReset)
+	for i, label := range t.Labels {
+		tw.printf("  %3d  %s", i+1, label)
+		if t.FirstRegression != nil && *t.FirstRegression == i {
+			tw.printf("  %s← first regression%s", colorRed, colorReset)
+		}
+		tw.printf("\n")
+	}
+
+	tw.printf("\n%s%sSummary%s\n\n", colorBold, colorCyan, colorReset)
+	width := max(len(t.Labels), len("Trend"))
+	tw.printf("  %s%-15s %s %12s   %12s   %9s   %s%s\n", colorDim, "Metric", pad("Trend", width), "First", "Last", "Change", "Regressed At", colorReset)
+	for _, s := range t.Summary {
+		first, last, ok := endpoints(s.Values)
+		if !ok {
+			continue
+		}
+		tw.printf("  %-15s %s %12s   %12s   %+8.1f%%   %s\n",
+			s.Metric, pad(sparkline(s.Values), width), formatTrendValue(first, s.Unit), formatTrendValue(last, s.Unit),
+			pctChange(first, last), regressedAt(s.FirstRegression))
+	}
+
+	tw.printf("\n%s%sSteps%s\n\n", colorBold, colorCyan, colorReset)
+	for _, step := range t.Steps {
+		color := ""
+		if step.Regressed {
+			color = colorRed
+		}
+		tw.printf("  %3d → %-3d %s%s%s\n", step.From+1, step.To+1, color, step.Verdict, colorReset)
+		for _, c := range step.Changes {
+			tw.printf("            • %s\n", c.Description)
+		}
+	}
+
+	metric := "Time"
+	if !hasValues(t.Nodes, metric) {
+		metric = "Cost"
+	}
+	nodes := slices.Clone(t.Nodes)
+	slices.SortStableFunc(nodes, func(a, b comparator.NodeTrend) int {
+		return cmp.Compare(peak(nodeSeries(b, metric).Values), peak(nodeSeries(a, metric).Values))
+	})
+	nodes = truncate(nodes, top)
+
+	tw.printf("\n%s%sNodes by Peak %s%s\n\n", colorBold, colorCyan, metric, colorReset)
+	for _, n := range nodes {
+		s := nodeSeries(n, metric)
+		first, last, ok := endpoints(s.Values)
+		if !ok {
+			continue
+		}
+		label := n.NodeType
+		if n.Relation != "" {
+			label += " on " + n.Relation
+		}
+		tw.printf("  %s %12s → %-12s %s", pad(sparkline(s.Values), width), formatTrendValue(first, s.Unit), formatTrendValue(last, s.Unit), label)
+		if s.FirstRegression != nil {
+			tw.printf("  %s(regressed at plan %d)%s", colorRed, *s.FirstRegression+1, colorReset)
+		}
+		tw.printf("\n")
+		if types := distinctTypes(n.NodeTypes); len(types) > 1 {
+			tw.printf("  %s %s%s%s\n", pad("", width), colorDim, strings.Join(types, " → "), colorReset)
+		}
+	}
+	return tw.err
+}
+
+// sparkline draws values as bars scaled between their minimum and maximum,
+// with "·" where a plan has no value.
+func sparkline(values []*float64) string {
+	lo, hi := 0.0, 0.0
+	seen := false
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if !seen || *v < lo {
+			lo = *v
+		}
+		if !seen || *v > hi {
+			hi = *v
+		}
+		seen = true
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case v == nil:
+			b.WriteRune('·')
+		case hi == lo:
+			b.WriteRune(sparkBars[0])
+		default:
+			b.WriteRune(sparkBars[int((*v-lo)/(hi-lo)*float64(len(sparkBars)-1)+0.5)])
+		}
+	}
+	return b.String()
+}
+
+// endpoints returns the first and last values present, and false when there
+// are none.
+func endpoints(values []*float64) (first, last float64, ok bool) {
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if !ok {
+			first, ok = *v, true
+		}
+		last = *v
+	}
+	return first, last, ok
+}
+
+func peak(values []*float64) float64 {
+	p := 0.0
+	for _, v := range values {
+		if v != nil && *v > p {
+			p = *v
+		}
+	}
+	return p
+}
+
+func hasValues(nodes []comparator.NodeTrend, metric string) bool {
+	for _, n := range nodes {
+		if _, _, ok := endpoints(nodeSeries(n, metric).Values); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeSeries(n comparator.NodeTrend, metric string) comparator.Series {
+	for _, s := range n.Series {
+		if s.Metric == metric {
+			return s
+		}
+	}
+	return comparator.Series{Metric: metric}
+}
+
+// distinctTypes returns the node types a node had, in order, without
+// repeats or absences.
+func distinctTypes(types []string) []string {
+	var distinct []string
+	for _, t := range types {
+		if t != "" && (len(distinct) == 0 || distinct[len(distinct)-1] != t) {
+			distinct = append(distinct, t)
+		}
+	}
+	return distinct
+}
+
+func formatTrendValue(v float64, unit string) string {
+	switch unit {
+	case "ms":
+		return fmt.Sprintf("%.3f ms", v)
+	case "blocks":
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+func regressedAt(i *int) string {
+	if i == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%splan %d%s", colorRed, *i+1, colorReset)
+}
+
+// pad right-pads s to width runes.
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
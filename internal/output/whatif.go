@@ -0,0 +1,83 @@
+package output
+
+import (
+	"io"
+
+	"github.com/jacobarthurs/pgplan/internal/whatif"
+)
+
+// RenderWhatIfText renders a what-if report: for each variant, whether it
+// changed each statement's plan, the time and cost against the baseline,
+// and the plan-shape changes it caused.
+func RenderWhatIfText(w io.Writer, report whatif.Report) error {
+	tw := &textWriter{w: w}
+
+	tw.printf("%s%sWhat-If: %s%s\n\n", colorBold, colorCyan, report.File, colorReset)
+
+	changed := 0
+	for _, v := range report.Variants {
+		if v.ShapeChanged {
+			changed++
+		}
+
+		tw.printf("  %s%s%s", colorBold, v.Name, colorReset)
+		if settings := settingsText(v); settings != v.Name {
+			tw.printf(" %s(%s)%s", colorDim, settings, colorReset)
+		}
+		tw.printf("\n")
+
+		for _, stmt := range v.Statements {
+			s := stmt.Comparison.Summary
+			indent := "    "
+			if len(v.Statements) > 1 {
+				tw.printf("    %s#%d%s\n", colorDim, stmt.Statement, colorReset)
+				indent = "      "
+			}
+			if stmt.ShapeChanged {
+				tw.printf("%s%splan changed%s\n", indent, colorYellow, colorReset)
+			} else {
+				tw.printf("%s%ssame plan%s\n", indent, colorDim, colorReset)
+			}
+			if !s.EstimateOnly {
+				tw.printf("%sExecution Time: %s (%s)\n", indent,
+					formatDelta(s.OldExecutionTime, s.NewExecutionTime, s.TimePct, s.TimeDir, "%.3f ms"),
+					formatDurationDelta(s.TimeDelta))
+			}
+			tw.printf("%sCost:           %s\n", indent, formatDelta(s.OldTotalCost, s.NewTotalCost, s.CostPct, s.CostDir, "%.2f"))
+			for _, c := range stmt.Comparison.Changes {
+				tw.printf("%s• %s\n", indent, c.Description)
+			}
+		}
+		tw.printf("\n")
+	}
+
+	if changed == 0 {
+		tw.printf("%sNo variant changed the plan.%s\n", colorBold, colorReset)
+		return tw.err
+	}
+	tw.printf("%s%d of %d variant(s) changed the plan:%s\n", colorBold, changed, len(report.Variants), colorReset)
+	for _, v := range report.Variants {
+		if !v.ShapeChanged {
+			continue
+		}
+		tw.printf("  %s", v.Name)
+		if len(v.Statements) == 1 {
+			if s := v.Statements[0].Comparison.Summary; !s.EstimateOnly {
+				tw.printf(" %s(%s, %+.1f%%)%s", dirColor(s.TimeDir), formatDurationDelta(s.TimeDelta), s.TimePct, colorReset)
+			}
+		}
+		tw.printf("\n")
+	}
+	return tw.err
+}
+
+func settingsText(v whatif.VariantResult) string {
+	text := ""
+	for i, s := range v.Settings {
+		if i > 0 {
+			text += ","
+		}
+		text += s.Name + "=" + s.Value
+	}
+	return text
+}
@@ -0,0 +1,248 @@
+package plan
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Stats summarizes a set of samples.
+type Stats struct {
+	Min    float64
+	Median float64
+	P95    float64
+	Max    float64
+}
+
+// Summarize returns the statistics of samples. P95 is the nearest-rank
+// percentile, so with fewer than 20 samples it is the maximum.
+func Summarize(samples []float64) Stats {
+	if len(samples) == 0 {
+		return Stats{}
+	}
+	sorted := slices.Sorted(slices.Values(samples))
+	n := len(sorted)
+
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return Stats{
+		Min:    sorted[0],
+		Median: median,
+		P95:    sorted[int(math.Ceil(0.95*float64(n)))-1],
+		Max:    sorted[n-1],
+	}
+}
+
+// NodeStats summarizes one plan node across benchmark runs.
+type NodeStats struct {
+	// Node identifies the node, e.g. "Seq Scan on orders".
+	Node string
+	// Time is the node's inclusive time across all its loops, in ms.
+	Time Stats
+	// Rows is the node's actual rows per loop.
+	Rows Stats
+	// Reads and Hits are the node's buffer reads and hits, as for
+	// NodeBuffers.TotalRead and TotalHit.
+	Reads Stats
+	Hits  Stats
+
+	// Times are the node's inclusive times in ms, one per run the node
+	// was aggregated from.
+	Times []float64 `json:"-"`
+	// LoopTimes are the same per loop, as Actual Total Time reports them,
+	// so they can be tested alongside it.
+	LoopTimes []float64 `json:"-"`
+}
+
+// Benchmark describes the runs a plan was aggregated from by Aggregate.
+type Benchmark struct {
+	// Runs is the number of measured runs and Warmup the number of runs
+	// before them that were discarded.
+	Runs   int
+	Warmup int
+
+	// Excluded counts measured runs whose plan shape differed from the
+	// representative plan's. Their times count toward ExecutionTime and
+	// PlanningTime, but not toward the per-node statistics.
+	Excluded int
+
+	ExecutionTime Stats
+	PlanningTime  Stats
+
+	// ExecutionTimes are the measured runs' execution times in ms, in run
+	// order.
+	ExecutionTimes []float64
+
+	// Nodes holds per-node statistics, in depth-first order of the
+	// representative plan.
+	Nodes []NodeStats
+
+	// Plans are the measured runs' plans, in run order.
+	Plans []ExplainOutput `json:"-"`
+}
+
+// Aggregate combines plans of the same statement from repeated runs into a
+// representative plan. Runs can settle on different plans; the shape most
+// runs share wins, and its run with the median execution time is the
+// template. Each node's actual times, rows and buffer counts are replaced
+// by their medians across the runs of that shape, and the execution and
+// planning times by their medians across all runs. The result's Benchmark
+// holds the distributions and every run's plan, and each of its nodes'
+// Stats the node's own.
+func Aggregate(runs []ExplainOutput) ExplainOutput {
+	if len(runs) == 0 {
+		return ExplainOutput{}
+	}
+
+	// Pick the most common shape, the earliest one on a tie.
+	keys := make([]string, len(runs))
+	counts := make(map[string]int)
+	for i := range runs {
+		keys[i] = shapeKey(&runs[i].Plan)
+		counts[keys[i]]++
+	}
+	shape := keys[0]
+	for _, k := range keys {
+		if counts[k] > counts[shape] {
+			shape = k
+		}
+	}
+	var matching []ExplainOutput
+	for i, k := range keys {
+		if k == shape {
+			matching = append(matching, runs[i])
+		}
+	}
+
+	// The template is the lower median run by execution time.
+	byTime := slices.Clone(matching)
+	slices.SortStableFunc(byTime, func(a, b ExplainOutput) int {
+		return cmp.Compare(a.ExecutionTime, b.ExecutionTime)
+	})
+	rep := byTime[(len(byTime)-1)/2]
+	rep.Plan = cloneNode(&rep.Plan)
+
+	bench := &Benchmark{
+		Runs:     len(runs),
+		Excluded: len(runs) - len(matching),
+		Plans:    slices.Clone(runs),
+	}
+	var planning []float64
+	for _, r := range runs {
+		bench.ExecutionTimes = append(bench.ExecutionTimes, r.ExecutionTime)
+		planning = append(planning, r.PlanningTime)
+	}
+	bench.ExecutionTime = Summarize(bench.ExecutionTimes)
+	bench.PlanningTime = Summarize(planning)
+	rep.ExecutionTime = bench.ExecutionTime.Median
+	rep.PlanningTime = bench.PlanningTime.Median
+
+	// Same shape means the same depth-first node sequence.
+	repNodes := flatten(&rep.Plan)
+	runNodes := make([][]*PlanNode, len(matching))
+	for i := range matching {
+		runNodes[i] = flatten(&matching[i].Plan)
+	}
+	for k, node := range repNodes {
+		samples := make([]*PlanNode, len(matching))
+		for i := range matching {
+			samples[i] = runNodes[i][k]
+		}
+		bench.Nodes = append(bench.Nodes, aggregateNode(node, samples))
+	}
+	for k, node := range repNodes {
+		node.Stats = &bench.Nodes[k]
+	}
+
+	rep.Benchmark = bench
+	return rep
+}
+
+// aggregateNode sets node's actual figures to the medians of samples, the
+// same node in each run, and returns their statistics.
+func aggregateNode(node *PlanNode, samples []*PlanNode) NodeStats {
+	stats := NodeStats{Node: node.NodeType}
+	if node.RelationName != "" {
+		stats.Node += " on " + node.RelationName
+	}
+
+	collect := func(f func(n *PlanNode) float64) []float64 {
+		values := make([]float64, len(samples))
+		for i, n := range samples {
+			values[i] = f(n)
+		}
+		return values
+	}
+	stats.Times = collect(func(n *PlanNode) float64 { return n.ActualTotalTime * float64(n.ActualLoops) })
+	stats.Time = Summarize(stats.Times)
+	stats.LoopTimes = collect(func(n *PlanNode) float64 { return n.ActualTotalTime })
+	stats.Rows = Summarize(collect(func(n *PlanNode) float64 { return n.ActualRows }))
+	stats.Reads = Summarize(collect(func(n *PlanNode) float64 { return float64(NodeBufferBreakdown(n).TotalRead()) }))
+	stats.Hits = Summarize(collect(func(n *PlanNode) float64 { return float64(NodeBufferBreakdown(n).TotalHit()) }))
+
+	node.ActualStartupTime = Summarize(collect(func(n *PlanNode) float64 { return n.ActualStartupTime })).Median
+	node.ActualTotalTime = Summarize(stats.LoopTimes).Median
+	node.ActualRows = stats.Rows.Median
+	for i, field := range bufferFields(node) {
+		values := collect(func(n *PlanNode) float64 { return float64(*bufferFields(n)[i]) })
+		*field = int64(math.Round(Summarize(values).Median))
+	}
+	return stats
+}
+
+func bufferFields(n *PlanNode) []*int64 {
+	return []*int64{
+		&n.SharedHitBlocks, &n.SharedReadBlocks, &n.SharedDirtiedBlocks, &n.SharedWrittenBlocks,
+		&n.LocalHitBlocks, &n.LocalReadBlocks, &n.LocalDirtiedBlocks, &n.LocalWrittenBlocks,
+		&n.TempReadBlocks, &n.TempWrittenBlocks,
+	}
+}
+
+// SameShape reports whether a and b have the same node types, relations and
+// indexes, nested the same way: whether the planner chose the same plan,
+// whatever its costs and timings.
+func SameShape(a, b *PlanNode) bool {
+	return shapeKey(a) == shapeKey(b)
+}
+
+// shapeKey identifies a plan's shape: its node types, relations and indexes
+// and how they nest.
+func shapeKey(node *PlanNode) string {
+	var b strings.Builder
+	var walk func(n *PlanNode)
+	walk = func(n *PlanNode) {
+		fmt.Fprintf(&b, "(%s|%s|%s", n.NodeType, n.RelationName, n.IndexName)
+		for i := range n.Plans {
+			walk(&n.Plans[i])
+		}
+		b.WriteString(")")
+	}
+	walk(node)
+	return b.String()
+}
+
+// flatten returns pointers to node and its descendants, depth first.
+func flatten(node *PlanNode) []*PlanNode {
+	nodes := []*PlanNode{node}
+	for i := range node.Plans {
+		nodes = append(nodes, flatten(&node.Plans[i])...)
+	}
+	return nodes
+}
+
+// cloneNode copies node deeply enough that changing the copy's figures
+// leaves node's tree alone.
+func cloneNode(node *PlanNode) PlanNode {
+	c := *node
+	if node.Plans != nil {
+		c.Plans = make([]PlanNode, len(node.Plans))
+		for i := range node.Plans {
+			c.Plans[i] = cloneNode(&node.Plans[i])
+		}
+	}
+	return c
+}
@@ -0,0 +1,122 @@
+package plan
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []float64
+		want    Stats
+	}{
+		{"empty", nil, Stats{}},
+		{"odd", []float64{5, 1, 3}, Stats{Min: 1, Median: 3, P95: 5, Max: 5}},
+		{"even", []float64{4, 1, 3, 2}, Stats{Min: 1, Median: 2.5, P95: 4, Max: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.samples); got != tt.want {
+				t.Errorf("Summarize(%v) = %+v, want %+v", tt.samples, got, tt.want)
+			}
+		})
+	}
+
+	samples := make([]float64, 100)
+	for i := range samples {
+		samples[i] = float64(100 - i)
+	}
+	if got := Summarize(samples).P95; got != 95 {
+		t.Errorf("P95 of 1..100 = %v, want 95", got)
+	}
+}
+
+func benchmarkRun(execTime, scanTime float64, reads int64) ExplainOutput {
+	return ExplainOutput{
+		ExecutionTime: execTime,
+		PlanningTime:  0.1,
+		Plan: PlanNode{
+			NodeType:         "Aggregate",
+			ActualTotalTime:  execTime,
+			ActualLoops:      1,
+			ActualRows:       1,
+			SharedReadBlocks: reads,
+			Plans: []PlanNode{{
+				NodeType:         "Seq Scan",
+				RelationName:     "orders",
+				ActualTotalTime:  scanTime,
+				ActualLoops:      2,
+				ActualRows:       500,
+				SharedReadBlocks: reads,
+			}},
+		},
+	}
+}
+
+func TestAggregate_Medians(t *testing.T) {
+	runs := []ExplainOutput{
+		benchmarkRun(10, 4, 100),
+		benchmarkRun(30, 12, 0),
+		benchmarkRun(12, 5, 0),
+	}
+
+	got := Aggregate(runs)
+
+	if got.ExecutionTime != 12 {
+		t.Errorf("ExecutionTime = %v, want median 12", got.ExecutionTime)
+	}
+	if got.Plan.Plans[0].ActualTotalTime != 5 {
+		t.Errorf("scan ActualTotalTime = %v, want median 5", got.Plan.Plans[0].ActualTotalTime)
+	}
+	if got.Plan.SharedReadBlocks != 0 {
+		t.Errorf("SharedReadBlocks = %d, want median 0", got.Plan.SharedReadBlocks)
+	}
+
+	b := got.Benchmark
+	if b == nil {
+		t.Fatal("Benchmark is nil")
+	}
+	if b.Runs != 3 || b.Excluded != 0 || len(b.Plans) != 3 {
+		t.Errorf("Runs = %d, Excluded = %d, len(Plans) = %d, want 3, 0, 3", b.Runs, b.Excluded, len(b.Plans))
+	}
+	if want := (Stats{Min: 10, Median: 12, P95: 30, Max: 30}); b.ExecutionTime != want {
+		t.Errorf("ExecutionTime stats = %+v, want %+v", b.ExecutionTime, want)
+	}
+	if len(b.Nodes) != 2 {
+		t.Fatalf("len(Nodes) = %d, want 2", len(b.Nodes))
+	}
+	scan := b.Nodes[1]
+	if scan.Node != "Seq Scan on orders" {
+		t.Errorf("Node = %q, want %q", scan.Node, "Seq Scan on orders")
+	}
+	// Node time is inclusive of all loops: 2 x per-loop time.
+	if want := (Stats{Min: 8, Median: 10, P95: 24, Max: 24}); scan.Time != want {
+		t.Errorf("scan Time = %+v, want %+v", scan.Time, want)
+	}
+	if scan.Reads.Max != 100 {
+		t.Errorf("scan Reads.Max = %v, want 100", scan.Reads.Max)
+	}
+
+	if runs[0].Plan.Plans[0].ActualTotalTime != 4 {
+		t.Error("Aggregate modified its input plans")
+	}
+}
+
+func TestAggregate_DifferentShapes(t *testing.T) {
+	other := benchmarkRun(50, 40, 0)
+	other.Plan.Plans[0].NodeType = "Index Scan"
+
+	runs := []ExplainOutput{other, benchmarkRun(10, 4, 0), benchmarkRun(12, 5, 0)}
+	got := Aggregate(runs)
+
+	if got.Plan.Plans[0].NodeType != "Seq Scan" {
+		t.Errorf("representative plan uses %s, want the majority shape (Seq Scan)", got.Plan.Plans[0].NodeType)
+	}
+	if got.Benchmark.Excluded != 1 {
+		t.Errorf("Excluded = %d, want 1", got.Benchmark.Excluded)
+	}
+	if got.Benchmark.Nodes[1].Time.Max != 10 {
+		t.Errorf("scan Time.Max = %v, want 10 (the other shape's run left out)", got.Benchmark.Nodes[1].Time.Max)
+	}
+	if got.ExecutionTime != 12 {
+		t.Errorf("ExecutionTime = %v, want 12 (median over all runs)", got.ExecutionTime)
+	}
+}
@@ -17,6 +17,25 @@ type NodeBuffers struct {
 	Temp   BlockCounts
 }
 
+// Add returns the element-wise sum of n and o, e.g. to total the buffers of
+// several independent statements.
+func (n NodeBuffers) Add(o NodeBuffers) NodeBuffers {
+	return NodeBuffers{
+		Shared: n.Shared.add(o.Shared),
+		Local:  n.Local.add(o.Local),
+		Temp:   n.Temp.add(o.Temp),
+	}
+}
+
+func (b BlockCounts) add(o BlockCounts) BlockCounts {
+	return BlockCounts{
+		Hit:     b.Hit + o.Hit,
+		Read:    b.Read + o.Read,
+		Dirtied: b.Dirtied + o.Dirtied,
+		Written: b.Written + o.Written,
+	}
+}
+
 func (n NodeBuffers) TotalRead() int64 {
 	return n.Shared.Read + n.Local.Read + n.Temp.Read
 }
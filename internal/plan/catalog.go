@@ -0,0 +1,320 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Catalog is what the database knows about the relations a plan reads: their
+// indexes, column statistics, sizes and activity, as LoadCatalog reads them.
+// It lets rules tell an index that exists but wasn't chosen from one that is
+// missing, and a misestimate from statistics gone stale.
+type Catalog struct {
+	// LoadedAt is the database's clock when the catalog was read, which
+	// LastAnalyzed is measured against.
+	LoadedAt  time.Time
+	Relations []Relation
+}
+
+// Relation is one table's entry in a Catalog.
+type Relation struct {
+	Schema string
+	Name   string
+
+	// Pages and Tuples are pg_class's relpages and reltuples, the planner's
+	// idea of the table's size as of its last VACUUM or ANALYZE. Tuples is
+	// -1 when it has had neither. TotalBytes is the size on disk, indexes
+	// and TOAST included.
+	Pages      int64
+	Tuples     float64
+	TotalBytes int64
+
+	// LiveTuples, DeadTuples and ModifiedSinceAnalyze are the statistics
+	// collector's counts from pg_stat_user_tables. LastAnalyzed is the later
+	// of the last ANALYZE and autoanalyze, nil if there was neither.
+	LiveTuples           int64
+	DeadTuples           int64
+	ModifiedSinceAnalyze int64
+	LastAnalyzed         *time.Time `json:",omitempty"`
+
+	Indexes []Index `json:",omitempty"`
+	// Columns are the pg_stats entries of the table's analyzed columns.
+	Columns []ColumnStats `json:",omitempty"`
+}
+
+// Index is an index on a Relation, as pg_indexes lists it.
+type Index struct {
+	Name       string
+	Definition string
+	// Columns are the key columns in order, INCLUDE columns left out. An
+	// expression is shown as pg_get_indexdef shows it, e.g.
+	// lower((email)::text).
+	Columns []string
+	Unique  bool
+	// Predicate is the WHERE clause of a partial index.
+	Predicate string `json:",omitempty"`
+}
+
+// ColumnStats are a column's planner statistics, from pg_stats.
+type ColumnStats struct {
+	Name     string
+	NullFrac float64
+	// NDistinct is the number of distinct values when positive, or minus
+	// their number as a fraction of rows when negative: -1 is unique.
+	NDistinct float64
+	// Correlation is how closely the table's physical order follows the
+	// column's, from -1 to 1; nil when it wasn't computed.
+	Correlation *float64 `json:",omitempty"`
+}
+
+// Relation returns the catalog's entry for the table a plan names, or nil.
+// Without a schema, as in plans made without VERBOSE, the name must match
+// one table only. Relation can be called on a nil Catalog.
+func (c *Catalog) Relation(schema, name string) *Relation {
+	if c == nil {
+		return nil
+	}
+	var match *Relation
+	for i := range c.Relations {
+		r := &c.Relations[i]
+		if r.Name != name {
+			continue
+		}
+		if r.Schema == schema {
+			return r
+		}
+		if schema == "" {
+			if match != nil {
+				return nil
+			}
+			match = r
+		}
+	}
+	return match
+}
+
+// QualifiedName returns r's name with its schema.
+func (r *Relation) QualifiedName() string {
+	return r.Schema + "." + r.Name
+}
+
+// Index returns r's index called name, or nil.
+func (r *Relation) Index(name string) *Index {
+	for i := range r.Indexes {
+		if r.Indexes[i].Name == name {
+			return &r.Indexes[i]
+		}
+	}
+	return nil
+}
+
+// Column returns the statistics of r's column called name, or nil when it
+// has none.
+func (r *Relation) Column(name string) *ColumnStats {
+	for i := range r.Columns {
+		if r.Columns[i].Name == name {
+			return &r.Columns[i]
+		}
+	}
+	return nil
+}
+
+// relationRef names a relation as a plan node does: Schema is empty in plans
+// made without VERBOSE.
+type relationRef struct {
+	Schema string
+	Name   string
+}
+
+// planRelations returns the relations plans scan, without duplicates, in
+// the order they are first met.
+func planRelations(plans []ExplainOutput) []relationRef {
+	var refs []relationRef
+	var walk func(node *PlanNode)
+	walk = func(node *PlanNode) {
+		if node.RelationName != "" {
+			if ref := (relationRef{node.Schema, node.RelationName}); !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+		for i := range node.Plans {
+			walk(&node.Plans[i])
+		}
+	}
+	for i := range plans {
+		walk(&plans[i].Plan)
+	}
+	return refs
+}
+
+// LoadCatalog reads the catalog entries of every relation plans scan from
+// dbConn: pg_class sizes, pg_stat_user_tables activity, pg_indexes and
+// pg_stats. Relation names without a schema are looked up with opts'
+// settings applied and setup run, so search_path resolves them as it did
+// for the plan; everything runs in a transaction that is rolled back. A
+// relation that no longer exists, such as a temporary table the script
+// created, is left out.
+func LoadCatalog(ctx context.Context, dbConn string, plans []ExplainOutput, opts ExecOptions) (*Catalog, error) {
+	setup := SplitStatements(opts.Setup)
+	if err := checkStatements(setup, nil, opts); err != nil {
+		return nil, err
+	}
+
+	refs := planRelations(plans)
+	if len(refs) == 0 {
+		return &Catalog{}, nil
+	}
+
+	conn, err := connect(ctx, dbConn)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close(context.WithoutCancel(ctx)) }()
+
+	tx, _, err := beginScript(ctx, conn, setup, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	catalog := &Catalog{}
+	if err := tx.QueryRow(ctx, "SELECT now()").Scan(&catalog.LoadedAt); err != nil {
+		return nil, queryError(ctx, opts, "catalog", "reading the clock", err)
+	}
+
+	oids, err := loadRelations(ctx, tx, refs, catalog, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(oids) == 0 {
+		return catalog, nil
+	}
+	if err := loadIndexes(ctx, tx, oids, catalog, opts); err != nil {
+		return nil, err
+	}
+	if err := loadColumnStats(ctx, tx, oids, catalog, opts); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+const relationsQuery = `
+WITH refs AS (
+	SELECT DISTINCT to_regclass(CASE WHEN r.schema = '' THEN quote_ident(r.name)
+		ELSE quote_ident(r.schema) || '.' || quote_ident(r.name) END) AS oid
+	FROM unnest($1::text[], $2::text[]) AS r(schema, name)
+)
+SELECT c.oid::bigint, n.nspname, c.relname, c.relpages, c.reltuples,
+	pg_total_relation_size(c.oid),
+	coalesce(s.n_live_tup, 0), coalesce(s.n_dead_tup, 0), coalesce(s.n_mod_since_analyze, 0),
+	greatest(s.last_analyze, s.last_autoanalyze)
+FROM refs
+JOIN pg_class c ON c.oid = refs.oid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+ORDER BY n.nspname, c.relname`
+
+// loadRelations adds an entry to catalog for each of refs that exists, and
+// returns the entries' OIDs, in the same order.
+func loadRelations(ctx context.Context, tx pgx.Tx, refs []relationRef, catalog *Catalog, opts ExecOptions) ([]int64, error) {
+	schemas, names := make([]string, len(refs)), make([]string, len(refs))
+	for i, ref := range refs {
+		schemas[i], names[i] = ref.Schema, ref.Name
+	}
+
+	rows, err := tx.Query(ctx, relationsQuery, schemas, names)
+	if err != nil {
+		return nil, queryError(ctx, opts, "catalog", "reading relations", err)
+	}
+	defer rows.Close()
+
+	var oids []int64
+	for rows.Next() {
+		var oid int64
+		var r Relation
+		if err := rows.Scan(&oid, &r.Schema, &r.Name, &r.Pages, &r.Tuples, &r.TotalBytes,
+			&r.LiveTuples, &r.DeadTuples, &r.ModifiedSinceAnalyze, &r.LastAnalyzed); err != nil {
+			return nil, fmt.Errorf("reading relations: %w", err)
+		}
+		oids = append(oids, oid)
+		catalog.Relations = append(catalog.Relations, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, opts, "catalog", "reading relations", err)
+	}
+	return oids, nil
+}
+
+const indexesQuery = `
+SELECT i.indrelid::bigint, x.indexname, x.indexdef, i.indisunique,
+	ARRAY(SELECT pg_get_indexdef(i.indexrelid, k, true)
+		FROM generate_series(1, i.indnkeyatts::int) AS k ORDER BY k),
+	coalesce(pg_get_expr(i.indpred, i.indrelid, true), '')
+FROM pg_index i
+JOIN pg_class ic ON ic.oid = i.indexrelid
+JOIN pg_namespace n ON n.oid = ic.relnamespace
+JOIN pg_indexes x ON x.schemaname = n.nspname AND x.indexname = ic.relname
+WHERE i.indrelid::bigint = ANY($1) AND i.indisvalid
+ORDER BY x.indexname`
+
+// loadIndexes adds the valid indexes of the relations oids identifies to
+// their entries in catalog.
+func loadIndexes(ctx context.Context, tx pgx.Tx, oids []int64, catalog *Catalog, opts ExecOptions) error {
+	rows, err := tx.Query(ctx, indexesQuery, oids)
+	if err != nil {
+		return queryError(ctx, opts, "catalog", "reading indexes", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oid int64
+		var idx Index
+		if err := rows.Scan(&oid, &idx.Name, &idx.Definition, &idx.Unique, &idx.Columns, &idx.Predicate); err != nil {
+			return fmt.Errorf("reading indexes: %w", err)
+		}
+		if i := slices.Index(oids, oid); i >= 0 {
+			catalog.Relations[i].Indexes = append(catalog.Relations[i].Indexes, idx)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return queryError(ctx, opts, "catalog", "reading indexes", err)
+	}
+	return nil
+}
+
+const columnStatsQuery = `
+SELECT c.oid::bigint, s.attname, s.null_frac, s.n_distinct, s.correlation
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_stats s ON s.schemaname = n.nspname AND s.tablename = c.relname AND NOT s.inherited
+WHERE c.oid::bigint = ANY($1)
+ORDER BY s.attname`
+
+// loadColumnStats adds the pg_stats entries of the relations oids
+// identifies to their entries in catalog.
+func loadColumnStats(ctx context.Context, tx pgx.Tx, oids []int64, catalog *Catalog, opts ExecOptions) error {
+	rows, err := tx.Query(ctx, columnStatsQuery, oids)
+	if err != nil {
+		return queryError(ctx, opts, "catalog", "reading column statistics", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oid int64
+		var col ColumnStats
+		if err := rows.Scan(&oid, &col.Name, &col.NullFrac, &col.NDistinct, &col.Correlation); err != nil {
+			return fmt.Errorf("reading column statistics: %w", err)
+		}
+		if i := slices.Index(oids, oid); i >= 0 {
+			catalog.Relations[i].Columns = append(catalog.Relations[i].Columns, col)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return queryError(ctx, opts, "catalog", "reading column statistics", err)
+	}
+	return nil
+}
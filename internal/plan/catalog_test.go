@@ -0,0 +1,101 @@
+package plan
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlanRelations(t *testing.T) {
+	plans := []ExplainOutput{
+		{Plan: PlanNode{NodeType: "Hash Join", Plans: []PlanNode{
+			{NodeType: "Seq Scan", Schema: "public", RelationName: "orders"},
+			{NodeType: "Hash", Plans: []PlanNode{
+				{NodeType: "Seq Scan", Schema: "public", RelationName: "customers"},
+			}},
+		}}},
+		{Plan: PlanNode{NodeType: "Index Scan", Schema: "public", RelationName: "orders"}},
+		{Plan: PlanNode{NodeType: "Seq Scan", RelationName: "orders"}},
+	}
+
+	got := planRelations(plans)
+	want := []relationRef{{"public", "orders"}, {"public", "customers"}, {"", "orders"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("planRelations = %v, want %v", got, want)
+	}
+}
+
+func TestCatalogRelation(t *testing.T) {
+	c := &Catalog{Relations: []Relation{
+		{Schema: "public", Name: "orders"},
+		{Schema: "archive", Name: "orders"},
+		{Schema: "public", Name: "customers"},
+	}}
+
+	tests := []struct {
+		schema, name string
+		want         string // qualified name, "" for nil
+	}{
+		{"archive", "orders", "archive.orders"},
+		{"", "customers", "public.customers"},
+		{"", "orders", ""}, // ambiguous
+		{"sales", "customers", ""},
+		{"public", "missing", ""},
+	}
+	for _, tt := range tests {
+		var got string
+		if r := c.Relation(tt.schema, tt.name); r != nil {
+			got = r.QualifiedName()
+		}
+		if got != tt.want {
+			t.Errorf("Relation(%q, %q) = %q, want %q", tt.schema, tt.name, got, tt.want)
+		}
+	}
+
+	var nilCatalog *Catalog
+	if r := nilCatalog.Relation("public", "orders"); r != nil {
+		t.Errorf("Relation on a nil Catalog = %v, want nil", r)
+	}
+}
+
+func TestRelationIndexAndColumn(t *testing.T) {
+	r := Relation{
+		Indexes: []Index{{Name: "orders_pkey", Columns: []string{"id"}}},
+		Columns: []ColumnStats{{Name: "status", NDistinct: 4}},
+	}
+	if idx := r.Index("orders_pkey"); idx == nil || idx.Columns[0] != "id" {
+		t.Errorf("Index(orders_pkey) = %v", idx)
+	}
+	if idx := r.Index("missing"); idx != nil {
+		t.Errorf("Index(missing) = %v, want nil", idx)
+	}
+	if col := r.Column("status"); col == nil || col.NDistinct != 4 {
+		t.Errorf("Column(status) = %v", col)
+	}
+	if col := r.Column("missing"); col != nil {
+		t.Errorf("Column(missing) = %v, want nil", col)
+	}
+}
+
+func TestLoadCatalog_RejectsBeforeConnecting(t *testing.T) {
+	plans := []ExplainOutput{{Plan: PlanNode{NodeType: "Seq Scan", RelationName: "orders"}}}
+
+	_, err := LoadCatalog(context.Background(), "postgres://invalid", plans, ExecOptions{Setup: "COMMIT;"})
+	if err == nil || !strings.Contains(err.Error(), "setup statement 1") {
+		t.Errorf("err = %v, want the setup statement refused", err)
+	}
+}
+
+func TestLoadCatalog_NoRelations(t *testing.T) {
+	plans := []ExplainOutput{{Plan: PlanNode{NodeType: "Result"}}}
+
+	// No relation to look up, so no connection is made.
+	c, err := LoadCatalog(context.Background(), "postgres://invalid", plans, ExecOptions{})
+	if err != nil {
+		t.Fatalf("LoadCatalog: %v", err)
+	}
+	if len(c.Relations) != 0 {
+		t.Errorf("Relations = %v, want none", c.Relations)
+	}
+}
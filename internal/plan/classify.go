@@ -0,0 +1,258 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StatementKind is what running a statement can do to the database.
+type StatementKind int
+
+const (
+	// KindRead only reads: SELECT, VALUES, TABLE, and WITH queries whose
+	// CTEs are all SELECTs.
+	KindRead StatementKind = iota
+	// KindSession only affects the current session or transaction: SET,
+	// temporary tables, prepared statements, cursors, NOTIFY.
+	KindSession
+	// KindWrite modifies or locks rows: INSERT, UPDATE, DELETE, MERGE,
+	// writable CTEs, SELECT ... FOR UPDATE, SELECT INTO, COPY, LOCK, and
+	// queries calling a function with side effects: see sideEffectFuncs.
+	KindWrite
+	// KindDDL changes the schema or runs maintenance: CREATE, ALTER, DROP,
+	// TRUNCATE, GRANT, VACUUM, ANALYZE, ... Unrecognized statements are
+	// treated as DDL.
+	KindDDL
+	// KindCall runs a procedure or anonymous code block, which can do
+	// anything: CALL, DO.
+	KindCall
+	// KindTransaction controls the transaction Execute runs everything in:
+	// BEGIN, COMMIT, ROLLBACK, SAVEPOINT, SET TRANSACTION, ...
+	KindTransaction
+)
+
+func (k StatementKind) String() string {
+	switch k {
+	case KindRead:
+		return "read"
+	case KindSession:
+		return "session"
+	case KindWrite:
+		return "write"
+	case KindDDL:
+		return "DDL"
+	case KindCall:
+		return "call"
+	case KindTransaction:
+		return "transaction control"
+	}
+	return fmt.Sprintf("StatementKind(%d)", int(k))
+}
+
+// Modifies reports whether statements of kind k can change data or schema
+// or take locks other sessions wait on.
+func (k StatementKind) Modifies() bool {
+	return k == KindWrite || k == KindDDL || k == KindCall
+}
+
+// ClassifyStatement reports what running stmt can do. It is conservative:
+// anything it can't show to be harmless counts as a write or DDL. An
+// EXECUTE of a prepared statement is a write, since the statement it runs
+// isn't known; Execute resolves those against PREPAREs earlier in the same
+// script.
+func ClassifyStatement(stmt string) StatementKind {
+	kind, _ := classify(stmt, nil)
+	return kind
+}
+
+// classify returns stmt's kind and a short description of what made it so,
+// such as "UPDATE" or "writable CTE". prepared maps the upper-cased names of
+// statements PREPAREd earlier in the script to their kinds.
+func classify(stmt string, prepared map[string]StatementKind) (StatementKind, string) {
+	words := sqlWords(stmt)
+	if len(words) == 0 {
+		return KindSession, "empty statement"
+	}
+
+	first := words[0].text
+	second := ""
+	if len(words) > 1 {
+		second = words[1].text
+	}
+
+	switch first {
+	case "SELECT", "WITH", "VALUES", "TABLE", "DECLARE":
+		return classifyQuery(words)
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "COPY", "LOCK":
+		return KindWrite, first
+	case "CALL", "DO":
+		return KindCall, first
+	case "BEGIN", "START", "COMMIT", "END", "ROLLBACK", "ABORT", "SAVEPOINT", "RELEASE":
+		return KindTransaction, first
+	case "SET":
+		if second == "TRANSACTION" || second == "SESSION" && len(words) > 2 && words[2].text == "CHARACTERISTICS" {
+			return KindTransaction, "SET TRANSACTION"
+		}
+		return KindSession, first
+	case "PREPARE":
+		if second == "TRANSACTION" {
+			return KindTransaction, "PREPARE TRANSACTION"
+		}
+		return KindSession, first
+	case "EXECUTE":
+		if kind, ok := prepared[second]; ok {
+			return kind, "EXECUTE of a prepared " + kind.String() + " statement"
+		}
+		return KindWrite, "EXECUTE of an unknown prepared statement"
+	case "RESET", "SHOW", "DISCARD", "DEALLOCATE", "FETCH", "MOVE", "CLOSE", "LISTEN", "UNLISTEN", "NOTIFY":
+		return KindSession, first
+	case "CREATE":
+		if second == "TEMP" || second == "TEMPORARY" ||
+			(second == "LOCAL" || second == "GLOBAL") && len(words) > 2 && (words[2].text == "TEMP" || words[2].text == "TEMPORARY") {
+			return KindSession, "CREATE TEMP"
+		}
+	}
+
+	return KindDDL, first
+}
+
+// sideEffectFuncs are the upper-cased names of built-in and common extension
+// functions whose effects outlive the rolled-back transaction or reach
+// beyond it: sequence changes, remote statements, session-level advisory
+// locks, signals to other backends, files written on the server.
+// Transaction-level advisory locks are included too, since other sessions
+// wait on them while the query runs.
+var sideEffectFuncs = map[string]bool{
+	"NEXTVAL": true,
+	"SETVAL":  true,
+
+	"DBLINK":            true,
+	"DBLINK_EXEC":       true,
+	"DBLINK_SEND_QUERY": true,
+
+	"PG_ADVISORY_LOCK":                 true,
+	"PG_ADVISORY_LOCK_SHARED":          true,
+	"PG_ADVISORY_XACT_LOCK":            true,
+	"PG_ADVISORY_XACT_LOCK_SHARED":     true,
+	"PG_TRY_ADVISORY_LOCK":             true,
+	"PG_TRY_ADVISORY_LOCK_SHARED":      true,
+	"PG_TRY_ADVISORY_XACT_LOCK":        true,
+	"PG_TRY_ADVISORY_XACT_LOCK_SHARED": true,
+
+	"PG_CANCEL_BACKEND":    true,
+	"PG_TERMINATE_BACKEND": true,
+	"PG_RELOAD_CONF":       true,
+	"PG_ROTATE_LOGFILE":    true,
+	"PG_SWITCH_WAL":        true,
+
+	"PG_CREATE_RESTORE_POINT":                true,
+	"PG_CREATE_PHYSICAL_REPLICATION_SLOT":    true,
+	"PG_CREATE_LOGICAL_REPLICATION_SLOT":     true,
+	"PG_DROP_REPLICATION_SLOT":               true,
+	"PG_LOGICAL_SLOT_GET_CHANGES":            true,
+	"PG_REPLICATION_SLOT_ADVANCE":            true,
+	"PG_STAT_RESET":                          true,
+	"PG_STAT_RESET_SHARED":                   true,
+	"PG_STAT_RESET_SINGLE_TABLE_COUNTERS":    true,
+	"PG_STAT_RESET_SINGLE_FUNCTION_COUNTERS": true,
+
+	"LO_EXPORT":     true,
+	"PG_FILE_WRITE": true,
+}
+
+// classifyQuery classifies a query statement, which only reads unless it
+// has a data-modifying CTE, locks the rows it reads, creates a table or
+// calls a function with side effects.
+func classifyQuery(words []sqlWord) (StatementKind, string) {
+	for i, w := range words {
+		prev := ""
+		if i > 0 {
+			prev = words[i-1].text
+		}
+
+		if w.call && sideEffectFuncs[w.text] {
+			return KindWrite, "call to " + strings.ToLower(w.text)
+		}
+
+		switch w.text {
+		case "UPDATE", "SHARE":
+			if prev == "FOR" || prev == "KEY" {
+				return KindWrite, "SELECT ... FOR " + w.text
+			}
+			if w.text == "UPDATE" {
+				return KindWrite, "writable CTE"
+			}
+		case "INSERT", "DELETE", "MERGE":
+			return KindWrite, "writable CTE"
+		case "INTO":
+			if w.depth == 0 && words[0].text != "DECLARE" {
+				return KindWrite, "SELECT INTO"
+			}
+		}
+	}
+	return KindRead, words[0].text
+}
+
+// preparedName returns the upper-cased name and body of a PREPARE
+// statement.
+func preparedName(stmt string) (name, body string, ok bool) {
+	words := sqlWords(stmt)
+	if len(words) < 3 || words[0].text != "PREPARE" || words[1].text == "TRANSACTION" {
+		return "", "", false
+	}
+	for _, w := range words[2:] {
+		if w.text == "AS" && w.depth == 0 {
+			return words[1].text, stmt[w.end:], true
+		}
+	}
+	return "", "", false
+}
+
+// sqlWord is a keyword or unquoted identifier in a statement.
+type sqlWord struct {
+	text  string // upper-cased
+	depth int    // parenthesis nesting level
+	end   int    // offset just past the word
+	call  bool   // followed by "(", as a function name is
+}
+
+// sqlWords returns the words of stmt outside string literals, quoted
+// identifiers, dollar-quoted bodies and comments.
+func sqlWords(stmt string) []sqlWord {
+	var words []sqlWord
+	depth := 0
+	for i := 0; i < len(stmt); {
+		switch c := stmt[i]; {
+		case c == '\'':
+			i = skipQuoted(stmt, i, '\'', isEscapeString(stmt, i))
+		case c == '"':
+			i = skipQuoted(stmt, i, '"', false)
+		case c == '-' && strings.HasPrefix(stmt[i:], "--"):
+			i = skipLineComment(stmt, i)
+		case c == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			i = skipBlockComment(stmt, i)
+		case c == '$':
+			i = skipDollarQuoted(stmt, i)
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+		case isIdentChar(c):
+			start := i
+			for i < len(stmt) && isIdentChar(stmt[i]) {
+				i++
+			}
+			words = append(words, sqlWord{
+				text:  strings.ToUpper(stmt[start:i]),
+				depth: depth,
+				end:   i,
+				call:  strings.HasPrefix(strings.TrimLeft(stmt[i:], " \t\r\n"), "("),
+			})
+		default:
+			i++
+		}
+	}
+	return words
+}
@@ -0,0 +1,86 @@
+package plan
+
+import "testing"
+
+func TestClassifyStatement(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want StatementKind
+	}{
+		{"SELECT * FROM orders", KindRead},
+		{"(SELECT 1) UNION (SELECT 2)", KindRead},
+		{"VALUES (1), (2)", KindRead},
+		{"TABLE orders", KindRead},
+		{"WITH recent AS (SELECT * FROM orders) SELECT * FROM recent", KindRead},
+		{"SELECT 'update me', \"delete\" FROM t -- insert\n/* merge */", KindRead},
+		{"SELECT $$INSERT INTO t$$", KindRead},
+		{"SELECT * FROM t WHERE id IN (SELECT id FROM u INTO_x)", KindRead},
+		{"DECLARE c CURSOR FOR SELECT * FROM t", KindRead},
+		{"SELECT nextval FROM t WHERE note = 'nextval(x)'", KindRead},
+		{"SELECT currval('orders_id_seq')", KindRead},
+
+		{"INSERT INTO t VALUES (1)", KindWrite},
+		{"update t set a = 1", KindWrite},
+		{"DELETE FROM t", KindWrite},
+		{"MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN DELETE", KindWrite},
+		{"WITH moved AS (DELETE FROM t RETURNING *) INSERT INTO archive SELECT * FROM moved", KindWrite},
+		{"WITH d AS (UPDATE t SET a = 1 RETURNING *) SELECT * FROM d", KindWrite},
+		{"SELECT * FROM t FOR UPDATE", KindWrite},
+		{"SELECT * FROM t FOR NO KEY UPDATE SKIP LOCKED", KindWrite},
+		{"SELECT * FROM t FOR KEY SHARE", KindWrite},
+		{"SELECT * INTO backup FROM t", KindWrite},
+		{"COPY t FROM '/tmp/t.csv'", KindWrite},
+		{"LOCK TABLE t IN SHARE MODE", KindWrite},
+		{"SELECT nextval('orders_id_seq')", KindWrite},
+		{"SELECT pg_catalog.setval ('orders_id_seq', 1)", KindWrite},
+		{"SELECT dblink_exec('remote', 'DELETE FROM t')", KindWrite},
+		{"SELECT * FROM t WHERE pg_try_advisory_lock(t.id)", KindWrite},
+		{"WITH ids AS (SELECT NEXTVAL('s') AS id) SELECT * FROM ids", KindWrite},
+
+		{"CREATE INDEX ON t (a)", KindDDL},
+		{"CREATE TABLE t2 AS SELECT * FROM t", KindDDL},
+		{"ALTER TABLE t ADD COLUMN b int", KindDDL},
+		{"DROP TABLE t", KindDDL},
+		{"TRUNCATE t", KindDDL},
+		{"ANALYZE t", KindDDL},
+		{"VACUUM t", KindDDL},
+
+		{"CALL refresh_totals()", KindCall},
+		{"DO $$ BEGIN DELETE FROM t; END $$", KindCall},
+
+		{"SET work_mem = '256MB'", KindSession},
+		{"SET LOCAL enable_seqscan = off", KindSession},
+		{"RESET ALL", KindSession},
+		{"CREATE TEMP TABLE x AS SELECT * FROM t", KindSession},
+		{"CREATE LOCAL TEMPORARY TABLE x (id int)", KindSession},
+		{"PREPARE q AS DELETE FROM t", KindSession},
+		{"NOTIFY jobs", KindSession},
+
+		{"BEGIN", KindTransaction},
+		{"COMMIT", KindTransaction},
+		{"ROLLBACK", KindTransaction},
+		{"SAVEPOINT s", KindTransaction},
+		{"SET TRANSACTION READ WRITE", KindTransaction},
+		{"SET SESSION CHARACTERISTICS AS TRANSACTION READ WRITE", KindTransaction},
+		{"PREPARE TRANSACTION 'x'", KindTransaction},
+
+		{"EXECUTE q(1)", KindWrite},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyStatement(tt.stmt); got != tt.want {
+			t.Errorf("ClassifyStatement(%q) = %s, want %s", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestStatementKind_Modifies(t *testing.T) {
+	for kind, want := range map[StatementKind]bool{
+		KindRead: false, KindSession: false, KindTransaction: false,
+		KindWrite: true, KindDDL: true, KindCall: true,
+	} {
+		if got := kind.Modifies(); got != want {
+			t.Errorf("%s.Modifies() = %v, want %v", kind, got, want)
+		}
+	}
+}
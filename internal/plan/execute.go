@@ -2,33 +2,423 @@ package plan
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
 )
 
-func Execute(dbConn, sql string) ([]ExplainOutput, error) {
-	ctx := context.Background()
+// ExecOptions controls how Execute runs EXPLAIN. The zero value runs
+// EXPLAIN (ANALYZE, VERBOSE, BUFFERS, FORMAT JSON).
+type ExecOptions struct {
+	// EstimateOnly runs plain EXPLAIN (VERBOSE, FORMAT JSON): the statement
+	// is planned but never executed, so the plan carries no actual times,
+	// rows or buffers.
+	EstimateOnly bool
 
-	conn, err := pgx.Connect(ctx, dbConn)
+	// AllowWrites lets Execute run statements that modify data or schema,
+	// or call procedures. By default those are refused unless EstimateOnly
+	// keeps them from running: rolling back the transaction doesn't undo
+	// sequence increments, trigger side effects or the locks taken while
+	// they run.
+	AllowWrites bool
+
+	// ReadOnly runs the transaction as READ ONLY, so the database itself
+	// rejects anything that would write.
+	ReadOnly bool
+
+	// ParamSets holds values for the $1, $2, ... placeholders in the
+	// statements. The script runs once per set, each time in a transaction
+	// of its own. With no sets, statements with placeholders are planned
+	// with EXPLAIN (GENERIC_PLAN), which needs PostgreSQL 16 or later.
+	ParamSets [][]Param
+
+	// Settings are applied at the start of each transaction, as SET LOCAL
+	// would, in order.
+	Settings []Setting
+
+	// Setup is SQL run as-is after Settings and before the statements, in
+	// the same transaction: SET, CREATE TEMP TABLE, ...
+	Setup string
+
+	// Timeout bounds each statement, setup included: it is set as
+	// statement_timeout, and the client gives up shortly after. A statement
+	// that runs longer fails with a *TimeoutError. Zero means no limit.
+	Timeout time.Duration
+
+	// Runs, when above 1, runs the script that many times, each in a
+	// transaction of its own, and returns each statement's plans aggregated
+	// across the runs: see Aggregate. Warmup more runs before those are
+	// discarded, so the measured runs see warm caches. Both are ignored with
+	// EstimateOnly, which executes nothing to measure.
+	Runs   int
+	Warmup int
+}
+
+func (o ExecOptions) benchmarking() bool {
+	return !o.EstimateOnly && (o.Runs > 1 || o.Warmup > 0)
+}
+
+func (o ExecOptions) explainPrefix() string {
+	if o.EstimateOnly {
+		return "EXPLAIN (VERBOSE, FORMAT JSON) "
+	}
+	return "EXPLAIN (ANALYZE, VERBOSE, BUFFERS, FORMAT JSON) "
+}
+
+const genericPlanPrefix = "EXPLAIN (GENERIC_PLAN, VERBOSE, FORMAT JSON) "
+
+// Execute runs EXPLAIN for every statement in sql and returns one plan per
+// explainable statement, in order. All statements share one transaction
+// that is always rolled back, so statements EXPLAIN can't target (SET,
+// CREATE TEMP TABLE, ...) are run as-is for the statements after them to
+// see, without leaving anything behind. With several parameter sets, the
+// plans of each run follow those of the run before. When benchmarking,
+// each statement's plan is the aggregate of its measured runs.
+//
+// Canceling ctx cancels the running statement on the server, not just the
+// client's wait for it.
+func Execute(ctx context.Context, dbConn, sql string, opts ExecOptions) ([]ExplainOutput, error) {
+	statements := SplitStatements(sql)
+	setup := SplitStatements(opts.Setup)
+	if err := checkStatements(setup, statements, opts); err != nil {
+		return nil, err
+	}
+	if err := checkParams(statements, opts.ParamSets); err != nil {
+		return nil, err
+	}
+
+	conn, err := connect(ctx, dbConn)
 	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close(context.WithoutCancel(ctx)) }()
+
+	sets := opts.ParamSets
+	if len(sets) == 0 {
+		sets = [][]Param{nil}
+	}
+
+	var plans []ExplainOutput
+	for i, params := range sets {
+		runPlans, err := executeRuns(opts, func() ([]ExplainOutput, error) {
+			return executeScript(ctx, conn, setup, statements, params, opts)
+		})
+		if err != nil {
+			if len(opts.ParamSets) > 1 {
+				return nil, fmt.Errorf("parameter set %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+		plans = append(plans, runPlans...)
+	}
+	return plans, nil
+}
+
+// connect opens a connection to dbConn whose queries are canceled on the
+// server when ctx is done.
+func connect(ctx context.Context, dbConn string) (*pgx.Conn, error) {
+	config, err := pgx.ParseConfig(dbConn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing connection string: %w", err)
+	}
+	// pgx's default only closes the connection when ctx is done, which
+	// leaves the backend running the query until it next writes to the
+	// socket. A cancel request stops it right away.
+	config.BuildContextWatcherHandler = func(conn *pgconn.PgConn) ctxwatch.Handler {
+		return &pgconn.CancelRequestContextWatcherHandler{Conn: conn, DeadlineDelay: cancelGracePeriod}
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w while connecting to database", ErrInterrupted)
+		}
 		return nil, fmt.Errorf("connecting to database: %w", err)
 	}
-	defer func() { _ = conn.Close(ctx) }()
+	return conn, nil
+}
+
+// DescribeConn names the database dbConn connects to as host:port/database,
+// leaving out credentials, or "database" when dbConn doesn't parse.
+func DescribeConn(dbConn string) string {
+	config, err := pgconn.ParseConfig(dbConn)
+	if err != nil {
+		return "database"
+	}
+	return fmt.Sprintf("%s:%d/%s", config.Host, config.Port, config.Database)
+}
+
+// executeRuns runs the script once, or when benchmarking opts.Warmup +
+// opts.Runs times, aggregating each statement's plans across the measured
+// runs. run runs the script once.
+func executeRuns(opts ExecOptions, run func() ([]ExplainOutput, error)) ([]ExplainOutput, error) {
+	if !opts.benchmarking() {
+		return run()
+	}
+
+	var byStatement [][]ExplainOutput
+	for attempt := range opts.Warmup + max(opts.Runs, 1) {
+		plans, err := run()
+		if attempt < opts.Warmup {
+			if err != nil {
+				return nil, fmt.Errorf("warm-up run %d: %w", attempt+1, err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("run %d: %w", attempt-opts.Warmup+1, err)
+		}
+
+		if byStatement == nil {
+			byStatement = make([][]ExplainOutput, len(plans))
+		}
+		if len(plans) != len(byStatement) {
+			return nil, fmt.Errorf("run %d: got %d plans, but the first run got %d", attempt-opts.Warmup+1, len(plans), len(byStatement))
+		}
+		for i, p := range plans {
+			byStatement[i] = append(byStatement[i], p)
+		}
+	}
 
-	tx, err := conn.Begin(ctx)
+	aggregated := make([]ExplainOutput, len(byStatement))
+	for i, runs := range byStatement {
+		aggregated[i] = Aggregate(runs)
+		aggregated[i].Benchmark.Warmup = opts.Warmup
+	}
+	return aggregated, nil
+}
+
+// executeScript runs setup and statements once in a rolled-back
+// transaction, binding params to their placeholders. A nil params plans
+// statements that have placeholders generically.
+func executeScript(ctx context.Context, conn *pgx.Conn, setup, statements []string, params []Param, opts ExecOptions) ([]ExplainOutput, error) {
+	tx, settings, err := beginScript(ctx, conn, setup, opts)
 	if err != nil {
-		return nil, fmt.Errorf("beginning transaction: %w", err)
+		return nil, err
 	}
-	defer func() { _ = tx.Rollback(ctx) }()
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
 
-	query := "EXPLAIN (ANALYZE, VERBOSE, BUFFERS, FORMAT JSON) " + sql
+	return explainStatements(ctx, tx, statements, settings, params, opts)
+}
+
+// beginScript begins the transaction a script runs in, applies the
+// settings and runs setup. It returns the settings applied, statement
+// timeout included. The caller must roll tx back.
+func beginScript(ctx context.Context, conn *pgx.Conn, setup []string, opts ExecOptions) (pgx.Tx, []Setting, error) {
+	var txOpts pgx.TxOptions
+	if opts.ReadOnly {
+		txOpts.AccessMode = pgx.ReadOnly
+	}
 
-	var jsonStr string
-	err = tx.QueryRow(ctx, query).Scan(&jsonStr)
+	tx, err := conn.BeginTx(ctx, txOpts)
 	if err != nil {
-		return nil, fmt.Errorf("executing EXPLAIN: %w", err)
+		return nil, nil, queryError(ctx, opts, "transaction start", "beginning transaction", err)
 	}
 
-	return ParseJSONPlan([]byte(jsonStr))
+	settings := opts.Settings
+	if opts.Timeout > 0 {
+		settings = append(slices.Clip(settings), Setting{Name: "statement_timeout", Value: strconv.FormatInt(opts.Timeout.Milliseconds(), 10)})
+	}
+	if err := applySettings(ctx, tx, settings); err != nil {
+		_ = tx.Rollback(context.WithoutCancel(ctx))
+		return nil, nil, queryError(ctx, opts, "settings", "applying settings", err)
+	}
+	for i, stmt := range setup {
+		label := fmt.Sprintf("setup statement %d", i+1)
+		if err := execTimed(ctx, tx, stmt, opts); err != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			return nil, nil, queryError(ctx, opts, label, "executing "+label, err)
+		}
+	}
+	return tx, settings, nil
+}
+
+// explainStatements runs EXPLAIN for each explainable statement in tx, and
+// the others as-is. settings are those beginScript applied.
+func explainStatements(ctx context.Context, tx pgx.Tx, statements []string, settings []Setting, params []Param, opts ExecOptions) ([]ExplainOutput, error) {
+	var plans []ExplainOutput
+	for i, stmt := range statements {
+		label := fmt.Sprintf("statement %d", i+1)
+		if !isExplainable(stmt) {
+			if err := execTimed(ctx, tx, stmt, opts); err != nil {
+				return nil, queryError(ctx, opts, label, "executing "+label, err)
+			}
+			continue
+		}
+
+		applied, err := sessionSettings(ctx, tx, settings)
+		if err != nil {
+			return nil, queryError(ctx, opts, label, "preparing "+label, err)
+		}
+
+		query, args := opts.explainPrefix()+stmt, []any(nil)
+		doing := "executing EXPLAIN for " + label
+		var queryParams string
+		switch n := maxPlaceholder(stmt); {
+		case n == 0:
+		case params == nil:
+			// The placeholders stay unbound, so the statement must not go
+			// through the extended protocol's parameter check.
+			query, args = genericPlanPrefix+stmt, []any{pgx.QueryExecModeSimpleProtocol}
+			doing = "executing EXPLAIN (GENERIC_PLAN) for " + label + " (needs PostgreSQL 16 or later; supply parameter values otherwise)"
+		default:
+			args = make([]any, n)
+			for j, p := range params[:n] {
+				args[j] = p.arg()
+			}
+			queryParams = formatParams(params[:n])
+		}
+
+		jsonStr, err := queryTimed(ctx, tx, query, args, opts)
+		if err != nil {
+			return nil, queryError(ctx, opts, label, doing, err)
+		}
+
+		parsed, err := ParseJSONPlan([]byte(jsonStr))
+		if err != nil {
+			return nil, err
+		}
+		for j := range parsed {
+			parsed[j].QueryText = stmt
+			parsed[j].QueryParameters = queryParams
+			parsed[j].Settings = applied
+			parsed[j].FromSQL = true
+		}
+		plans = append(plans, parsed...)
+	}
+
+	return plans, nil
+}
+
+// ErrInterrupted is returned when the context Execute runs under is
+// canceled, e.g. on Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// TimeoutError reports a statement that ran longer than
+// ExecOptions.Timeout and was canceled.
+type TimeoutError struct {
+	Statement string // e.g. "statement 2"
+	Timeout   time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s and was canceled on the server; raise --timeout, or use --estimate to plan it without running it", e.Statement, e.Timeout)
+}
+
+// cancelGracePeriod is how long a canceled statement has to stop before
+// the connection is dropped, and how much later than statement_timeout the
+// client gives up on a server that doesn't enforce it.
+const cancelGracePeriod = 2 * time.Second
+
+// execTimed and queryTimed run one statement, bounded by opts.Timeout.
+func execTimed(ctx context.Context, tx pgx.Tx, sql string, opts ExecOptions) error {
+	ctx, cancel := statementContext(ctx, opts)
+	defer cancel()
+	_, err := tx.Exec(ctx, sql)
+	return err
+}
+
+func queryTimed(ctx context.Context, tx pgx.Tx, sql string, args []any, opts ExecOptions) (string, error) {
+	ctx, cancel := statementContext(ctx, opts)
+	defer cancel()
+	var result string
+	err := tx.QueryRow(ctx, sql, args...).Scan(&result)
+	return result, err
+}
+
+func statementContext(ctx context.Context, opts ExecOptions) (context.Context, context.CancelFunc) {
+	if opts.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, opts.Timeout+cancelGracePeriod)
+}
+
+// queryError describes err from running what (e.g. "statement 2"): as an
+// interruption or a TimeoutError if that's what ended it, otherwise as err
+// wrapped with what was being done.
+func queryError(ctx context.Context, opts ExecOptions, what, doing string, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w during %s; any running query was canceled on the server", ErrInterrupted, what)
+	}
+
+	var pgErr *pgconn.PgError
+	timedOut := errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) ||
+		errors.As(err, &pgErr) && pgErr.Code == "57014" && strings.Contains(pgErr.Message, "statement timeout")
+	if opts.Timeout > 0 && timedOut {
+		return &TimeoutError{Statement: what, Timeout: opts.Timeout}
+	}
+
+	return fmt.Errorf("%s: %w", doing, err)
+}
+
+// checkParams makes sure every parameter set has a value for each
+// placeholder the statements use, before anything is run.
+func checkParams(statements []string, sets [][]Param) error {
+	if len(sets) == 0 {
+		return nil
+	}
+
+	needed := 0
+	for _, stmt := range statements {
+		if isExplainable(stmt) {
+			needed = max(needed, maxPlaceholder(stmt))
+		}
+	}
+	if needed == 0 {
+		return fmt.Errorf("parameter values given, but no statement has $1, $2, ... placeholders")
+	}
+
+	for i, set := range sets {
+		if len(set) < needed {
+			if len(sets) > 1 {
+				return fmt.Errorf("parameter set %d has %d value(s), but the statements use up to $%d", i+1, len(set), needed)
+			}
+			return fmt.Errorf("%d parameter value(s) given, but the statements use up to $%d", len(set), needed)
+		}
+	}
+	return nil
+}
+
+// checkStatements refuses statements that could have effects outliving the
+// rolled-back transaction, before anything is run. Explainable statements
+// only run under ANALYZE, and not when planned generically for lack of
+// parameter values; setup and the other statements always run.
+func checkStatements(setup, statements []string, opts ExecOptions) error {
+	prepared := make(map[string]StatementKind)
+	check := func(label, stmt string, explained bool) error {
+		kind, what := classify(stmt, prepared)
+		if name, body, ok := preparedName(stmt); ok {
+			prepared[name], _ = classify(body, prepared)
+		}
+
+		runs := !explained || !opts.EstimateOnly && (len(opts.ParamSets) > 0 || maxPlaceholder(stmt) == 0)
+		switch {
+		case kind == KindTransaction:
+			return fmt.Errorf("%s (%s) is not allowed: every statement runs in one transaction that is always rolled back", label, what)
+		case !kind.Modifies() || opts.AllowWrites || !runs:
+			return nil
+		case !explained:
+			return fmt.Errorf("%s is a %s statement (%s) that would be run as-is; use --allow-writes (or allow_writes in the profile) to run it anyway", label, kind, what)
+		}
+		return fmt.Errorf("%s is a %s statement (%s): EXPLAIN ANALYZE runs it, and rolling back doesn't undo sequence increments, trigger side effects or the locks it takes; use --estimate to plan it without running it, or --allow-writes (or allow_writes in the profile) to run it anyway", label, kind, what)
+	}
+
+	for i, stmt := range setup {
+		if err := check(fmt.Sprintf("setup statement %d", i+1), stmt, false); err != nil {
+			return err
+		}
+	}
+	for i, stmt := range statements {
+		if err := check(fmt.Sprintf("statement %d", i+1), stmt, isExplainable(stmt)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
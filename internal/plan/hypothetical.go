@@ -0,0 +1,130 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoHypoPG is returned when the database lacks the hypopg extension
+// that hypothetical indexes need.
+var ErrNoHypoPG = errors.New("the hypopg extension is not installed in this database: run CREATE EXTENSION hypopg, or see https://github.com/HypoPG/hypopg")
+
+// HypotheticalPlans are a script's plans with one hypothetical index in
+// place.
+type HypotheticalPlans struct {
+	// Definition is the CREATE INDEX statement the index was made from.
+	Definition string
+	// IndexName is the name hypopg gave the index, as plans that use it
+	// show it in Index Name.
+	IndexName string
+	// Err is why the index couldn't be created, e.g. an unknown column.
+	// Plans is then empty.
+	Err   error
+	Plans []ExplainOutput
+}
+
+// ExecuteHypothetical plans every statement in sql with plain EXPLAIN, then
+// again with each index in definitions created hypothetically with hypopg,
+// one at a time, so the planner's choice can be checked without building
+// anything. It returns the plans without any of the indexes, and one
+// HypotheticalPlans per definition, in order. opts.EstimateOnly is implied:
+// the planner can pick a hypothetical index, but the executor can't use it.
+//
+// Everything runs in one transaction that is rolled back, and each index is
+// dropped before the next is created. A definition hypopg rejects is
+// reported in its HypotheticalPlans rather than failing the rest.
+func ExecuteHypothetical(ctx context.Context, dbConn, sql string, definitions []string, opts ExecOptions) ([]ExplainOutput, []HypotheticalPlans, error) {
+	opts.EstimateOnly = true
+	if len(opts.ParamSets) > 1 {
+		return nil, nil, fmt.Errorf("hypothetical indexes are checked with one parameter set, got %d", len(opts.ParamSets))
+	}
+
+	statements := SplitStatements(sql)
+	setup := SplitStatements(opts.Setup)
+	if err := checkStatements(setup, statements, opts); err != nil {
+		return nil, nil, err
+	}
+	if err := checkParams(statements, opts.ParamSets); err != nil {
+		return nil, nil, err
+	}
+	var params []Param
+	if len(opts.ParamSets) > 0 {
+		params = opts.ParamSets[0]
+	}
+
+	conn, err := connect(ctx, dbConn)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = conn.Close(context.WithoutCancel(ctx)) }()
+
+	tx, settings, err := beginScript(ctx, conn, setup, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	var installed bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'hypopg')").Scan(&installed); err != nil {
+		return nil, nil, queryError(ctx, opts, "hypopg check", "checking for hypopg", err)
+	}
+	if !installed {
+		return nil, nil, ErrNoHypoPG
+	}
+
+	explain := func() ([]ExplainOutput, error) {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, queryError(ctx, opts, "savepoint", "creating savepoint", err)
+		}
+		defer func() { _ = savepoint.Rollback(context.WithoutCancel(ctx)) }()
+		return explainStatements(ctx, savepoint, statements, settings, params, opts)
+	}
+
+	before, err := explain()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after := make([]HypotheticalPlans, len(definitions))
+	for i, def := range definitions {
+		after[i].Definition = def
+		name, err := createHypotheticalIndex(ctx, tx, def)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, queryError(ctx, opts, "hypothetical index", "creating hypothetical index", err)
+			}
+			after[i].Err = err
+			continue
+		}
+		after[i].IndexName = name
+
+		after[i].Plans, err = explain()
+		if _, resetErr := tx.Exec(ctx, "SELECT hypopg_reset()"); err == nil && resetErr != nil {
+			err = queryError(ctx, opts, "hypopg reset", "dropping hypothetical index", resetErr)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", def, err)
+		}
+	}
+	return before, after, nil
+}
+
+// createHypotheticalIndex creates the index def describes with hypopg, in a
+// savepoint so that a definition it rejects doesn't abort tx, and returns
+// its name.
+func createHypotheticalIndex(ctx context.Context, tx pgx.Tx, def string) (string, error) {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	var name string
+	if err := savepoint.QueryRow(ctx, "SELECT indexname FROM hypopg_create_index($1)", def).Scan(&name); err != nil {
+		_ = savepoint.Rollback(context.WithoutCancel(ctx))
+		return "", err
+	}
+	return name, savepoint.Commit(ctx)
+}
@@ -0,0 +1,21 @@
+package plan
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExecuteHypothetical_RejectsBeforeConnecting(t *testing.T) {
+	defs := []string{"CREATE INDEX ON orders (customer_id)"}
+
+	_, _, err := ExecuteHypothetical(context.Background(), "postgres://invalid", "SELECT * FROM orders WHERE customer_id = $1", defs, ExecOptions{ParamSets: [][]Param{{}, {}}})
+	if err == nil || !strings.Contains(err.Error(), "one parameter set") {
+		t.Errorf("err = %v, want a parameter set error", err)
+	}
+
+	_, _, err = ExecuteHypothetical(context.Background(), "postgres://invalid", "SELECT 1; COMMIT;", defs, ExecOptions{})
+	if err == nil || !strings.Contains(err.Error(), "statement 2") {
+		t.Errorf("err = %v, want the transaction statement refused", err)
+	}
+}
@@ -0,0 +1,98 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+)
+
+// Script is a named SQL script, e.g. a query file.
+type Script struct {
+	Name string
+	SQL  string
+}
+
+// ExecuteMigration plans every script, runs migration, and plans every script
+// again, returning each script's plans before and after, as Execute would
+// return them. Everything runs in one transaction that is always rolled back,
+// so the migration never takes effect. Each run of a script is wrapped in a
+// savepoint that is rolled back after it, so scripts don't see each other's
+// temporary tables or settings. Statements with placeholders are planned
+// generically.
+//
+// The migration's locks are held until the end, so it's best run against a
+// copy of production rather than production itself. Migration statements
+// that can't run in a transaction block, such as CREATE INDEX
+// CONCURRENTLY, fail.
+func ExecuteMigration(ctx context.Context, dbConn, migration string, scripts []Script, opts ExecOptions) (before, after [][]ExplainOutput, err error) {
+	if len(opts.ParamSets) > 0 {
+		return nil, nil, fmt.Errorf("parameter values aren't supported when checking a migration: statements with placeholders are planned generically")
+	}
+	if opts.ReadOnly {
+		return nil, nil, fmt.Errorf("a migration can't run in a READ ONLY transaction")
+	}
+
+	migrationStatements := SplitStatements(migration)
+	if len(migrationStatements) == 0 {
+		return nil, nil, fmt.Errorf("the migration has no statements")
+	}
+	prepared := make(map[string]StatementKind)
+	for i, stmt := range migrationStatements {
+		if kind, what := classify(stmt, prepared); kind == KindTransaction {
+			return nil, nil, fmt.Errorf("migration statement %d (%s) is not allowed: the migration runs in one transaction that is always rolled back", i+1, what)
+		}
+	}
+
+	setup := SplitStatements(opts.Setup)
+	statements := make([][]string, len(scripts))
+	for i, s := range scripts {
+		statements[i] = SplitStatements(s.SQL)
+		if err := checkStatements(setup, statements[i], opts); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", s.Name, err)
+		}
+	}
+
+	conn, err := connect(ctx, dbConn)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = conn.Close(context.WithoutCancel(ctx)) }()
+
+	tx, settings, err := beginScript(ctx, conn, setup, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	planAll := func(phase string) ([][]ExplainOutput, error) {
+		all := make([][]ExplainOutput, len(scripts))
+		for i, s := range scripts {
+			plans, err := executeRuns(opts, func() ([]ExplainOutput, error) {
+				savepoint, err := tx.Begin(ctx)
+				if err != nil {
+					return nil, queryError(ctx, opts, "savepoint", "creating savepoint", err)
+				}
+				defer func() { _ = savepoint.Rollback(context.WithoutCancel(ctx)) }()
+				return explainStatements(ctx, savepoint, statements[i], settings, nil, opts)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s the migration: %s: %w", phase, s.Name, err)
+			}
+			all[i] = plans
+		}
+		return all, nil
+	}
+
+	if before, err = planAll("before"); err != nil {
+		return nil, nil, err
+	}
+	for i, stmt := range migrationStatements {
+		label := fmt.Sprintf("migration statement %d", i+1)
+		if err := execTimed(ctx, tx, stmt, opts); err != nil {
+			return nil, nil, queryError(ctx, opts, label, "executing "+label, err)
+		}
+	}
+	if after, err = planAll("after"); err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
@@ -0,0 +1,28 @@
+package plan
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExecuteMigration_RejectsBeforeConnecting(t *testing.T) {
+	scripts := []Script{{Name: "orders.sql", SQL: "SELECT * FROM orders WHERE id = $1"}}
+	tests := []struct {
+		name      string
+		migration string
+		opts      ExecOptions
+		want      string
+	}{
+		{"empty migration", "-- nothing\n", ExecOptions{}, "no statements"},
+		{"commit in migration", "DROP INDEX orders_customer_idx; COMMIT;", ExecOptions{}, "migration statement 2"},
+		{"read only", "DROP INDEX orders_customer_idx;", ExecOptions{ReadOnly: true}, "READ ONLY"},
+		{"parameter values", "DROP INDEX orders_customer_idx;", ExecOptions{ParamSets: [][]Param{{}}}, "parameter values"},
+	}
+	for _, tt := range tests {
+		_, _, err := ExecuteMigration(context.Background(), "postgres://invalid", tt.migration, scripts, tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
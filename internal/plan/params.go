@@ -0,0 +1,319 @@
+package plan
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NullParam is how NULL is written in parameter flags and CSV files, as in
+// PostgreSQL's COPY text format.
+const NullParam = `\N`
+
+// maxParams is the most bind parameters PostgreSQL accepts for a statement.
+const maxParams = 65535
+
+var paramHeaderRe = regexp.MustCompile(`^\$?(\d+)$`)
+
+// Param is a bind parameter value in PostgreSQL's text format, so the server
+// parses it as whatever type its placeholder has.
+type Param struct {
+	Value string
+	Null  bool
+}
+
+// ParseParam returns the parameter for a command-line value: NullParam is
+// NULL, anything else is taken as-is.
+func ParseParam(s string) Param {
+	if s == NullParam {
+		return Param{Null: true}
+	}
+	return Param{Value: s}
+}
+
+func (p Param) arg() any {
+	if p.Null {
+		return nil
+	}
+	return p.Value
+}
+
+// literal renders p as PostgreSQL does in "Query Parameters".
+func (p Param) literal() string {
+	if p.Null {
+		return "NULL"
+	}
+	return "'" + strings.ReplaceAll(p.Value, "'", "''") + "'"
+}
+
+// formatParams renders params as auto_explain's "Query Parameters" value:
+// $1 = '42', $2 = NULL.
+func formatParams(params []Param) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = fmt.Sprintf("$%d = %s", i+1, p.literal())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LoadParams reads parameter sets from a file: CSV for a .csv file, JSON or
+// YAML otherwise. See ParseParamsCSV and ParseParams.
+func LoadParams(path string) ([][]Param, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading params file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var sets [][]Param
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		sets, err = ParseParamsCSV(f)
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(f); err == nil {
+			sets, err = ParseParams(data)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("params file %s: %w", path, err)
+	}
+	return sets, nil
+}
+
+// ParseParams parses parameter values from a JSON or YAML document, which
+// is one of:
+//
+//   - a list of values for $1, $2, ...: [42, "shipped"]
+//   - a mapping from placeholder to value: {"$1": 42, "$2": "shipped"}
+//   - a list of either, one parameter set per item
+//
+// Scalars are passed in their text form and null is NULL. Lists and
+// mappings in value position are passed as JSON, for json/jsonb parameters;
+// write a PostgreSQL array as its literal, e.g. "{1,2,3}".
+func ParseParams(data []byte) ([][]Param, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing params: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("no parameter values")
+	}
+
+	root := doc.Content[0]
+	switch root.Kind {
+	case yaml.MappingNode:
+		set, err := paramSet(root)
+		if err != nil {
+			return nil, err
+		}
+		return [][]Param{set}, nil
+	case yaml.SequenceNode:
+		if len(root.Content) == 0 {
+			return nil, fmt.Errorf("no parameter values")
+		}
+		if !isCollection(root.Content[0]) {
+			set, err := paramSet(root)
+			if err != nil {
+				return nil, err
+			}
+			return [][]Param{set}, nil
+		}
+
+		sets := make([][]Param, 0, len(root.Content))
+		for i, item := range root.Content {
+			if !isCollection(item) {
+				return nil, fmt.Errorf("parameter set %d: expected a list or mapping of values", i+1)
+			}
+			set, err := paramSet(item)
+			if err != nil {
+				return nil, fmt.Errorf("parameter set %d: %w", i+1, err)
+			}
+			sets = append(sets, set)
+		}
+		return sets, nil
+	}
+	return nil, fmt.Errorf("expected a list or mapping of parameter values")
+}
+
+func isCollection(n *yaml.Node) bool {
+	return n.Kind == yaml.SequenceNode || n.Kind == yaml.MappingNode
+}
+
+// paramSet converts a list of values, or a mapping from "$n" (or "n") to
+// value, to a parameter set. A mapping must cover $1 through its highest
+// placeholder.
+func paramSet(n *yaml.Node) ([]Param, error) {
+	if n.Kind == yaml.SequenceNode {
+		if len(n.Content) > maxParams {
+			return nil, fmt.Errorf("%d parameter values, but PostgreSQL accepts at most %d", len(n.Content), maxParams)
+		}
+		set := make([]Param, len(n.Content))
+		for i, item := range n.Content {
+			p, err := paramValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("$%d: %w", i+1, err)
+			}
+			set[i] = p
+		}
+		return set, nil
+	}
+
+	byIndex := make(map[int]Param)
+	maxIndex := 0
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i].Value
+		m := paramHeaderRe.FindStringSubmatch(key)
+		if m == nil {
+			return nil, fmt.Errorf("invalid placeholder %q: expected $1, $2, ...", key)
+		}
+		index, err := strconv.Atoi(m[1])
+		if err != nil || index < 1 {
+			return nil, fmt.Errorf("invalid placeholder %q: expected $1, $2, ...", key)
+		}
+		if index > maxParams {
+			return nil, fmt.Errorf("invalid placeholder %q: PostgreSQL accepts at most %d parameters", key, maxParams)
+		}
+		p, err := paramValue(n.Content[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("$%d: %w", index, err)
+		}
+		byIndex[index] = p
+		maxIndex = max(maxIndex, index)
+	}
+
+	set := make([]Param, maxIndex)
+	for i := range set {
+		p, ok := byIndex[i+1]
+		if !ok {
+			return nil, fmt.Errorf("missing value for $%d", i+1)
+		}
+		set[i] = p
+	}
+	return set, nil
+}
+
+func paramValue(n *yaml.Node) (Param, error) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return paramValue(n.Alias)
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return Param{Null: true}, nil
+		}
+		return Param{Value: n.Value}, nil
+	}
+
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return Param{}, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Param{}, err
+	}
+	return Param{Value: string(data)}, nil
+}
+
+// ParseParamsCSV reads one parameter set per CSV row, with columns in
+// placeholder order. A header row naming the placeholders ($1,$2,...) is
+// optional and may reorder the columns. A field of \N is NULL.
+func ParseParamsCSV(r io.Reader) ([][]Param, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing params: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no parameter values")
+	}
+
+	// order[c] is the 0-based parameter position of column c.
+	order := make([]int, len(rows[0]))
+	for c := range order {
+		order[c] = c
+	}
+	if header, ok := csvParamHeader(rows[0]); ok {
+		order = header
+		rows = rows[1:]
+		if len(rows) == 0 {
+			return nil, errors.New("no parameter values")
+		}
+	}
+
+	sets := make([][]Param, len(rows))
+	for i, row := range rows {
+		set := make([]Param, len(order))
+		for c, field := range row {
+			set[order[c]] = ParseParam(field)
+		}
+		sets[i] = set
+	}
+	return sets, nil
+}
+
+// csvParamHeader returns the parameter position of each column when row is
+// a header of distinct "$n" placeholders covering $1 through $len(row).
+func csvParamHeader(row []string) ([]int, bool) {
+	order := make([]int, len(row))
+	seen := make([]bool, len(row))
+	for c, field := range row {
+		if !strings.HasPrefix(field, "$") {
+			return nil, false
+		}
+		m := paramHeaderRe.FindStringSubmatch(field)
+		if m == nil {
+			return nil, false
+		}
+		index, _ := strconv.Atoi(m[1])
+		if index < 1 || index > len(row) || seen[index-1] {
+			return nil, false
+		}
+		seen[index-1] = true
+		order[c] = index - 1
+	}
+	return order, true
+}
+
+// maxPlaceholder returns the highest $n placeholder in stmt, or 0 if it has
+// none. Placeholders in string literals, quoted identifiers, dollar-quoted
+// bodies and comments don't count.
+func maxPlaceholder(stmt string) int {
+	highest := 0
+	for i := 0; i < len(stmt); {
+		switch c := stmt[i]; {
+		case c == '\'':
+			i = skipQuoted(stmt, i, '\'', isEscapeString(stmt, i))
+		case c == '"':
+			i = skipQuoted(stmt, i, '"', false)
+		case c == '-' && strings.HasPrefix(stmt[i:], "--"):
+			i = skipLineComment(stmt, i)
+		case c == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			i = skipBlockComment(stmt, i)
+		case c == '$' && i+1 < len(stmt) && stmt[i+1] >= '0' && stmt[i+1] <= '9' && (i == 0 || !isIdentChar(stmt[i-1])):
+			end := i + 1
+			for end < len(stmt) && stmt[end] >= '0' && stmt[end] <= '9' {
+				end++
+			}
+			if n, err := strconv.Atoi(stmt[i+1 : end]); err == nil {
+				highest = max(highest, n)
+			}
+			i = end
+		case c == '$':
+			i = skipDollarQuoted(stmt, i)
+		default:
+			i++
+		}
+	}
+	return highest
+}
@@ -0,0 +1,161 @@
+package plan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want [][]Param
+	}{
+		{
+			"JSON list",
+			`[42, "shipped", null, true]`,
+			[][]Param{{{Value: "42"}, {Value: "shipped"}, {Null: true}, {Value: "true"}}},
+		},
+		{
+			"JSON mapping",
+			`{"$2": "shipped", "$1": 42}`,
+			[][]Param{{{Value: "42"}, {Value: "shipped"}}},
+		},
+		{
+			"JSON sets",
+			`[[1, "a"], [2, "b"]]`,
+			[][]Param{{{Value: "1"}, {Value: "a"}}, {{Value: "2"}, {Value: "b"}}},
+		},
+		{
+			"YAML sets of mappings",
+			"- $1: 1\n  $2: o'brien\n- 1: 2\n  2: ~\n",
+			[][]Param{{{Value: "1"}, {Value: "o'brien"}}, {{Value: "2"}, {Null: true}}},
+		},
+		{
+			"nested value passed as JSON",
+			`[7, {"status": ["a", "b"]}]`,
+			[][]Param{{{Value: "7"}, {Value: `{"status":["a","b"]}`}}},
+		},
+		{
+			"numbers keep their text",
+			`[1.50, 1e3, "0042"]`,
+			[][]Param{{{Value: "1.50"}, {Value: "1e3"}, {Value: "0042"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseParams([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseParams_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{"empty", "", "no parameter values"},
+		{"empty list", "[]", "no parameter values"},
+		{"scalar", "42", "expected a list or mapping"},
+		{"gap in mapping", `{"$1": 1, "$3": 3}`, "missing value for $2"},
+		{"bad key", `{"id": 1}`, `invalid placeholder "id"`},
+		{"placeholder beyond the limit", `{"$1": 1, "$65536": 2}`, "at most 65535 parameters"},
+		{"placeholder overflowing int", `{"$99999999999999999999": 1}`, `invalid placeholder "$99999999999999999999"`},
+		{"mixed sets", `[[1], 2]`, "parameter set 2: expected a list or mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseParams([]byte(tt.doc))
+			if err == nil {
+				t.Fatalf("expected error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseParamsCSV(t *testing.T) {
+	got, err := ParseParamsCSV(strings.NewReader("1,shipped\n2,\\N\n3,\"a,b\"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][]Param{
+		{{Value: "1"}, {Value: "shipped"}},
+		{{Value: "2"}, {Null: true}},
+		{{Value: "3"}, {Value: "a,b"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseParamsCSV_Header(t *testing.T) {
+	got, err := ParseParamsCSV(strings.NewReader("$2,$1\nshipped,1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][]Param{{{Value: "1"}, {Value: "shipped"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseParamsCSV(strings.NewReader("$1,$2\n")); err == nil {
+		t.Error("expected error for header without rows")
+	}
+}
+
+func TestParseParamsCSV_RaggedRows(t *testing.T) {
+	if _, err := ParseParamsCSV(strings.NewReader("1,2\n3\n")); err == nil {
+		t.Error("expected error for rows with differing field counts")
+	}
+}
+
+func TestMaxPlaceholder(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want int
+	}{
+		{"SELECT 1", 0},
+		{"SELECT * FROM t WHERE a = $1 AND b = $2", 2},
+		{"SELECT * FROM t WHERE a = $2 OR a = $10", 10},
+		{"SELECT '$3', \"$4\" -- $5\n/* $6 */ FROM t WHERE a = $1", 1},
+		{"SELECT $$ $7 $$, $tag$ $8 $tag$ WHERE a = $1", 1},
+		{"SELECT a$1 FROM t", 0},
+		{"SELECT * FROM t WHERE a=$1::int", 1},
+	}
+
+	for _, tt := range tests {
+		if got := maxPlaceholder(tt.stmt); got != tt.want {
+			t.Errorf("maxPlaceholder(%q) = %d, want %d", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestFormatParams(t *testing.T) {
+	got := formatParams([]Param{{Value: "42"}, {Value: "o'brien"}, {Null: true}})
+	want := "$1 = '42', $2 = 'o''brien', $3 = NULL"
+	if got != want {
+		t.Errorf("formatParams = %q, want %q", got, want)
+	}
+}
+
+func TestParseParam(t *testing.T) {
+	if p := ParseParam(`\N`); !p.Null {
+		t.Errorf(`ParseParam(\N) = %+v, want NULL`, p)
+	}
+	if p := ParseParam("NULL"); p.Null || p.Value != "NULL" {
+		t.Errorf("ParseParam(NULL) = %+v, want the string NULL", p)
+	}
+}
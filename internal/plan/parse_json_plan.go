@@ -1,11 +1,23 @@
 package plan
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
 
+// ParseJSONPlan parses EXPLAIN (FORMAT JSON) output. PostgreSQL wraps each
+// plan in a one-element array, but a bare top-level object (as some tools
+// copy it, and as auto_explain logs it) is accepted too.
 func ParseJSONPlan(data []byte) ([]ExplainOutput, error) {
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		var single ExplainOutput
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return nil, fmt.Errorf("invalid EXPLAIN JSON: %w", err)
+		}
+		return []ExplainOutput{single}, nil
+	}
+
 	var plans []ExplainOutput
 	if err := json.Unmarshal(data, &plans); err != nil {
 		return nil, fmt.Errorf("invalid EXPLAIN JSON: %w", err)
@@ -510,6 +510,31 @@ func TestParseJSONPlan_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestParseJSONPlan_BareObject(t *testing.T) {
+	input := `{
+		"Plan": {"Node Type": "Seq Scan", "Relation Name": "users", "Total Cost": 20.0},
+		"Execution Time": 0.5
+	}`
+
+	plans, err := ParseJSONPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("expected 1 plan, got %d", len(plans))
+	}
+	if plans[0].Plan.RelationName != "users" || plans[0].ExecutionTime != 0.5 {
+		t.Errorf("got %q/%v, want users/0.5", plans[0].Plan.RelationName, plans[0].ExecutionTime)
+	}
+}
+
+func TestParseJSONPlan_InvalidBareObject(t *testing.T) {
+
+	if _, err := ParseJSONPlan([]byte(`{"Plan": {"Node Type": `)); err == nil {
+		t.Fatal("expected error for truncated object")
+	}
+}
+
 func TestParseJSONPlan_MissingPlanField(t *testing.T) {
 	input := `[{"Planning Time": 1.0, "Execution Time": 2.0}]`
 	plans, err := ParseJSONPlan([]byte(input))
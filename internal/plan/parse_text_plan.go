@@ -0,0 +1,549 @@
+package plan
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	textCostRe       = regexp.MustCompile(`\(cost=(\d+(?:\.\d+)?)\.\.(\d+(?:\.\d+)?) rows=(\d+) width=(\d+)\)`)
+	textActualRe     = regexp.MustCompile(`\(actual time=(\d+(?:\.\d+)?)\.\.(\d+(?:\.\d+)?) rows=(\d+(?:\.\d+)?) loops=(\d+)\)`)
+	textActualRowsRe = regexp.MustCompile(`\(actual rows=(\d+(?:\.\d+)?) loops=(\d+)\)`)
+	textNodeEndRe    = regexp.MustCompile(`\s*\((?:cost=|actual |never executed)`)
+	textLabelRe      = regexp.MustCompile(`^(?:(InitPlan|SubPlan) \d+.*|CTE \S+)$`)
+	textWorkerRe     = regexp.MustCompile(`^Worker \d+:`)
+	textTriggerRe    = regexp.MustCompile(`^(Trigger .+): time=(\d+(?:\.\d+)?) calls=(\d+)$`)
+	textJoinRe       = regexp.MustCompile(`^(Hash|Merge|Nested Loop)(?: (Left|Right|Full|Semi|Anti|Right Semi|Right Anti))? Join$`)
+	textKBRe         = regexp.MustCompile(`(\d+)kB`)
+	textSettingRe    = regexp.MustCompile(`([\w.]+) = '([^']*)'`)
+)
+
+// textNode is an intermediate tree node. Children are kept as pointers while
+// parsing so appending a sibling never invalidates an open parent; the tree
+// is copied into PlanNode values once the whole plan has been read.
+type textNode struct {
+	node     PlanNode
+	arrowCol int // column of the "->" marker, -1 for the root
+	children []*textNode
+}
+
+type textParser struct {
+	plans []ExplainOutput
+
+	cur   *ExplainOutput
+	root  *textNode
+	stack []*textNode
+
+	// base is the indentation of the first root node line. psql's aligned
+	// output prefixes every row with a space, so columns are measured
+	// relative to it rather than to the start of the line.
+	base int
+
+	// pendingLabel is a "SubPlan 1"/"InitPlan 1"/"CTE name" line waiting for
+	// the node it introduces.
+	pendingLabel string
+
+	// inTree is false once a top-level line (Planning Time, JIT:, ...) has
+	// closed the current plan tree; indented lines after it belong to that
+	// top-level section, not to the last node.
+	inTree bool
+
+	// workerCol is the column of a "Worker N:" line whose nested lines,
+	// that worker's own Buffers, Sort Method, ..., are being skipped, or -1.
+	workerCol int
+}
+
+// ParseTextPlan parses PostgreSQL's default text-format EXPLAIN output into
+// the same structure ParseJSONPlan produces. Plans printed back to back (e.g.
+// psql output for a multi-statement script) are returned in order.
+func ParseTextPlan(data []byte) ([]ExplainOutput, error) {
+	p := &textParser{base: -1, workerCol: -1}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		content := strings.TrimSpace(line)
+		if content == "" || isPsqlDecoration(content) {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if p.base < 0 {
+			p.base = indent
+		}
+		col := max(indent-p.base, 0)
+
+		if err := p.parseLine(col, content); err != nil {
+			return nil, err
+		}
+	}
+	p.finishPlan()
+
+	if len(p.plans) == 0 {
+		return nil, fmt.Errorf("no plan nodes found in text EXPLAIN output")
+	}
+	return p.plans, nil
+}
+
+func (p *textParser) parseLine(col int, content string) error {
+	if p.workerCol >= 0 {
+		if col > p.workerCol {
+			return nil
+		}
+		p.workerCol = -1
+	}
+
+	if rest, ok := strings.CutPrefix(content, "->"); ok {
+		if p.root == nil {
+			return fmt.Errorf("invalid text EXPLAIN output: child node %q before any root node", strings.TrimSpace(rest))
+		}
+		p.addChild(col, strings.TrimSpace(rest))
+		return nil
+	}
+
+	if col == 0 {
+		if isTextNodeLine(content) {
+			p.finishPlan()
+			p.startPlan(content)
+			return nil
+		}
+		p.parseTopLevel(content)
+		return nil
+	}
+
+	if !p.inTree || len(p.stack) == 0 {
+		return nil
+	}
+
+	if textLabelRe.MatchString(content) {
+		p.pendingLabel = content
+		return nil
+	}
+	if textWorkerRe.MatchString(content) {
+		p.workerCol = col
+		return nil
+	}
+
+	parseTextProperty(&p.stack[len(p.stack)-1].node, content)
+	return nil
+}
+
+// isTextNodeLine distinguishes a root node line from a top-level property
+// such as "Planning Time: 0.1 ms" - node lines carry cost/actual figures or,
+// with COSTS OFF, are simply a node name without a "Key: value" colon.
+func isTextNodeLine(content string) bool {
+	return textNodeEndRe.MatchString(content) || !strings.Contains(content, ":")
+}
+
+func (p *textParser) startPlan(content string) {
+	p.cur = &ExplainOutput{}
+	p.root = &textNode{arrowCol: -1}
+	parseTextNodeLine(&p.root.node, content)
+	p.stack = []*textNode{p.root}
+	p.pendingLabel = ""
+	p.inTree = true
+}
+
+func (p *textParser) addChild(arrowCol int, content string) {
+	for len(p.stack) > 1 && p.stack[len(p.stack)-1].arrowCol >= arrowCol {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	parent := p.stack[len(p.stack)-1]
+
+	child := &textNode{arrowCol: arrowCol}
+	parseTextNodeLine(&child.node, content)
+	if p.pendingLabel != "" {
+		child.node.SubplanName = p.pendingLabel
+		child.node.ParentRelationship = "InitPlan"
+		if strings.HasPrefix(p.pendingLabel, "SubPlan") {
+			child.node.ParentRelationship = "SubPlan"
+		}
+		p.pendingLabel = ""
+	}
+
+	parent.children = append(parent.children, child)
+	p.stack = append(p.stack, child)
+	p.inTree = true
+}
+
+func (p *textParser) finishPlan() {
+	if p.cur == nil || p.root == nil {
+		return
+	}
+	p.cur.Plan = p.root.build()
+	p.plans = append(p.plans, *p.cur)
+	p.cur = nil
+	p.root = nil
+	p.stack = nil
+	p.inTree = false
+}
+
+// build copies the intermediate tree into PlanNode values, filling in the
+// Parent Relationship PostgreSQL's JSON output would have reported. Text
+// output only prints it for SubPlan/InitPlan children, so the rest is
+// inferred from the parent's node type and the child's position.
+func (t *textNode) build() PlanNode {
+	node := t.node
+	position := 0
+	for _, c := range t.children {
+		child := c.build()
+		if child.ParentRelationship == "" {
+			switch node.NodeType {
+			case "Append", "Merge Append":
+				child.ParentRelationship = "Member"
+			case "Subquery Scan":
+				child.ParentRelationship = "Subquery"
+			default:
+				child.ParentRelationship = "Outer"
+				if position > 0 {
+					child.ParentRelationship = "Inner"
+				}
+			}
+			position++
+		}
+		node.Plans = append(node.Plans, child)
+	}
+	return node
+}
+
+func (p *textParser) parseTopLevel(content string) {
+	p.inTree = false
+	if p.cur == nil {
+		return
+	}
+
+	key, value, _ := strings.Cut(content, ": ")
+	switch key {
+	case "Planning Time":
+		p.cur.PlanningTime = parseTextMillis(value)
+	case "Execution Time", "Total runtime":
+		p.cur.ExecutionTime = parseTextMillis(value)
+	case "Settings":
+		// Settings: work_mem = '64MB', jit = 'off'
+		for _, m := range textSettingRe.FindAllStringSubmatch(value, -1) {
+			if p.cur.Settings == nil {
+				p.cur.Settings = make(map[string]string)
+			}
+			p.cur.Settings[m[1]] = m[2]
+		}
+	default:
+		if m := textTriggerRe.FindStringSubmatch(content); m != nil {
+			p.cur.Triggers = append(p.cur.Triggers, map[string]any{
+				"Trigger Name": strings.TrimPrefix(m[1], "Trigger "),
+				"Time":         parseTextFloat(m[2]),
+				"Calls":        parseTextInt(m[3]),
+			})
+		}
+	}
+}
+
+// parseTextNodeLine fills in node identity, estimates and actuals from a
+// node line such as:
+//
+//	Index Scan using users_pkey on public.users u  (cost=0.29..8.30 rows=1 width=8) (actual time=0.010..0.011 rows=1 loops=1)
+func parseTextNodeLine(node *PlanNode, line string) {
+	desc := line
+	if loc := textNodeEndRe.FindStringIndex(line); loc != nil {
+		desc = line[:loc[0]]
+	}
+	parseTextNodeDescription(node, strings.TrimSpace(desc))
+
+	if m := textCostRe.FindStringSubmatch(line); m != nil {
+		node.StartupCost = parseTextFloat(m[1])
+		node.TotalCost = parseTextFloat(m[2])
+		node.PlanRows = parseTextInt(m[3])
+		node.PlanWidth = int(parseTextInt(m[4]))
+	}
+	if m := textActualRe.FindStringSubmatch(line); m != nil {
+		node.ActualStartupTime = parseTextFloat(m[1])
+		node.ActualTotalTime = parseTextFloat(m[2])
+		node.ActualRows = parseTextFloat(m[3])
+		node.ActualLoops = parseTextInt(m[4])
+	} else if m := textActualRowsRe.FindStringSubmatch(line); m != nil {
+		node.ActualRows = parseTextFloat(m[1])
+		node.ActualLoops = parseTextInt(m[2])
+	}
+}
+
+// parseTextNodeDescription splits the node label (everything before the
+// cost parentheses) into the fields the JSON format reports separately,
+// e.g. "Parallel Seq Scan on public.users u" or "Hash Left Join".
+func parseTextNodeDescription(node *PlanNode, desc string) {
+	if rest, ok := strings.CutPrefix(desc, "Parallel "); ok {
+		node.ParallelAware = true
+		desc = rest
+	}
+	desc = strings.TrimPrefix(desc, "Async ")
+	for _, mode := range []string{"Partial", "Finalize"} {
+		if rest, ok := strings.CutPrefix(desc, mode+" "); ok {
+			node.PartialMode = mode
+			desc = rest
+		}
+	}
+
+	if m := textJoinRe.FindStringSubmatch(desc); m != nil {
+		node.NodeType = m[1] + " Join"
+		if m[1] == "Nested Loop" {
+			node.NodeType = "Nested Loop"
+		}
+		node.JoinType = coalesceString(m[2], "Inner")
+		return
+	}
+
+	switch desc {
+	case "Nested Loop":
+		node.NodeType = desc
+		node.JoinType = "Inner"
+		return
+	case "Aggregate":
+		node.NodeType, node.Strategy = "Aggregate", "Plain"
+		return
+	case "GroupAggregate":
+		node.NodeType, node.Strategy = "Aggregate", "Sorted"
+		return
+	case "HashAggregate":
+		node.NodeType, node.Strategy = "Aggregate", "Hashed"
+		return
+	case "MixedAggregate":
+		node.NodeType, node.Strategy = "Aggregate", "Mixed"
+		return
+	}
+
+	if rest, ok := strings.CutPrefix(desc, "HashSetOp "); ok && !strings.Contains(rest, " on ") {
+		node.NodeType, node.Strategy = "SetOp", "Hashed"
+		return
+	}
+	if rest, ok := strings.CutPrefix(desc, "SetOp "); ok && !strings.Contains(rest, " on ") {
+		node.NodeType, node.Strategy = "SetOp", "Sorted"
+		return
+	}
+
+	nodeType, target, hasTarget := strings.Cut(desc, " on ")
+	nodeType, index, hasIndex := strings.Cut(nodeType, " using ")
+	if hasIndex {
+		node.IndexName = index
+	}
+	if dir, ok := strings.CutSuffix(nodeType, " Backward"); ok {
+		nodeType = dir
+		node.ScanDirection = "Backward"
+	} else if strings.HasPrefix(nodeType, "Index") {
+		node.ScanDirection = "Forward"
+	}
+	node.NodeType = nodeType
+
+	if !hasTarget {
+		return
+	}
+
+	name, alias, _ := strings.Cut(target, " ")
+	switch nodeType {
+	case "Bitmap Index Scan":
+		node.IndexName = name
+	case "CTE Scan", "WorkTable Scan":
+		node.CTEName = name
+		node.Alias = coalesceString(alias, name)
+	case "Subquery Scan", "Function Scan", "Values Scan", "Table Function Scan", "Named Tuplestore Scan":
+		node.Alias = coalesceString(alias, name)
+	case "Insert", "Update", "Delete", "Merge":
+		node.NodeType = "ModifyTable"
+		node.Operation = nodeType
+		setTextRelation(node, name, alias)
+	default:
+		setTextRelation(node, name, alias)
+	}
+}
+
+func setTextRelation(node *PlanNode, name, alias string) {
+	if schema, rel, ok := strings.Cut(name, "."); ok {
+		node.Schema = schema
+		name = rel
+	}
+	node.RelationName = name
+	// JSON output always reports an alias, even when it is just the
+	// relation name; text output omits it in that case.
+	node.Alias = coalesceString(alias, name)
+}
+
+// parseTextProperty applies one indented "Key: value" line to node. Lines
+// for keys PlanNode doesn't model (Output, Recheck Cond, ...) are ignored.
+func parseTextProperty(node *PlanNode, line string) {
+	key, value, ok := strings.Cut(line, ": ")
+	if !ok {
+		return
+	}
+
+	switch key {
+	case "Filter":
+		node.Filter = value
+	case "Index Cond":
+		node.IndexCond = value
+	case "Hash Cond":
+		node.HashCond = value
+	case "Merge Cond":
+		node.MergeCond = value
+	case "Join Filter":
+		node.JoinFilter = value
+	case "Rows Removed by Filter":
+		node.RowsRemovedByFilter = int64(parseTextFloat(value))
+	case "Rows Removed by Join Filter":
+		node.RowsRemovedByJoinFilter = int64(parseTextFloat(value))
+	case "Sort Key":
+		node.SortKey = splitTextList(value)
+	case "Group Key":
+		node.GroupKey = splitTextList(value)
+	case "Sort Method":
+		parseTextSortMethod(node, value)
+	case "Buffers":
+		parseTextBuffers(node, value)
+	case "Heap Blocks":
+		for _, kv := range strings.Fields(value) {
+			k, v, _ := strings.Cut(kv, "=")
+			switch k {
+			case "exact":
+				node.ExactHeapBlocks = parseTextInt(v)
+			case "lossy":
+				node.LossyHeapBlocks = parseTextInt(v)
+			}
+		}
+	case "Buckets", "Batches":
+		parseTextHashInfo(node, line)
+	case "Workers Planned":
+		node.WorkersPlanned = int(parseTextInt(value))
+	case "Workers Launched":
+		node.WorkersLaunched = int(parseTextInt(value))
+	}
+}
+
+// parseTextSortMethod handles "quicksort  Memory: 71kB" and
+// "external merge  Disk: 1024kB".
+func parseTextSortMethod(node *PlanNode, value string) {
+	method, space, _ := strings.Cut(value, "  ")
+	node.SortMethod = strings.TrimSpace(method)
+
+	spaceType, used, ok := strings.Cut(strings.TrimSpace(space), ": ")
+	if !ok {
+		return
+	}
+	node.SortSpaceType = spaceType
+	if m := textKBRe.FindStringSubmatch(used); m != nil {
+		node.SortSpaceUsed = parseTextInt(m[1])
+	}
+}
+
+// parseTextHashInfo handles the Hash node line
+// "Buckets: 65536 (originally 1024)  Batches: 4 (originally 1)  Memory Usage: 4097kB"
+// and the HashAggregate line "Batches: 5  Memory Usage: 4145kB  Disk Usage: 20512kB".
+// HashAggregate batches are reported under a different JSON key ("HashAgg
+// Batches") that PlanNode doesn't model, so only its memory usage is kept.
+func parseTextHashInfo(node *PlanNode, line string) {
+	isHash := strings.HasPrefix(line, "Buckets:")
+
+	for segment := range strings.SplitSeq(line, "  ") {
+		key, value, ok := strings.Cut(strings.TrimSpace(segment), ": ")
+		if !ok {
+			continue
+		}
+		current, original, hasOriginal := strings.Cut(value, " (originally ")
+		original = strings.TrimSuffix(original, ")")
+
+		switch key {
+		case "Buckets":
+			node.HashBuckets = int(parseTextInt(current))
+		case "Batches":
+			if !isHash {
+				continue
+			}
+			node.HashBatches = int(parseTextInt(current))
+			node.OriginalHashBatches = node.HashBatches
+			if hasOriginal {
+				node.OriginalHashBatches = int(parseTextInt(original))
+			}
+		case "Memory Usage":
+			if m := textKBRe.FindStringSubmatch(value); m != nil {
+				node.PeakMemoryUsage = parseTextInt(m[1])
+			}
+		}
+	}
+}
+
+// parseTextBuffers handles
+// "shared hit=5 read=10 dirtied=1 written=2, local hit=1, temp read=3 written=4".
+func parseTextBuffers(node *PlanNode, value string) {
+	for group := range strings.SplitSeq(value, ",") {
+		fields := strings.Fields(group)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, kv := range fields[1:] {
+			k, v, _ := strings.Cut(kv, "=")
+			n := parseTextInt(v)
+			switch fields[0] + " " + k {
+			case "shared hit":
+				node.SharedHitBlocks = n
+			case "shared read":
+				node.SharedReadBlocks = n
+			case "shared dirtied":
+				node.SharedDirtiedBlocks = n
+			case "shared written":
+				node.SharedWrittenBlocks = n
+			case "local hit":
+				node.LocalHitBlocks = n
+			case "local read":
+				node.LocalReadBlocks = n
+			case "local dirtied":
+				node.LocalDirtiedBlocks = n
+			case "local written":
+				node.LocalWrittenBlocks = n
+			case "temp read":
+				node.TempReadBlocks = n
+			case "temp written":
+				node.TempWrittenBlocks = n
+			}
+		}
+	}
+}
+
+// splitTextList splits a Sort Key/Group Key value on top-level commas, so
+// expressions like "COALESCE(a, b)" stay intact.
+func splitTextList(value string) []string {
+	var items []string
+	depth, start := 0, 0
+	inQuote := false
+	for i, r := range value {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, strings.TrimSpace(value[start:]))
+}
+
+func parseTextMillis(value string) float64 {
+	return parseTextFloat(strings.TrimSpace(strings.TrimSuffix(value, "ms")))
+}
+
+func parseTextFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
+
+func parseTextInt(s string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return n
+}
+
+func coalesceString(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,470 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTextPlan_NestedPlan(t *testing.T) {
+	input := `                                                      QUERY PLAN
+-----------------------------------------------------------------------------------------------------------------------
+ Sort  (cost=69.83..72.33 rows=1000 width=8) (actual time=0.456..0.478 rows=1000 loops=1)
+   Output: id
+   Sort Key: users.id
+   Sort Method: quicksort  Memory: 71kB
+   Buffers: shared hit=5 read=10
+   ->  Seq Scan on public.users u  (cost=0.00..20.00 rows=1000 width=8) (actual time=0.013..0.108 rows=1000 loops=1)
+         Output: id
+         Filter: (u.active = true)
+         Rows Removed by Filter: 500
+         Buffers: shared hit=5 read=10
+ Planning Time: 0.085 ms
+ Execution Time: 0.523 ms
+(12 rows)
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("expected 1 plan, got %d", len(plans))
+	}
+
+	p := plans[0]
+	if p.PlanningTime != 0.085 {
+		t.Errorf("PlanningTime = %v, want 0.085", p.PlanningTime)
+	}
+	if p.ExecutionTime != 0.523 {
+		t.Errorf("ExecutionTime = %v, want 0.523", p.ExecutionTime)
+	}
+
+	root := p.Plan
+	checks := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"NodeType", root.NodeType, "Sort"},
+		{"StartupCost", root.StartupCost, 69.83},
+		{"TotalCost", root.TotalCost, 72.33},
+		{"PlanRows", root.PlanRows, int64(1000)},
+		{"PlanWidth", root.PlanWidth, 8},
+		{"ActualStartupTime", root.ActualStartupTime, 0.456},
+		{"ActualTotalTime", root.ActualTotalTime, 0.478},
+		{"ActualRows", root.ActualRows, float64(1000)},
+		{"ActualLoops", root.ActualLoops, int64(1)},
+		{"SortMethod", root.SortMethod, "quicksort"},
+		{"SortSpaceType", root.SortSpaceType, "Memory"},
+		{"SortSpaceUsed", root.SortSpaceUsed, int64(71)},
+		{"SharedHitBlocks", root.SharedHitBlocks, int64(5)},
+		{"SharedReadBlocks", root.SharedReadBlocks, int64(10)},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("root %s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if !reflect.DeepEqual(root.SortKey, []string{"users.id"}) {
+		t.Errorf("SortKey = %v, want [users.id]", root.SortKey)
+	}
+
+	if len(root.Plans) != 1 {
+		t.Fatalf("expected 1 child, got %d", len(root.Plans))
+	}
+	child := root.Plans[0]
+	childChecks := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"NodeType", child.NodeType, "Seq Scan"},
+		{"ParentRelationship", child.ParentRelationship, "Outer"},
+		{"Schema", child.Schema, "public"},
+		{"RelationName", child.RelationName, "users"},
+		{"Alias", child.Alias, "u"},
+		{"Filter", child.Filter, "(u.active = true)"},
+		{"RowsRemovedByFilter", child.RowsRemovedByFilter, int64(500)},
+		{"ActualTotalTime", child.ActualTotalTime, 0.108},
+	}
+	for _, c := range childChecks {
+		if c.got != c.want {
+			t.Errorf("child %s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestParseTextPlan_HashJoinWithBatches(t *testing.T) {
+	input := `Hash Left Join  (cost=30.50..200.00 rows=5000 width=16) (actual time=1.000..25.000 rows=5000 loops=1)
+  Hash Cond: (o.user_id = u.id)
+  Buffers: shared hit=40 read=60, temp read=120 written=120
+  ->  Seq Scan on orders o  (cost=0.00..100.00 rows=5000 width=8) (actual time=0.010..5.000 rows=5000 loops=1)
+        Buffers: shared hit=20 read=30
+  ->  Hash  (cost=20.00..20.00 rows=1000 width=8) (actual time=0.900..0.900 rows=1000 loops=1)
+        Buckets: 65536 (originally 1024)  Batches: 4 (originally 1)  Memory Usage: 4097kB
+        Buffers: shared hit=20 read=30, temp written=120
+        ->  Seq Scan on users u  (cost=0.00..20.00 rows=1000 width=8) (actual time=0.005..0.300 rows=1000 loops=1)
+              Buffers: shared hit=20 read=30
+Planning Time: 0.2 ms
+Execution Time: 26.1 ms
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	root := plans[0].Plan
+	if root.NodeType != "Hash Join" || root.JoinType != "Left" {
+		t.Errorf("root = %q/%q, want Hash Join/Left", root.NodeType, root.JoinType)
+	}
+	if root.HashCond != "(o.user_id = u.id)" {
+		t.Errorf("HashCond = %q", root.HashCond)
+	}
+	if root.TempReadBlocks != 120 || root.TempWrittenBlocks != 120 {
+		t.Errorf("temp blocks = %d/%d, want 120/120", root.TempReadBlocks, root.TempWrittenBlocks)
+	}
+	if len(root.Plans) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(root.Plans))
+	}
+	if root.Plans[0].ParentRelationship != "Outer" || root.Plans[1].ParentRelationship != "Inner" {
+		t.Errorf("relationships = %q/%q, want Outer/Inner",
+			root.Plans[0].ParentRelationship, root.Plans[1].ParentRelationship)
+	}
+
+	hash := root.Plans[1]
+	if hash.NodeType != "Hash" {
+		t.Errorf("NodeType = %q, want Hash", hash.NodeType)
+	}
+	if hash.HashBuckets != 65536 || hash.HashBatches != 4 || hash.OriginalHashBatches != 1 {
+		t.Errorf("hash = buckets %d batches %d (orig %d), want 65536/4/1",
+			hash.HashBuckets, hash.HashBatches, hash.OriginalHashBatches)
+	}
+	if hash.PeakMemoryUsage != 4097 {
+		t.Errorf("PeakMemoryUsage = %d, want 4097", hash.PeakMemoryUsage)
+	}
+	if len(hash.Plans) != 1 || hash.Plans[0].RelationName != "users" {
+		t.Fatalf("Hash child not attached: %+v", hash.Plans)
+	}
+	if hash.Plans[0].Alias != "u" {
+		t.Errorf("Alias = %q, want u", hash.Plans[0].Alias)
+	}
+}
+
+func TestParseTextPlan_IndexScanAndBitmap(t *testing.T) {
+	input := `Nested Loop  (cost=4.50..60.00 rows=10 width=8) (actual time=0.050..0.400 rows=10 loops=1)
+  ->  Bitmap Heap Scan on orders  (cost=4.20..30.00 rows=10 width=8) (actual time=0.030..0.100 rows=10 loops=1)
+        Recheck Cond: (status = 'open'::text)
+        Heap Blocks: exact=8 lossy=2
+        ->  Bitmap Index Scan on idx_orders_status  (cost=0.00..4.20 rows=10 width=0) (actual time=0.020..0.020 rows=10 loops=1)
+              Index Cond: (status = 'open'::text)
+  ->  Index Scan Backward using users_pkey on users  (cost=0.29..3.00 rows=1 width=8) (actual time=0.010..0.010 rows=1 loops=10)
+        Index Cond: (id = orders.user_id)
+        Filter: (active)
+        Rows Removed by Filter: 3
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	root := plans[0].Plan
+	if root.NodeType != "Nested Loop" || root.JoinType != "Inner" {
+		t.Errorf("root = %q/%q, want Nested Loop/Inner", root.NodeType, root.JoinType)
+	}
+
+	heap := root.Plans[0]
+	if heap.ExactHeapBlocks != 8 || heap.LossyHeapBlocks != 2 {
+		t.Errorf("heap blocks = %d/%d, want 8/2", heap.ExactHeapBlocks, heap.LossyHeapBlocks)
+	}
+	bitmap := heap.Plans[0]
+	if bitmap.NodeType != "Bitmap Index Scan" || bitmap.IndexName != "idx_orders_status" || bitmap.RelationName != "" {
+		t.Errorf("bitmap = %q index %q relation %q", bitmap.NodeType, bitmap.IndexName, bitmap.RelationName)
+	}
+
+	idx := root.Plans[1]
+	checks := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"NodeType", idx.NodeType, "Index Scan"},
+		{"ScanDirection", idx.ScanDirection, "Backward"},
+		{"IndexName", idx.IndexName, "users_pkey"},
+		{"RelationName", idx.RelationName, "users"},
+		{"Alias", idx.Alias, "users"},
+		{"IndexCond", idx.IndexCond, "(id = orders.user_id)"},
+		{"RowsRemovedByFilter", idx.RowsRemovedByFilter, int64(3)},
+		{"ActualLoops", idx.ActualLoops, int64(10)},
+		{"ParentRelationship", idx.ParentRelationship, "Inner"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestParseTextPlan_ParallelWorkersAndAggregate(t *testing.T) {
+	input := `Finalize GroupAggregate  (cost=1000.00..5000.00 rows=100 width=16) (actual time=10.000..50.000 rows=100 loops=1)
+  Group Key: region, COALESCE(city, 'n/a'::text)
+  ->  Gather Merge  (cost=1000.00..4900.00 rows=200 width=16) (actual time=10.000..49.000 rows=300 loops=1)
+        Workers Planned: 2
+        Workers Launched: 1
+        ->  Partial HashAggregate  (cost=0.00..3000.00 rows=100 width=16) (actual time=9.000..9.500 rows=100.00 loops=2)
+              Group Key: region, COALESCE(city, 'n/a'::text)
+              Batches: 5  Memory Usage: 4145kB  Disk Usage: 20512kB
+              Worker 0:  Batches: 1  Memory Usage: 100kB
+              ->  Parallel Seq Scan on sales  (cost=0.00..2000.00 rows=50000 width=16) (actual time=0.010..4.000 rows=50000 loops=2)
+                    Worker 0:  actual time=0.010..4.100 rows=48000 loops=1
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	root := plans[0].Plan
+	if root.NodeType != "Aggregate" || root.Strategy != "Sorted" || root.PartialMode != "Finalize" {
+		t.Errorf("root = %q/%q/%q, want Aggregate/Sorted/Finalize", root.NodeType, root.Strategy, root.PartialMode)
+	}
+	wantKey := []string{"region", "COALESCE(city, 'n/a'::text)"}
+	if !reflect.DeepEqual(root.GroupKey, wantKey) {
+		t.Errorf("GroupKey = %q, want %q", root.GroupKey, wantKey)
+	}
+
+	gather := root.Plans[0]
+	if gather.WorkersPlanned != 2 || gather.WorkersLaunched != 1 {
+		t.Errorf("workers = %d/%d, want 2/1", gather.WorkersLaunched, gather.WorkersPlanned)
+	}
+
+	agg := gather.Plans[0]
+	if agg.Strategy != "Hashed" || agg.PartialMode != "Partial" {
+		t.Errorf("agg = %q/%q, want Hashed/Partial", agg.Strategy, agg.PartialMode)
+	}
+	if agg.HashBatches != 0 {
+		t.Errorf("HashBatches = %d, want 0 (HashAgg batches are not Hash Batches)", agg.HashBatches)
+	}
+	if agg.PeakMemoryUsage != 4145 {
+		t.Errorf("PeakMemoryUsage = %d, want 4145 (worker lines must be ignored)", agg.PeakMemoryUsage)
+	}
+
+	scan := agg.Plans[0]
+	if scan.NodeType != "Seq Scan" || !scan.ParallelAware {
+		t.Errorf("scan = %q parallel %v, want Seq Scan/true", scan.NodeType, scan.ParallelAware)
+	}
+	if scan.ActualRows != 50000 {
+		t.Errorf("ActualRows = %v, want 50000 (worker line must not override)", scan.ActualRows)
+	}
+}
+
+func TestParseTextPlan_ParallelSortWorkerDetails(t *testing.T) {
+	input := `Gather Merge  (cost=8505.65..18228.98 rows=83334 width=12) (actual time=31.402..58.012 rows=100000 loops=1)
+  Workers Planned: 2
+  Workers Launched: 2
+  Buffers: shared hit=270
+  ->  Sort  (cost=7505.62..7609.79 rows=41667 width=12) (actual time=24.881..27.300 rows=33333 loops=3)
+        Sort Key: created_at
+        Sort Method: quicksort  Memory: 3207kB
+        Buffers: shared hit=90
+        Worker 0:  actual time=22.035..24.301 rows=30123 loops=1
+          Sort Method: quicksort  Memory: 2867kB
+          Buffers: shared hit=2
+        Worker 1:  actual time=22.101..24.412 rows=29754 loops=1
+          Sort Method: external merge  Disk: 880kB
+          Buffers: shared hit=3, temp read=110 written=111
+        ->  Parallel Seq Scan on events  (cost=0.00..4303.67 rows=41667 width=12) (actual time=0.010..6.204 rows=33333 loops=3)
+              Buffers: shared hit=90
+Planning Time: 0.110 ms
+Execution Time: 61.530 ms
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort := plans[0].Plan.Plans[0]
+	if sort.SharedHitBlocks != 90 || sort.TempReadBlocks != 0 {
+		t.Errorf("Sort buffers = hit %d, temp read %d; want 90, 0 (worker lines must be ignored)", sort.SharedHitBlocks, sort.TempReadBlocks)
+	}
+	if sort.SortMethod != "quicksort" || sort.SortSpaceType != "Memory" || sort.SortSpaceUsed != 3207 {
+		t.Errorf("sort = %q %q %d, want quicksort/Memory/3207", sort.SortMethod, sort.SortSpaceType, sort.SortSpaceUsed)
+	}
+	if len(sort.Plans) != 1 || sort.Plans[0].RelationName != "events" || sort.Plans[0].SharedHitBlocks != 90 {
+		t.Errorf("Sort children = %+v, want the Parallel Seq Scan on events after the worker lines", sort.Plans)
+	}
+	if plans[0].ExecutionTime != 61.530 {
+		t.Errorf("ExecutionTime = %v, want 61.530", plans[0].ExecutionTime)
+	}
+}
+
+func TestParseTextPlan_SubPlanAndCTE(t *testing.T) {
+	input := `CTE Scan on recent r  (cost=10.00..30.00 rows=100 width=8) (actual time=0.100..2.000 rows=100 loops=1)
+  Filter: (SubPlan 2)
+  CTE recent
+    ->  Seq Scan on events  (cost=0.00..10.00 rows=100 width=8) (actual time=0.010..0.500 rows=100 loops=1)
+  SubPlan 2
+    ->  Index Only Scan using idx_tags on tags  (cost=0.15..0.20 rows=1 width=0) (actual time=0.005..0.005 rows=1 loops=100)
+          Index Cond: (event_id = r.id)
+Planning Time: 0.1 ms
+Execution Time: 2.5 ms
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	root := plans[0].Plan
+	if root.CTEName != "recent" || root.Alias != "r" {
+		t.Errorf("CTE scan = name %q alias %q, want recent/r", root.CTEName, root.Alias)
+	}
+	if len(root.Plans) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(root.Plans))
+	}
+
+	cte := root.Plans[0]
+	if cte.SubplanName != "CTE recent" || cte.ParentRelationship != "InitPlan" {
+		t.Errorf("cte = %q/%q, want CTE recent/InitPlan", cte.SubplanName, cte.ParentRelationship)
+	}
+
+	sub := root.Plans[1]
+	if sub.SubplanName != "SubPlan 2" || sub.ParentRelationship != "SubPlan" {
+		t.Errorf("subplan = %q/%q, want SubPlan 2/SubPlan", sub.SubplanName, sub.ParentRelationship)
+	}
+	if sub.IndexCond != "(event_id = r.id)" {
+		t.Errorf("IndexCond = %q, not attached to subplan node", sub.IndexCond)
+	}
+}
+
+func TestParseTextPlan_NeverExecutedAndTimingOff(t *testing.T) {
+	input := `Append  (cost=0.00..50.00 rows=200 width=8) (actual rows=100 loops=1)
+  ->  Seq Scan on part_2024  (cost=0.00..25.00 rows=100 width=8) (actual rows=100 loops=1)
+  ->  Seq Scan on part_2025  (cost=0.00..25.00 rows=100 width=8) (never executed)
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	root := plans[0].Plan
+	if root.ActualRows != 100 || root.ActualLoops != 1 || root.ActualTotalTime != 0 {
+		t.Errorf("root actuals = rows %v loops %d time %v", root.ActualRows, root.ActualLoops, root.ActualTotalTime)
+	}
+	for _, child := range root.Plans {
+		if child.ParentRelationship != "Member" {
+			t.Errorf("%s ParentRelationship = %q, want Member", child.RelationName, child.ParentRelationship)
+		}
+	}
+	skipped := root.Plans[1]
+	if skipped.RelationName != "part_2025" || skipped.ActualLoops != 0 || skipped.TotalCost != 25 {
+		t.Errorf("never executed node = %+v", skipped)
+	}
+}
+
+func TestParseTextPlan_PlanningBuffersNotAttachedToNode(t *testing.T) {
+	input := `Seq Scan on users  (cost=0.00..20.00 rows=1000 width=8) (actual time=0.013..0.108 rows=1000 loops=1)
+  Buffers: shared hit=5
+Planning:
+  Buffers: shared hit=99 read=7
+Planning Time: 0.085 ms
+Trigger for constraint orders_user_fk: time=1.500 calls=10
+Execution Time: 0.523 ms
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := plans[0]
+	if p.Plan.SharedHitBlocks != 5 || p.Plan.SharedReadBlocks != 0 {
+		t.Errorf("node buffers = hit %d read %d, want 5/0", p.Plan.SharedHitBlocks, p.Plan.SharedReadBlocks)
+	}
+	if p.ExecutionTime != 0.523 {
+		t.Errorf("ExecutionTime = %v, want 0.523", p.ExecutionTime)
+	}
+	if len(p.Triggers) != 1 {
+		t.Fatalf("expected 1 trigger, got %d", len(p.Triggers))
+	}
+}
+
+func TestParseTextPlan_MultiplePlans(t *testing.T) {
+	input := `                     QUERY PLAN
+------------------------------------------------------
+ Seq Scan on users  (cost=0.00..20.00 rows=1000 width=8)
+(1 row)
+
+                     QUERY PLAN
+------------------------------------------------------
+ Update on users  (cost=0.00..22.50 rows=0 width=0)
+   ->  Seq Scan on users  (cost=0.00..22.50 rows=1000 width=14)
+(2 rows)
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 plans, got %d", len(plans))
+	}
+	if plans[0].Plan.NodeType != "Seq Scan" {
+		t.Errorf("plan 1 NodeType = %q", plans[0].Plan.NodeType)
+	}
+	update := plans[1].Plan
+	if update.NodeType != "ModifyTable" || update.Operation != "Update" || update.RelationName != "users" {
+		t.Errorf("plan 2 = %q/%q on %q, want ModifyTable/Update on users", update.NodeType, update.Operation, update.RelationName)
+	}
+	if len(update.Plans) != 1 {
+		t.Errorf("plan 2 children = %d, want 1", len(update.Plans))
+	}
+}
+
+func TestParseTextPlan_DiskSort(t *testing.T) {
+	input := `Sort  (cost=1.00..2.00 rows=10 width=8) (actual time=100.000..120.000 rows=1000000 loops=1)
+  Sort Key: created_at DESC
+  Sort Method: external merge  Disk: 1024kB
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	node := plans[0].Plan
+	if node.SortMethod != "external merge" || node.SortSpaceType != "Disk" || node.SortSpaceUsed != 1024 {
+		t.Errorf("sort = %q %q %d, want external merge/Disk/1024", node.SortMethod, node.SortSpaceType, node.SortSpaceUsed)
+	}
+}
+
+func TestParseTextPlan_EmptyInput(t *testing.T) {
+	if _, err := ParseTextPlan([]byte("QUERY PLAN\n----------\n(0 rows)\n")); err == nil {
+		t.Fatal("expected error for input without plan nodes")
+	}
+}
+
+func TestParseTextPlan_ChildBeforeRoot(t *testing.T) {
+	if _, err := ParseTextPlan([]byte("  ->  Seq Scan on users  (cost=0.00..1.00 rows=1 width=4)\n")); err == nil {
+		t.Fatal("expected error for child node without a root")
+	}
+}
+
+func TestParseTextPlan_Settings(t *testing.T) {
+	input := `Seq Scan on users  (cost=0.00..20.00 rows=1000 width=8)
+Settings: work_mem = '64MB', random_page_cost = '1.1', search_path = 'app, public'
+`
+
+	plans, err := ParseTextPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"work_mem": "64MB", "random_page_cost": "1.1", "search_path": "app, public"}
+	if !reflect.DeepEqual(plans[0].Settings, want) {
+		t.Errorf("Settings = %v, want %v", plans[0].Settings, want)
+	}
+}
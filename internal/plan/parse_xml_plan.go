@@ -0,0 +1,169 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// xmlFieldKinds maps each JSON key of ExplainOutput and PlanNode to the kind
+// of its Go field. XML carries every value as text, so leaf elements are
+// converted to the type the JSON decoder expects for that key.
+var xmlFieldKinds = collectFieldKinds(reflect.TypeFor[ExplainOutput](), reflect.TypeFor[PlanNode]())
+
+type xmlElement struct {
+	name     string
+	text     strings.Builder
+	children []*xmlElement
+}
+
+// ParseXMLPlan parses EXPLAIN (FORMAT XML) output. Element names are the
+// JSON key names with spaces replaced by hyphens ("Node-Type"), lists are
+// wrapped in <Plans>/<Item> elements, and each statement is a <Query>
+// (or, as auto_explain logs it, a bare <explain> holding a <Plan>).
+func ParseXMLPlan(data []byte) ([]ExplainOutput, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	root := &xmlElement{}
+	stack := []*xmlElement{root}
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXPLAIN XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &xmlElement{name: t.Name.Local}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, el)
+			stack = append(stack, el)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(t)
+		}
+	}
+
+	var queries []any
+	var collect func(el *xmlElement)
+	collect = func(el *xmlElement) {
+		for _, child := range el.children {
+			// auto_explain omits the <Query> wrapper and puts <Plan>
+			// directly under <explain>.
+			if child.name == "Query" || child.hasChild("Plan") {
+				queries = append(queries, xmlObject(child))
+				continue
+			}
+			collect(child)
+		}
+	}
+	collect(root)
+
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("invalid EXPLAIN XML: no <Query> element found")
+	}
+
+	return decodeStructuredPlans(queries, "XML")
+}
+
+func (el *xmlElement) hasChild(name string) bool {
+	for _, child := range el.children {
+		if child.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func xmlObject(el *xmlElement) map[string]any {
+	obj := make(map[string]any, len(el.children))
+	for _, child := range el.children {
+		key := strings.ReplaceAll(child.name, "-", " ")
+		if value, ok := xmlValue(key, child); ok {
+			obj[key] = value
+		}
+	}
+	return obj
+}
+
+func xmlValue(key string, el *xmlElement) (any, bool) {
+	if len(el.children) == 0 {
+		return xmlScalar(key, strings.TrimSpace(el.text.String()))
+	}
+
+	if key == "Plan" {
+		return xmlObject(el), true
+	}
+
+	// Lists: <Plans><Plan/>...</Plans>, <Sort-Key><Item/>...</Sort-Key>,
+	// <Triggers><Trigger/>...</Triggers> - every child shares one name.
+	if xmlIsList(key, el) {
+		items := make([]any, 0, len(el.children))
+		for _, child := range el.children {
+			if len(child.children) == 0 {
+				items = append(items, strings.TrimSpace(child.text.String()))
+			} else {
+				items = append(items, xmlObject(child))
+			}
+		}
+		return items, true
+	}
+
+	return xmlObject(el), true
+}
+
+func xmlIsList(key string, el *xmlElement) bool {
+	if xmlFieldKinds[key] == reflect.Slice {
+		return true
+	}
+	name := el.children[0].name
+	for _, child := range el.children[1:] {
+		if child.name != name {
+			return false
+		}
+	}
+	return name == "Item" || len(el.children) > 1
+}
+
+func xmlScalar(key, text string) (any, bool) {
+	switch xmlFieldKinds[key] {
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(text, 64)
+		return f, err == nil
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, 64)
+		return n, err == nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		return b, err == nil
+	case reflect.Slice, reflect.Struct, reflect.Map:
+		// An empty container such as <Triggers></Triggers>.
+		return nil, false
+	default:
+		return text, true
+	}
+}
+
+func collectFieldKinds(types ...reflect.Type) map[string]reflect.Kind {
+	kinds := make(map[string]reflect.Kind)
+	for _, t := range types {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			kinds[name] = field.Type.Kind()
+		}
+	}
+	return kinds
+}
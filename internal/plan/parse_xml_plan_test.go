@@ -0,0 +1,220 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseXMLPlan_NestedPlan(t *testing.T) {
+	input := `<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query>
+    <Plan>
+      <Node-Type>Hash Join</Node-Type>
+      <Parallel-Aware>false</Parallel-Aware>
+      <Join-Type>Inner</Join-Type>
+      <Startup-Cost>30.50</Startup-Cost>
+      <Total-Cost>200.00</Total-Cost>
+      <Plan-Rows>5000</Plan-Rows>
+      <Plan-Width>16</Plan-Width>
+      <Actual-Startup-Time>1.000</Actual-Startup-Time>
+      <Actual-Total-Time>25.000</Actual-Total-Time>
+      <Actual-Rows>5000.00</Actual-Rows>
+      <Actual-Loops>1</Actual-Loops>
+      <Inner-Unique>true</Inner-Unique>
+      <Hash-Cond>(o.user_id = u.id)</Hash-Cond>
+      <Plans>
+        <Plan>
+          <Node-Type>Seq Scan</Node-Type>
+          <Parent-Relationship>Outer</Parent-Relationship>
+          <Relation-Name>orders</Relation-Name>
+          <Alias>o</Alias>
+          <Startup-Cost>0.00</Startup-Cost>
+          <Total-Cost>100.00</Total-Cost>
+          <Plan-Rows>5000</Plan-Rows>
+          <Plan-Width>8</Plan-Width>
+          <Actual-Rows>5000</Actual-Rows>
+          <Actual-Loops>1</Actual-Loops>
+        </Plan>
+        <Plan>
+          <Node-Type>Hash</Node-Type>
+          <Parent-Relationship>Inner</Parent-Relationship>
+          <Startup-Cost>20.00</Startup-Cost>
+          <Total-Cost>20.00</Total-Cost>
+          <Plan-Rows>1000</Plan-Rows>
+          <Plan-Width>8</Plan-Width>
+          <Hash-Buckets>1024</Hash-Buckets>
+          <Hash-Batches>4</Hash-Batches>
+          <Original-Hash-Batches>1</Original-Hash-Batches>
+          <Peak-Memory-Usage>4097</Peak-Memory-Usage>
+          <Plans>
+            <Plan>
+              <Node-Type>Sort</Node-Type>
+              <Parent-Relationship>Outer</Parent-Relationship>
+              <Startup-Cost>0.00</Startup-Cost>
+              <Total-Cost>20.00</Total-Cost>
+              <Plan-Rows>1000</Plan-Rows>
+              <Plan-Width>8</Plan-Width>
+              <Sort-Key>
+                <Item>u.id</Item>
+                <Item>u.name</Item>
+              </Sort-Key>
+            </Plan>
+          </Plans>
+        </Plan>
+      </Plans>
+    </Plan>
+    <Planning-Time>0.200</Planning-Time>
+    <Triggers>
+    </Triggers>
+    <Execution-Time>26.100</Execution-Time>
+  </Query>
+</explain>`
+
+	plans, err := ParseXMLPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("expected 1 plan, got %d", len(plans))
+	}
+
+	p := plans[0]
+	if p.PlanningTime != 0.2 || p.ExecutionTime != 26.1 {
+		t.Errorf("times = %v/%v, want 0.2/26.1", p.PlanningTime, p.ExecutionTime)
+	}
+
+	root := p.Plan
+	checks := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"NodeType", root.NodeType, "Hash Join"},
+		{"JoinType", root.JoinType, "Inner"},
+		{"TotalCost", root.TotalCost, 200.0},
+		{"PlanRows", root.PlanRows, int64(5000)},
+		{"ActualRows", root.ActualRows, 5000.0},
+		{"InnerUnique", root.InnerUnique, true},
+		{"HashCond", root.HashCond, "(o.user_id = u.id)"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	if len(root.Plans) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(root.Plans))
+	}
+	if root.Plans[0].Alias != "o" {
+		t.Errorf("Alias = %q, want o", root.Plans[0].Alias)
+	}
+
+	hash := root.Plans[1]
+	if hash.HashBatches != 4 || hash.OriginalHashBatches != 1 || hash.PeakMemoryUsage != 4097 {
+		t.Errorf("hash = %d/%d/%d", hash.HashBatches, hash.OriginalHashBatches, hash.PeakMemoryUsage)
+	}
+
+	// A single-child <Plans> must still decode as a list.
+	if len(hash.Plans) != 1 {
+		t.Fatalf("expected 1 hash child, got %d", len(hash.Plans))
+	}
+	if want := []string{"u.id", "u.name"}; !reflect.DeepEqual(hash.Plans[0].SortKey, want) {
+		t.Errorf("SortKey = %v, want %v", hash.Plans[0].SortKey, want)
+	}
+}
+
+func TestParseXMLPlan_SingleItemList(t *testing.T) {
+	input := `<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query>
+    <Plan>
+      <Node-Type>Aggregate</Node-Type>
+      <Strategy>Hashed</Strategy>
+      <Startup-Cost>1.00</Startup-Cost>
+      <Total-Cost>2.00</Total-Cost>
+      <Plan-Rows>10</Plan-Rows>
+      <Plan-Width>4</Plan-Width>
+      <Group-Key>
+        <Item>region</Item>
+      </Group-Key>
+    </Plan>
+  </Query>
+</explain>`
+
+	plans, err := ParseXMLPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(plans[0].Plan.GroupKey, []string{"region"}) {
+		t.Errorf("GroupKey = %v, want [region]", plans[0].Plan.GroupKey)
+	}
+}
+
+func TestParseXMLPlan_MultipleQueries(t *testing.T) {
+	input := `<explain><Query><Plan><Node-Type>Result</Node-Type><Total-Cost>0.01</Total-Cost></Plan></Query></explain>
+<explain><Query><Plan><Node-Type>Seq Scan</Node-Type><Relation-Name>users</Relation-Name></Plan></Query></explain>`
+
+	plans, err := ParseXMLPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 plans, got %d", len(plans))
+	}
+	if plans[1].Plan.RelationName != "users" {
+		t.Errorf("plan 2 RelationName = %q, want users", plans[1].Plan.RelationName)
+	}
+}
+
+func TestParseXMLPlan_AutoExplainWithoutQuery(t *testing.T) {
+	input := `<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query-Text>SELECT 1</Query-Text>
+  <Plan>
+    <Node-Type>Result</Node-Type>
+    <Total-Cost>0.01</Total-Cost>
+  </Plan>
+</explain>`
+
+	plans, err := ParseXMLPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Plan.NodeType != "Result" || plans[0].QueryText != "SELECT 1" {
+		t.Errorf("got %+v, want one Result plan with its query text", plans)
+	}
+}
+
+func TestParseXMLPlan_NoQuery(t *testing.T) {
+	if _, err := ParseXMLPlan([]byte(`<explain></explain>`)); err == nil {
+		t.Fatal("expected error for XML without a <Query> element")
+	}
+}
+
+func TestParseXMLPlan_Malformed(t *testing.T) {
+	if _, err := ParseXMLPlan([]byte(`<explain><Query><Plan>`)); err == nil {
+		t.Fatal("expected error for truncated XML")
+	}
+}
+
+func TestParseXMLPlan_Settings(t *testing.T) {
+	input := `<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query>
+    <Plan>
+      <Node-Type>Result</Node-Type>
+      <Total-Cost>0.01</Total-Cost>
+    </Plan>
+    <Settings>
+      <work_mem>64MB</work_mem>
+      <jit>off</jit>
+    </Settings>
+  </Query>
+</explain>`
+
+	plans, err := ParseXMLPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := plans[0].Settings; got["work_mem"] != "64MB" || got["jit"] != "off" || len(got) != 2 {
+		t.Errorf("Settings = %v, want work_mem and jit", got)
+	}
+}
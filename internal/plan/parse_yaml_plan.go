@@ -0,0 +1,46 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseYAMLPlan parses EXPLAIN (FORMAT YAML) output. PostgreSQL uses the same
+// key names in every structured format, so the document is re-encoded as
+// JSON and decoded through ExplainOutput's JSON struct tags. A bare mapping,
+// as auto_explain logs it, is accepted as a single plan.
+func ParseYAMLPlan(data []byte) ([]ExplainOutput, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid EXPLAIN YAML: %w", err)
+	}
+	switch d := doc.(type) {
+	case []any:
+	case map[string]any:
+		doc = []any{d}
+	default:
+		return nil, fmt.Errorf("invalid EXPLAIN YAML: expected a list of plans")
+	}
+
+	return decodeStructuredPlans(doc, "YAML")
+}
+
+// decodeStructuredPlans converts a generic document (lists, string-keyed
+// maps and scalars) with PostgreSQL's JSON key names into ExplainOutputs.
+func decodeStructuredPlans(doc any, format string) ([]ExplainOutput, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EXPLAIN %s: %w", format, err)
+	}
+
+	var plans []ExplainOutput
+	if err := json.Unmarshal(data, &plans); err != nil {
+		return nil, fmt.Errorf("invalid EXPLAIN %s: %w", format, err)
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("empty EXPLAIN output")
+	}
+	return plans, nil
+}
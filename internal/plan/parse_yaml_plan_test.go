@@ -0,0 +1,123 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAMLPlan_NestedPlan(t *testing.T) {
+	input := `- Plan: 
+    Node Type: "Sort"
+    Parallel Aware: false
+    Startup Cost: 69.83
+    Total Cost: 72.33
+    Plan Rows: 1000
+    Plan Width: 8
+    Actual Startup Time: 0.456
+    Actual Total Time: 0.478
+    Actual Rows: 1000
+    Actual Loops: 1
+    Sort Key: 
+      - "id"
+    Sort Method: "external merge"
+    Sort Space Used: 1024
+    Sort Space Type: "Disk"
+    Shared Hit Blocks: 5
+    Shared Read Blocks: 10
+    Plans: 
+      - Node Type: "Seq Scan"
+        Parent Relationship: "Outer"
+        Parallel Aware: false
+        Relation Name: "users"
+        Alias: "users"
+        Startup Cost: 0.00
+        Total Cost: 20.00
+        Plan Rows: 1000
+        Plan Width: 8
+        Actual Startup Time: 0.013
+        Actual Total Time: 0.108
+        Actual Rows: 1000
+        Actual Loops: 1
+        Filter: "(active = true)"
+        Rows Removed by Filter: 500
+  Planning Time: 0.085
+  Triggers: 
+  Execution Time: 0.523
+`
+
+	plans, err := ParseYAMLPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("expected 1 plan, got %d", len(plans))
+	}
+
+	p := plans[0]
+	if p.PlanningTime != 0.085 || p.ExecutionTime != 0.523 {
+		t.Errorf("times = %v/%v, want 0.085/0.523", p.PlanningTime, p.ExecutionTime)
+	}
+
+	root := p.Plan
+	if root.NodeType != "Sort" || root.SortSpaceType != "Disk" || root.SortSpaceUsed != 1024 {
+		t.Errorf("root = %q %q %d", root.NodeType, root.SortSpaceType, root.SortSpaceUsed)
+	}
+	if !reflect.DeepEqual(root.SortKey, []string{"id"}) {
+		t.Errorf("SortKey = %v, want [id]", root.SortKey)
+	}
+	if root.SharedReadBlocks != 10 {
+		t.Errorf("SharedReadBlocks = %d, want 10", root.SharedReadBlocks)
+	}
+
+	if len(root.Plans) != 1 {
+		t.Fatalf("expected 1 child, got %d", len(root.Plans))
+	}
+	child := root.Plans[0]
+	if child.RelationName != "users" || child.Filter != "(active = true)" || child.RowsRemovedByFilter != 500 {
+		t.Errorf("child = %q %q %d", child.RelationName, child.Filter, child.RowsRemovedByFilter)
+	}
+}
+
+func TestParseYAMLPlan_NumericLookingString(t *testing.T) {
+	// PostgreSQL quotes every string value, so an alias that looks like a
+	// number must still land in the string field.
+	input := `- Plan: 
+    Node Type: "Subquery Scan"
+    Alias: "2024"
+    Startup Cost: 0.00
+    Total Cost: 1.00
+    Plan Rows: 1
+    Plan Width: 4
+`
+
+	plans, err := ParseYAMLPlan([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plans[0].Plan.Alias != "2024" {
+		t.Errorf("Alias = %q, want 2024", plans[0].Plan.Alias)
+	}
+}
+
+// auto_explain logs FORMAT YAML plans as a bare mapping rather than a list.
+func TestParseYAMLPlan_BareMapping(t *testing.T) {
+	plans, err := ParseYAMLPlan([]byte("Query Text: \"SELECT 1\"\nPlan:\n  Node Type: \"Result\"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Plan.NodeType != "Result" || plans[0].QueryText != "SELECT 1" {
+		t.Errorf("got %+v, want one Result plan with its query text", plans)
+	}
+}
+
+func TestParseYAMLPlan_NotAList(t *testing.T) {
+	if _, err := ParseYAMLPlan([]byte("just a string\n")); err == nil {
+		t.Fatal("expected error for YAML that is not a list of plans")
+	}
+}
+
+func TestParseYAMLPlan_Invalid(t *testing.T) {
+	if _, err := ParseYAMLPlan([]byte("- Plan: [unclosed")); err == nil {
+		t.Fatal("expected error for invalid YAML")
+	}
+}
@@ -0,0 +1,138 @@
+package plan
+
+import (
+	"encoding/csv"
+	"regexp"
+	"strings"
+)
+
+var (
+	psqlRecordRe       = regexp.MustCompile(`^-\[ RECORD \d+ \]-*$`)
+	psqlExpandedHeadRe = regexp.MustCompile(`^QUERY PLAN\s*\| ?(.*)$`)
+	psqlExpandedContRe = regexp.MustCompile(`^\s*\| ?(.*)$`)
+	psqlRowCountRe     = regexp.MustCompile(`^\(\d+ rows?\)$`)
+)
+
+// stripPsqlDecorations removes the table framing that client tools add around
+// EXPLAIN output when it is copied out of a result grid, returning the bare
+// plan text. Recognized layouts are:
+//
+//   - psql aligned output: a "QUERY PLAN" header, a dashed rule, a leading
+//     space on every row, "+" wrap markers at line ends, and a "(N rows)"
+//     footer
+//   - psql expanded output (\x): "-[ RECORD n ]-" separators and a
+//     "QUERY PLAN | " column prefix
+//   - pgAdmin/DBeaver "copy with headers": a "QUERY PLAN" header followed by
+//     the value as-is or as a CSV-quoted field
+//
+// Input without a QUERY PLAN header is returned unchanged, so SQL and plain
+// plans never go through these heuristics.
+func stripPsqlDecorations(data []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	first := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return data
+	}
+
+	switch header := strings.TrimSpace(lines[first]); {
+	case psqlRecordRe.MatchString(header):
+		return []byte(stripExpanded(lines[first:]))
+	case header == `"QUERY PLAN"`:
+		return []byte(stripCSV(lines[first+1:]))
+	case header == "QUERY PLAN":
+		return []byte(stripAligned(lines[first+1:]))
+	}
+	return data
+}
+
+func stripAligned(lines []string) string {
+	var out []string
+	for _, line := range lines {
+		if isPsqlDecoration(strings.TrimSpace(line)) {
+			continue
+		}
+		out = append(out, trimWrapMarker(line))
+	}
+
+	// psql pads every aligned row with one leading space; drop it so text
+	// plans keep their relative indentation starting at column 0.
+	padded := true
+	for _, line := range out {
+		if line != "" && !strings.HasPrefix(line, " ") {
+			padded = false
+			break
+		}
+	}
+	if padded {
+		for i, line := range out {
+			out[i] = strings.TrimPrefix(line, " ")
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func stripExpanded(lines []string) string {
+	var out []string
+	for _, line := range lines {
+		if psqlRecordRe.MatchString(strings.TrimSpace(line)) {
+			continue
+		}
+		if m := psqlExpandedHeadRe.FindStringSubmatch(line); m != nil {
+			out = append(out, trimWrapMarker(m[1]))
+			continue
+		}
+		if m := psqlExpandedContRe.FindStringSubmatch(line); m != nil {
+			out = append(out, trimWrapMarker(m[1]))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// stripCSV unquotes a CSV-quoted QUERY PLAN column, as pgAdmin copies it:
+// one quoted field per row, with embedded quotes doubled.
+func stripCSV(lines []string) string {
+	r := csv.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return strings.Join(lines, "\n")
+	}
+
+	var out []string
+	for _, record := range records {
+		if len(record) > 0 {
+			out = append(out, record[0])
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// trimWrapMarker removes the "+" psql appends to a row that continues on the
+// next line, along with the padding in front of it.
+func trimWrapMarker(line string) string {
+	trimmed := strings.TrimRight(line, " ")
+	if rest, ok := strings.CutSuffix(trimmed, "+"); ok {
+		return strings.TrimRight(rest, " ")
+	}
+	return trimmed
+}
+
+// isPsqlDecoration reports whether line is part of psql's table framing
+// rather than the plan itself: the "QUERY PLAN" header, the dashed rule
+// under it, and the "(N rows)" footer.
+func isPsqlDecoration(line string) bool {
+	if line == "QUERY PLAN" || psqlRowCountRe.MatchString(line) {
+		return true
+	}
+	return line != "" && strings.Trim(line, "-+") == ""
+}
@@ -0,0 +1,139 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripPsqlDecorations_AlignedJSON(t *testing.T) {
+	input := `                 QUERY PLAN
+---------------------------------------------
+ [                                          +
+   {                                        +
+     "Plan": {                              +
+       "Node Type": "Seq Scan",             +
+       "Relation Name": "users",            +
+       "Total Cost": 20.00                  +
+     },                                     +
+     "Execution Time": 0.523                +
+   }                                        +
+ ]
+(1 row)
+`
+
+	got := string(stripPsqlDecorations([]byte(input)))
+
+	plans, err := ParseJSONPlan([]byte(got))
+	if err != nil {
+		t.Fatalf("stripped output does not parse: %v\n%s", err, got)
+	}
+	if plans[0].Plan.RelationName != "users" || plans[0].ExecutionTime != 0.523 {
+		t.Errorf("got %q/%v, want users/0.523", plans[0].Plan.RelationName, plans[0].ExecutionTime)
+	}
+	if strings.Contains(got, "+") || strings.Contains(got, "QUERY PLAN") || strings.Contains(got, "(1 row)") {
+		t.Errorf("decorations left in output:\n%s", got)
+	}
+}
+
+func TestStripPsqlDecorations_AlignedTextKeepsIndentation(t *testing.T) {
+	input := `                          QUERY PLAN
+----------------------------------------------------------------
+ Sort  (cost=69.83..72.33 rows=1000 width=8)
+   Sort Key: id
+   ->  Seq Scan on users  (cost=0.00..20.00 rows=1000 width=8)
+(3 rows)
+`
+
+	got := string(stripPsqlDecorations([]byte(input)))
+	want := "Sort  (cost=69.83..72.33 rows=1000 width=8)\n  Sort Key: id\n  ->  Seq Scan on users  (cost=0.00..20.00 rows=1000 width=8)"
+	if strings.TrimSpace(got) != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestStripPsqlDecorations_Expanded(t *testing.T) {
+	input := `-[ RECORD 1 ]--------------------------------
+QUERY PLAN | [                              +
+           |   {                            +
+           |     "Plan": {                  +
+           |       "Node Type": "Seq Scan"  +
+           |     }                          +
+           |   }                            +
+           | ]
+`
+
+	plans, err := ParseJSONPlan(stripPsqlDecorations([]byte(input)))
+	if err != nil {
+		t.Fatalf("stripped output does not parse: %v", err)
+	}
+	if plans[0].Plan.NodeType != "Seq Scan" {
+		t.Errorf("NodeType = %q, want Seq Scan", plans[0].Plan.NodeType)
+	}
+}
+
+func TestStripPsqlDecorations_ExpandedText(t *testing.T) {
+	input := `-[ RECORD 1 ]------------------------------------------------------
+QUERY PLAN | Hash Join  (cost=1.00..2.00 rows=1 width=8)
+-[ RECORD 2 ]------------------------------------------------------
+QUERY PLAN |   Hash Cond: (a.id = b.id)
+-[ RECORD 3 ]------------------------------------------------------
+QUERY PLAN |   ->  Seq Scan on a  (cost=0.00..1.00 rows=1 width=4)
+`
+
+	plans, err := ParseTextPlan(stripPsqlDecorations([]byte(input)))
+	if err != nil {
+		t.Fatalf("stripped output does not parse: %v", err)
+	}
+	root := plans[0].Plan
+	if root.HashCond != "(a.id = b.id)" || len(root.Plans) != 1 {
+		t.Errorf("got HashCond %q with %d children", root.HashCond, len(root.Plans))
+	}
+}
+
+func TestStripPsqlDecorations_PgAdminCSV(t *testing.T) {
+	input := "\"QUERY PLAN\"\n\"[\n  {\n    \"\"Plan\"\": {\n      \"\"Node Type\"\": \"\"Index Scan\"\"\n    }\n  }\n]\"\n"
+
+	plans, err := ParseJSONPlan(stripPsqlDecorations([]byte(input)))
+	if err != nil {
+		t.Fatalf("stripped output does not parse: %v", err)
+	}
+	if plans[0].Plan.NodeType != "Index Scan" {
+		t.Errorf("NodeType = %q, want Index Scan", plans[0].Plan.NodeType)
+	}
+}
+
+func TestStripPsqlDecorations_PgAdminCSVText(t *testing.T) {
+	input := "\"QUERY PLAN\"\n\"Limit  (cost=0.00..1.00 rows=1 width=4)\"\n\"  ->  Seq Scan on users  (cost=0.00..10.00 rows=10 width=4)\"\n"
+
+	plans, err := ParseTextPlan(stripPsqlDecorations([]byte(input)))
+	if err != nil {
+		t.Fatalf("stripped output does not parse: %v", err)
+	}
+	if len(plans[0].Plan.Plans) != 1 {
+		t.Errorf("expected Seq Scan child under Limit, got %+v", plans[0].Plan)
+	}
+}
+
+func TestStripPsqlDecorations_DBeaverHeader(t *testing.T) {
+	input := "QUERY PLAN\n[{\"Plan\": {\"Node Type\": \"Result\"}}]\n"
+
+	plans, err := ParseJSONPlan(stripPsqlDecorations([]byte(input)))
+	if err != nil {
+		t.Fatalf("stripped output does not parse: %v", err)
+	}
+	if plans[0].Plan.NodeType != "Result" {
+		t.Errorf("NodeType = %q, want Result", plans[0].Plan.NodeType)
+	}
+}
+
+func TestStripPsqlDecorations_UndecoratedUnchanged(t *testing.T) {
+	for _, input := range []string{
+		"SELECT a + b\nFROM t\n",
+		`[{"Plan": {"Node Type": "Seq Scan"}}]`,
+		"",
+	} {
+		if got := string(stripPsqlDecorations([]byte(input))); got != input {
+			t.Errorf("stripPsqlDecorations(%q) = %q, want input unchanged", input, got)
+		}
+	}
+}
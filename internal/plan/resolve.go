@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,78 +10,164 @@ import (
 	"strings"
 )
 
-func Resolve(input, dbConn, label string) (ExplainOutput, error) {
-	data, err := readInput(input, label)
+// Resolve returns the first plan in input. See ResolveAll.
+func Resolve(ctx context.Context, input, dbConn, label string, opts ExecOptions) (ExplainOutput, error) {
+	plans, err := ResolveAll(ctx, input, dbConn, label, opts)
 	if err != nil {
 		return ExplainOutput{}, err
 	}
+	return plans[0], nil
+}
+
+// ResolveAll reads input (a file path, "-" for stdin, or "" for interactive
+// mode) and returns every plan it contains: each plan of a multi-plan
+// EXPLAIN document, or one plan per statement of a SQL script. opts controls
+// how SQL input is executed: see Execute. Canceling ctx stops reading stdin
+// and any query running for SQL input.
+func ResolveAll(ctx context.Context, input, dbConn, label string, opts ExecOptions) ([]ExplainOutput, error) {
+	data, err := readInput(ctx, input, label)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveData(ctx, data, input, dbConn, label, opts)
+}
+
+// ResolveData is ResolveAll for input already read, such as a file as it was
+// at an earlier git revision. name is the file it came from, if any: its
+// extension helps tell the input's type.
+func ResolveData(ctx context.Context, data []byte, name, dbConn, label string, opts ExecOptions) ([]ExplainOutput, error) {
+	data = stripPsqlDecorations(data)
 
 	var plans []ExplainOutput
+	var err error
 
-	switch inputType := detectType(data, input); inputType {
+	switch inputType := detectType(data, name); inputType {
 	case "json":
 		plans, err = ParseJSONPlan(data)
 	case "sql":
 
-		if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(strings.ToUpper(trimmed), "EXPLAIN") {
-			return ExplainOutput{}, fmt.Errorf("input should not include EXPLAIN prefix - provide the raw query only")
+		explainable := false
+		for _, stmt := range SplitStatements(string(data)) {
+			if firstKeyword(stmt) == "EXPLAIN" {
+				return nil, fmt.Errorf("input should not include EXPLAIN prefix - provide the raw query only")
+			}
+			explainable = explainable || isExplainable(stmt)
+		}
+		if !explainable {
+			return nil, fmt.Errorf("no statement to EXPLAIN in %sinput", label)
 		}
 
 		if dbConn == "" {
-			return ExplainOutput{}, fmt.Errorf("SQL input requires a database connection")
+			return nil, fmt.Errorf("SQL input requires a database connection")
 		}
-		plans, err = Execute(dbConn, string(data))
+		plans, err = Execute(ctx, dbConn, string(data), opts)
 	case "text":
-		return ExplainOutput{}, fmt.Errorf(`text format not supported - use JSON format:
-
-EXPLAIN (ANALYZE, VERBOSE, BUFFERS, FORMAT JSON) <your query>
-
-then provide the complete JSON output`)
+		plans, err = ParseTextPlan(data)
+	case "yaml":
+		plans, err = ParseYAMLPlan(data)
+	case "xml":
+		plans, err = ParseXMLPlan(data)
 	default:
-		return ExplainOutput{}, fmt.Errorf("unable to detect %sinput type: expected JSON plan, SQL query, or .json/.sql file", label)
+		return nil, fmt.Errorf("unable to detect %sinput type: expected JSON, YAML, XML or text plan, SQL query, or .json/.yaml/.xml/.txt/.sql file", label)
 	}
 
 	if err != nil {
-		return ExplainOutput{}, err
+		return nil, err
 	}
 	if len(plans) == 0 {
-		return ExplainOutput{}, fmt.Errorf("no query plan found in %sinput", label)
+		return nil, fmt.Errorf("no query plan found in %sinput", label)
 	}
-	return plans[0], nil
+	return plans, nil
 }
 
-func readInput(input, label string) ([]byte, error) {
+// ResolveRuns resolves several inputs holding runs of the same statements,
+// such as plan files captured at different times, and aggregates each
+// statement's runs across them: see Aggregate. An input that is itself a
+// benchmark contributes each of its runs. Every input must hold the same
+// number of plans.
+func ResolveRuns(ctx context.Context, inputs []string, dbConn, label string, opts ExecOptions) ([]ExplainOutput, error) {
+	var runs [][]ExplainOutput
+	for _, input := range inputs {
+		plans, err := ResolveAll(ctx, input, dbConn, label, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", input, err)
+		}
+		if runs == nil {
+			runs = make([][]ExplainOutput, len(plans))
+		}
+		if len(plans) != len(runs) {
+			return nil, fmt.Errorf("%s has %d plan(s), but %s has %d", input, len(plans), inputs[0], len(runs))
+		}
+		for i, p := range plans {
+			if p.Benchmark != nil {
+				runs[i] = append(runs[i], p.Benchmark.Plans...)
+			} else {
+				runs[i] = append(runs[i], p)
+			}
+		}
+	}
+
+	aggregated := make([]ExplainOutput, len(runs))
+	for i := range runs {
+		aggregated[i] = Aggregate(runs[i])
+	}
+	return aggregated, nil
+}
+
+func readInput(ctx context.Context, input, label string) ([]byte, error) {
 	switch input {
 	case "":
-		return readInteractive(label)
+		return readInteractive(ctx, label)
 	case "-":
-		return io.ReadAll(os.Stdin)
+		return readStdin(ctx)
 	default:
 		return os.ReadFile(input)
 	}
 }
 
-func readInteractive(label string) ([]byte, error) {
-	fmt.Printf("Paste %sEXPLAIN (ANALYZE, VERBOSE, BUFFERS, FORMAT JSON) output or SQL query", label)
+func readInteractive(ctx context.Context, label string) ([]byte, error) {
+	fmt.Printf("Paste %sEXPLAIN (ANALYZE, VERBOSE, BUFFERS) output (JSON, YAML, XML or text format) or SQL query", label)
 	if runtime.GOOS == "windows" {
 		fmt.Print(" (Ctrl+Z, Enter to submit)\n")
 	} else {
 		fmt.Print(" (Ctrl+D to submit)\n")
 	}
 
-	data, err := io.ReadAll(os.Stdin)
+	data, err := readStdin(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if trimmed := strings.TrimSpace(string(data)); (strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{")) &&
-		!json.Valid(data) {
+	stripped := stripPsqlDecorations(data)
+	if trimmed := strings.TrimSpace(string(stripped)); (strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{")) &&
+		!json.Valid(stripped) {
 		return nil, fmt.Errorf("input appears truncated; for large inputs use: pgplan analyze <file>")
 	}
 
 	return data, nil
 }
 
+// readStdin reads all of stdin, giving up when ctx is canceled. The read
+// itself can't be interrupted, so it is left to finish in the background.
+func readStdin(ctx context.Context) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := io.ReadAll(os.Stdin)
+		done <- result{data, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w while reading input", ErrInterrupted)
+	}
+}
+
 func detectType(data []byte, filename string) string {
 	if strings.HasSuffix(filename, ".json") {
 		return "json"
@@ -91,6 +178,12 @@ func detectType(data []byte, filename string) string {
 	if strings.HasSuffix(filename, ".txt") {
 		return "text"
 	}
+	if strings.HasSuffix(filename, ".yaml") || strings.HasSuffix(filename, ".yml") {
+		return "yaml"
+	}
+	if strings.HasSuffix(filename, ".xml") {
+		return "xml"
+	}
 
 	trimmed := strings.TrimSpace(string(data))
 
@@ -98,16 +191,20 @@ func detectType(data []byte, filename string) string {
 		return "json"
 	}
 
+	if strings.HasPrefix(trimmed, "<") {
+		return "xml"
+	}
+
+	if strings.HasPrefix(trimmed, "- Plan:") {
+		return "yaml"
+	}
+
 	if strings.Contains(trimmed, "(cost=") {
 		return "text"
 	}
 
-	if strings.HasPrefix(strings.ToUpper(trimmed), "SELECT") ||
-		strings.HasPrefix(strings.ToUpper(trimmed), "WITH") ||
-		strings.HasPrefix(strings.ToUpper(trimmed), "INSERT") ||
-		strings.HasPrefix(strings.ToUpper(trimmed), "UPDATE") ||
-		strings.HasPrefix(strings.ToUpper(trimmed), "DELETE") ||
-		strings.HasPrefix(strings.ToUpper(trimmed), "EXPLAIN") {
+	switch firstKeyword(trimmed) {
+	case "SELECT", "WITH", "INSERT", "UPDATE", "DELETE", "MERGE", "VALUES", "EXPLAIN":
 		return "sql"
 	}
 
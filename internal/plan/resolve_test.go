@@ -1,6 +1,8 @@
 package plan
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -27,6 +29,38 @@ func TestDetectType_TxtExtension(t *testing.T) {
 	}
 }
 
+func TestDetectType_YAMLExtension(t *testing.T) {
+
+	for _, name := range []string{"plan.yaml", "plan.yml"} {
+		if result := detectType([]byte("anything"), name); result != "yaml" {
+			t.Errorf("%s: got %q, want yaml", name, result)
+		}
+	}
+}
+
+func TestDetectType_XMLExtension(t *testing.T) {
+
+	if result := detectType([]byte("anything"), "plan.xml"); result != "xml" {
+		t.Errorf("got %q, want xml", result)
+	}
+}
+
+func TestDetectType_YAMLContent(t *testing.T) {
+	data := []byte("- Plan: \n    Node Type: \"Seq Scan\"\n")
+
+	if result := detectType(data, ""); result != "yaml" {
+		t.Errorf("got %q, want yaml", result)
+	}
+}
+
+func TestDetectType_XMLContent(t *testing.T) {
+	data := []byte(`<explain xmlns="http://www.postgresql.org/2009/explain"><Query/></explain>`)
+
+	if result := detectType(data, ""); result != "xml" {
+		t.Errorf("got %q, want xml", result)
+	}
+}
+
 func TestDetectType_JSONContent(t *testing.T) {
 	data := []byte(`[{"Plan": {"Node Type": "Seq Scan"}}]`)
 
@@ -75,7 +109,7 @@ func TestReadInput_File(t *testing.T) {
 		t.Fatalf("WriteFile failed: %v", err)
 	}
 
-	data, err := readInput(path, "")
+	data, err := readInput(context.Background(), path, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -86,7 +120,7 @@ func TestReadInput_File(t *testing.T) {
 
 func TestReadInput_MissingFile(t *testing.T) {
 
-	if _, err := readInput("/nonexistent/file.json", ""); err == nil {
+	if _, err := readInput(context.Background(), "/nonexistent/file.json", ""); err == nil {
 		t.Fatal("expected error for missing file")
 	}
 }
@@ -114,7 +148,50 @@ func TestResolve_JSONFile(t *testing.T) {
 		t.Fatalf("WriteFile failed: %v", err)
 	}
 
-	plan, err := Resolve(path, "", "")
+	plan, err := Resolve(context.Background(), path, "", "", ExecOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Plan.NodeType != "Seq Scan" {
+		t.Errorf("NodeType = %q, want Seq Scan", plan.Plan.NodeType)
+	}
+}
+
+func TestResolve_TextFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "plan.txt")
+	content := []byte(`Seq Scan on users  (cost=0.00..20.00 rows=100 width=8) (actual time=0.010..0.100 rows=100 loops=1)
+Planning Time: 0.1 ms
+Execution Time: 0.2 ms
+`)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	plan, err := Resolve(context.Background(), path, "", "", ExecOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Plan.NodeType != "Seq Scan" || plan.ExecutionTime != 0.2 {
+		t.Errorf("got %q/%v, want Seq Scan/0.2", plan.Plan.NodeType, plan.ExecutionTime)
+	}
+}
+
+func TestResolve_PsqlWrappedJSONFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "plan.json")
+	content := []byte(` QUERY PLAN
+------------
+ {                                    +
+   "Plan": {"Node Type": "Seq Scan"}  +
+ }
+(1 row)
+`)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	plan, err := Resolve(context.Background(), path, "", "", ExecOptions{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -130,7 +207,7 @@ func TestResolve_SQLFileWithoutDB(t *testing.T) {
 		t.Fatalf("WriteFile failed: %v", err)
 	}
 
-	if _, err := Resolve(path, "", ""); err == nil {
+	if _, err := Resolve(context.Background(), path, "", "", ExecOptions{}); err == nil {
 		t.Fatal("expected error for SQL input without DB connection")
 	}
 }
@@ -142,7 +219,7 @@ func TestResolve_InvalidJSON(t *testing.T) {
 		t.Fatalf("WriteFile failed: %v", err)
 	}
 
-	if _, err := Resolve(path, "", ""); err == nil {
+	if _, err := Resolve(context.Background(), path, "", "", ExecOptions{}); err == nil {
 		t.Fatal("expected error for invalid JSON")
 	}
 }
@@ -154,7 +231,7 @@ func TestResolve_EmptyJSONArray(t *testing.T) {
 		t.Fatalf("WriteFile failed: %v", err)
 	}
 
-	if _, err := Resolve(path, "", ""); err == nil {
+	if _, err := Resolve(context.Background(), path, "", "", ExecOptions{}); err == nil {
 		t.Fatal("expected error for empty JSON array")
 	}
 }
@@ -166,7 +243,113 @@ func TestResolve_TruncatedJSON(t *testing.T) {
 		t.Fatalf("WriteFile failed: %v", err)
 	}
 
-	if _, err := Resolve(path, "", ""); err == nil {
+	if _, err := Resolve(context.Background(), path, "", "", ExecOptions{}); err == nil {
 		t.Fatal("expected error for truncated JSON")
 	}
 }
+
+func TestResolveAll_MultiplePlans(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "plans.json")
+	content := []byte(`[
+		{"Query Text": "SELECT * FROM users", "Plan": {"Node Type": "Seq Scan", "Relation Name": "users", "Total Cost": 20.0}},
+		{"Query Text": "SELECT * FROM orders", "Plan": {"Node Type": "Seq Scan", "Relation Name": "orders", "Total Cost": 40.0}}
+	]`)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	plans, err := ResolveAll(context.Background(), path, "", "", ExecOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("got %d plans, want 2", len(plans))
+	}
+	if plans[1].Plan.RelationName != "orders" {
+		t.Errorf("plans[1] RelationName = %q, want orders", plans[1].Plan.RelationName)
+	}
+	if plans[1].QueryText != "SELECT * FROM orders" {
+		t.Errorf("plans[1] QueryText = %q, want %q", plans[1].QueryText, "SELECT * FROM orders")
+	}
+
+	first, err := Resolve(context.Background(), path, "", "", ExecOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Plan.RelationName != "users" {
+		t.Errorf("Resolve RelationName = %q, want users (the first plan)", first.Plan.RelationName)
+	}
+}
+
+func TestResolve_SQLFileWithOnlyUtilityStatements(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "setup.sql")
+	if err := os.WriteFile(path, []byte("SET work_mem = '64MB';"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if _, err := ResolveAll(context.Background(), path, "postgres://unused", "", ExecOptions{}); err == nil {
+		t.Fatal("expected error for SQL input with nothing to explain")
+	}
+}
+
+func TestResolveRuns_AggregatesFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	var paths []string
+	for i, ms := range []string{"3.0", "1.0", "2.0"} {
+		path := filepath.Join(tmpDir, fmt.Sprintf("run%d.txt", i))
+		content := "Seq Scan on users  (cost=0.00..20.00 rows=100 width=8) (actual time=0.010.." + ms + " rows=100 loops=1)\nExecution Time: " + ms + " ms\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	plans, err := ResolveRuns(context.Background(), paths, "", "", ExecOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("got %d plans, want 1", len(plans))
+	}
+	if plans[0].ExecutionTime != 2 {
+		t.Errorf("ExecutionTime = %v, want the median, 2", plans[0].ExecutionTime)
+	}
+	if b := plans[0].Benchmark; b == nil || b.Runs != 3 {
+		t.Errorf("Benchmark = %+v, want 3 runs", b)
+	}
+}
+
+func TestResolveRuns_MismatchedStatementCounts(t *testing.T) {
+	tmpDir := t.TempDir()
+	one := filepath.Join(tmpDir, "one.txt")
+	two := filepath.Join(tmpDir, "two.json")
+	if err := os.WriteFile(one, []byte("Seq Scan on users  (cost=0.00..20.00 rows=100 width=8)\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.WriteFile(two, []byte(`[{"Plan": {"Node Type": "Result"}}, {"Plan": {"Node Type": "Result"}}]`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if _, err := ResolveRuns(context.Background(), []string{one, two}, "", "", ExecOptions{}); err == nil {
+		t.Fatal("expected error for inputs with different numbers of plans")
+	}
+}
+
+func TestResolveData_UsesNameForType(t *testing.T) {
+	data := []byte(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "orders", "Total Cost": 10}}]`)
+
+	plans, err := ResolveData(context.Background(), data, "queries/orders.json", "", "", ExecOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Plan.RelationName != "orders" {
+		t.Errorf("plans = %+v", plans)
+	}
+
+	_, err = ResolveData(context.Background(), []byte("SELECT 1"), "queries/one.sql", "", "old ", ExecOptions{})
+	if err == nil || err.Error() != "SQL input requires a database connection" {
+		t.Errorf("err = %v, want a connection error for SQL", err)
+	}
+}
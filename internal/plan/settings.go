@@ -0,0 +1,76 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var settingNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(?:\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// Setting is a configuration parameter to set for the transaction EXPLAIN
+// runs in, such as work_mem, enable_seqscan or role.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// ParseSetting parses "name=value". The value is used as-is, without SQL
+// quoting: work_mem=64MB, search_path=app,public.
+func ParseSetting(s string) (Setting, error) {
+	name, value, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return Setting{}, fmt.Errorf("invalid setting %q: expected name=value", s)
+	}
+	if !settingNameRe.MatchString(name) {
+		return Setting{}, fmt.Errorf("invalid setting name %q", name)
+	}
+	return Setting{Name: strings.ToLower(name), Value: strings.TrimSpace(value)}, nil
+}
+
+// applySettings sets each setting for the rest of tx, as SET LOCAL would.
+func applySettings(ctx context.Context, tx pgx.Tx, settings []Setting) error {
+	for _, s := range settings {
+		if _, err := tx.Exec(ctx, "SELECT set_config($1, $2, true)", s.Name, s.Value); err != nil {
+			return fmt.Errorf("setting %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// sessionSettings returns every parameter set in this session or
+// transaction, whether by applySettings or a SET statement, plus the given
+// settings, which include some pg_settings hides (role).
+func sessionSettings(ctx context.Context, tx pgx.Tx, applied []Setting) (map[string]string, error) {
+	rows, err := tx.Query(ctx, "SELECT name, current_setting(name) FROM pg_settings WHERE source = 'session'")
+	if err != nil {
+		return nil, fmt.Errorf("reading session settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("reading session settings: %w", err)
+		}
+		settings[name] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading session settings: %w", err)
+	}
+
+	for _, s := range applied {
+		if _, ok := settings[s.Name]; !ok {
+			settings[s.Name] = s.Value
+		}
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	return settings, nil
+}
@@ -0,0 +1,33 @@
+package plan
+
+import "testing"
+
+func TestParseSetting(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Setting
+	}{
+		{"work_mem=64MB", Setting{Name: "work_mem", Value: "64MB"}},
+		{"search_path=app,public", Setting{Name: "search_path", Value: "app,public"}},
+		{" enable_SeqScan = off ", Setting{Name: "enable_seqscan", Value: "off"}},
+		{"auto_explain.log_min_duration=0", Setting{Name: "auto_explain.log_min_duration", Value: "0"}},
+		{"application_name=", Setting{Name: "application_name", Value: ""}},
+		{"options=-c a=b", Setting{Name: "options", Value: "-c a=b"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSetting(tt.in)
+		if err != nil {
+			t.Errorf("ParseSetting(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSetting(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"work_mem", "=64MB", "work mem=64MB", "x; DROP TABLE t=1"} {
+		if _, err := ParseSetting(bad); err == nil {
+			t.Errorf("ParseSetting(%q) expected error", bad)
+		}
+	}
+}
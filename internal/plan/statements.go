@@ -0,0 +1,169 @@
+package plan
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	dollarTagRe     = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+	createTableAsRe = regexp.MustCompile(`(?is)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?(?:TABLE|MATERIALIZED\s+VIEW)\b.*\bAS\b`)
+)
+
+// SplitStatements splits a SQL script into its individual statements on
+// top-level semicolons. Semicolons inside string literals, quoted
+// identifiers, dollar-quoted bodies and comments don't split. Statements
+// consisting only of whitespace and comments are dropped, and the trailing
+// semicolon is not included.
+func SplitStatements(sql string) []string {
+	var statements []string
+	start := 0
+
+	emit := func(end int) {
+		if stmt := strings.TrimSpace(sql[start:end]); stripSQLComments(stmt) != "" {
+			statements = append(statements, stmt)
+		}
+	}
+
+	for i := 0; i < len(sql); {
+		switch c := sql[i]; {
+		case c == '\'':
+			i = skipQuoted(sql, i, '\'', isEscapeString(sql, i))
+		case c == '"':
+			i = skipQuoted(sql, i, '"', false)
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			i = skipLineComment(sql, i)
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			i = skipBlockComment(sql, i)
+		case c == '$':
+			i = skipDollarQuoted(sql, i)
+		case c == ';':
+			emit(i)
+			i++
+			start = i
+		default:
+			i++
+		}
+	}
+	emit(len(sql))
+
+	return statements
+}
+
+// isEscapeString reports whether the quote at i opens an E'...' string, in
+// which backslash escapes a quote.
+func isEscapeString(sql string, i int) bool {
+	if i == 0 || (sql[i-1] != 'E' && sql[i-1] != 'e') {
+		return false
+	}
+	return i == 1 || !isIdentChar(sql[i-2])
+}
+
+func skipQuoted(sql string, i int, quote byte, backslashEscapes bool) int {
+	for i++; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+func skipLineComment(sql string, i int) int {
+	if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+		return i + end + 1
+	}
+	return len(sql)
+}
+
+// skipBlockComment skips a /* ... */ comment. PostgreSQL block comments
+// nest, unlike C's.
+func skipBlockComment(sql string, i int) int {
+	depth := 0
+	for i < len(sql) {
+		switch {
+		case strings.HasPrefix(sql[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(sql[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(sql)
+}
+
+// skipDollarQuoted skips a $tag$ ... $tag$ body. A "$" that doesn't open a
+// dollar quote (e.g. the $1 parameter placeholder) is skipped on its own.
+func skipDollarQuoted(sql string, i int) int {
+	if i > 0 && isIdentChar(sql[i-1]) {
+		return i + 1
+	}
+	tag := dollarTagRe.FindString(sql[i:])
+	if tag == "" {
+		return i + 1
+	}
+	if end := strings.Index(sql[i+len(tag):], tag); end >= 0 {
+		return i + len(tag) + end + len(tag)
+	}
+	return len(sql)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// stripSQLComments returns stmt with leading comments and whitespace
+// removed, which is enough to find the statement's first keyword.
+func stripSQLComments(stmt string) string {
+	for {
+		stmt = strings.TrimSpace(stmt)
+		switch {
+		case strings.HasPrefix(stmt, "--"):
+			stmt = stmt[skipLineComment(stmt, 0):]
+		case strings.HasPrefix(stmt, "/*"):
+			stmt = stmt[skipBlockComment(stmt, 0):]
+		default:
+			return stmt
+		}
+	}
+}
+
+// firstKeyword returns the upper-cased first word of stmt, ignoring
+// leading comments and parentheses.
+func firstKeyword(stmt string) string {
+	stmt = strings.TrimLeft(stripSQLComments(stmt), "( \t\r\n")
+	end := strings.IndexFunc(stmt, func(r rune) bool {
+		return r >= 128 || !isIdentChar(byte(r))
+	})
+	if end < 0 {
+		end = len(stmt)
+	}
+	return strings.ToUpper(stmt[:end])
+}
+
+// isExplainable reports whether PostgreSQL accepts stmt as the target of
+// EXPLAIN. Other statements in a script (SET, CREATE TEMP TABLE, ...) are
+// run as-is so the statements after them see their effects.
+func isExplainable(stmt string) bool {
+	switch firstKeyword(stmt) {
+	case "SELECT", "WITH", "INSERT", "UPDATE", "DELETE", "MERGE", "VALUES", "TABLE", "EXECUTE", "DECLARE":
+		return true
+	case "CREATE":
+		return createTableAsRe.MatchString(stripSQLComments(stmt))
+	}
+	return false
+}
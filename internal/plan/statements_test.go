@@ -0,0 +1,103 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	cases := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "single without semicolon",
+			sql:  "SELECT 1",
+			want: []string{"SELECT 1"},
+		},
+		{
+			name: "several with trailing whitespace",
+			sql:  "SELECT 1;\n  SELECT 2;\n\n",
+			want: []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name: "semicolon in string literal",
+			sql:  "SELECT 'a;b'; SELECT 'it''s;'",
+			want: []string{"SELECT 'a;b'", "SELECT 'it''s;'"},
+		},
+		{
+			name: "escape string",
+			sql:  `SELECT E'a\';b'; SELECT 2`,
+			want: []string{`SELECT E'a\';b'`, "SELECT 2"},
+		},
+		{
+			name: "quoted identifier",
+			sql:  `SELECT "odd;name" FROM t; SELECT 2`,
+			want: []string{`SELECT "odd;name" FROM t`, "SELECT 2"},
+		},
+		{
+			name: "comments",
+			sql:  "-- first; still a comment\nSELECT 1 /* a; /* nested; */ b */; SELECT 2",
+			want: []string{"-- first; still a comment\nSELECT 1 /* a; /* nested; */ b */", "SELECT 2"},
+		},
+		{
+			name: "dollar quoted body",
+			sql:  "DO $fn$ BEGIN PERFORM 1; END $fn$; SELECT $$;$$",
+			want: []string{"DO $fn$ BEGIN PERFORM 1; END $fn$", "SELECT $$;$$"},
+		},
+		{
+			name: "parameter placeholders",
+			sql:  "SELECT * FROM t WHERE a = $1; SELECT $2",
+			want: []string{"SELECT * FROM t WHERE a = $1", "SELECT $2"},
+		},
+		{
+			name: "comment-only statements dropped",
+			sql:  "SELECT 1; -- trailing note\n; /* nothing */",
+			want: []string{"SELECT 1"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SplitStatements(tc.sql); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("SplitStatements(%q) = %q, want %q", tc.sql, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFirstKeyword(t *testing.T) {
+	cases := map[string]string{
+		"select 1":                       "SELECT",
+		"  -- note\n/* c */ WITH x AS (": "WITH",
+		"(SELECT 1) UNION (SELECT 2)":    "SELECT",
+		"":                               "",
+	}
+	for stmt, want := range cases {
+		if got := firstKeyword(stmt); got != want {
+			t.Errorf("firstKeyword(%q) = %q, want %q", stmt, got, want)
+		}
+	}
+}
+
+func TestIsExplainable(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT 1":                                true,
+		"WITH x AS (SELECT 1) SELECT * FROM x":    true,
+		"update t set a = 1":                      true,
+		"MERGE INTO t USING s ON true DO NOTHING": true,
+		"VALUES (1)":                              true,
+		"CREATE TEMP TABLE t AS SELECT 1":         true,
+		"CREATE MATERIALIZED VIEW v AS SELECT 1":  true,
+		"CREATE TEMP TABLE t (a int)":             false,
+		"SET work_mem = '64MB'":                   false,
+		"ANALYZE t":                               false,
+		"BEGIN":                                   false,
+	}
+	for stmt, want := range cases {
+		if got := isExplainable(stmt); got != want {
+			t.Errorf("isExplainable(%q) = %v, want %v", stmt, got, want)
+		}
+	}
+}
@@ -6,6 +6,8 @@ type PlanNode struct {
 	ParentRelationship string `json:"Parent Relationship,omitempty"`
 	Strategy           string `json:"Strategy,omitempty"`
 	PartialMode        string `json:"Partial Mode,omitempty"`
+	ParallelAware      bool   `json:"Parallel Aware,omitempty"`
+	Operation          string `json:"Operation,omitempty"`
 
 	// Estimates vs actuals
 	StartupCost       float64 `json:"Startup Cost"`
@@ -83,12 +85,49 @@ type PlanNode struct {
 	Plans []PlanNode `json:"Plans,omitempty"`
 
 	SubplanName string `json:"Subplan Name,omitempty"`
+
+	// Stats is set on the nodes of a plan aggregated from repeated runs:
+	// see Aggregate.
+	Stats *NodeStats `json:"-"`
 }
 
 // ExplainOutput represents the top-level EXPLAIN JSON output from PostgreSQL.
 type ExplainOutput struct {
-	Plan          PlanNode `json:"Plan"`
-	PlanningTime  float64  `json:"Planning Time,omitempty"`
-	ExecutionTime float64  `json:"Execution Time,omitempty"`
-	Triggers      []any    `json:"Triggers,omitempty"`
+	// QueryText is the statement the plan belongs to. PostgreSQL's own
+	// EXPLAIN output omits it; auto_explain includes it, and Execute fills
+	// it in for SQL input.
+	QueryText string `json:"Query Text,omitempty"`
+	// QueryParameters lists the bind parameter values the plan was made
+	// with, as "$1 = '42', $2 = NULL". auto_explain logs it when
+	// log_parameter_max_length allows; Execute fills it in when parameter
+	// values are given.
+	QueryParameters string   `json:"Query Parameters,omitempty"`
+	Plan            PlanNode `json:"Plan"`
+	PlanningTime    float64  `json:"Planning Time,omitempty"`
+	ExecutionTime   float64  `json:"Execution Time,omitempty"`
+	Triggers        []any    `json:"Triggers,omitempty"`
+
+	// Settings are the configuration parameters that differed from their
+	// defaults when the plan was made: EXPLAIN (SETTINGS) output, or for
+	// SQL input, everything set for the session by Execute.
+	Settings map[string]string `json:"Settings,omitempty"`
+
+	// Benchmark is set on a plan aggregated from repeated runs: see
+	// Aggregate.
+	Benchmark *Benchmark `json:"-"`
+
+	// FromSQL is set on plans Execute made from SQL input, on the database
+	// it connected to, as opposed to plans read from EXPLAIN output that
+	// may have come from anywhere.
+	FromSQL bool `json:"-"`
+}
+
+// Analyzed reports whether the plan was produced with EXPLAIN ANALYZE:
+// Planning/Execution Time are present, or - for auto_explain output, which
+// omits them - the root node carries an Actual Loops count. This is decided
+// at the query level rather than per node: a node's own Actual Loops is
+// legitimately 0 for a skipped CASE branch, excluded partition, etc. even
+// when the query was analyzed.
+func (o *ExplainOutput) Analyzed() bool {
+	return o.PlanningTime > 0 || o.ExecutionTime > 0 || o.Plan.ActualLoops > 0
 }
@@ -15,6 +15,15 @@ var configDirFunc = configDir
 type Profile struct {
 	Name    string `yaml:"name"`
 	ConnStr string `yaml:"conn_str"`
+
+	// AllowWrites lets EXPLAIN ANALYZE run data-modifying statements, DDL
+	// and procedure calls against this database.
+	AllowWrites bool `yaml:"allow_writes,omitempty"`
+	// ReadOnly runs every EXPLAIN in a READ ONLY transaction.
+	ReadOnly bool `yaml:"read_only,omitempty"`
+	// Settings are configuration parameters set before every EXPLAIN, such
+	// as work_mem or random_page_cost, to match production.
+	Settings map[string]string `yaml:"settings,omitempty"`
 }
 
 type Config struct {
@@ -23,21 +32,29 @@ type Config struct {
 }
 
 func Resolve(name string) (string, error) {
+	p, err := Get(name)
+	if err != nil {
+		return "", err
+	}
+	return p.ConnStr, nil
+}
+
+func Get(name string) (Profile, error) {
 	cfg, err := load()
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", fmt.Errorf("no profiles configured")
+			return Profile{}, fmt.Errorf("no profiles configured")
 		}
-		return "", err
+		return Profile{}, err
 	}
 
 	for _, p := range cfg.Profiles {
 		if p.Name == name {
-			return p.ConnStr, nil
+			return p, nil
 		}
 	}
 
-	return "", fmt.Errorf("profile %q not found", name)
+	return Profile{}, fmt.Errorf("profile %q not found", name)
 }
 
 func List() ([]Profile, error) {
@@ -94,22 +111,33 @@ func Remove(name string) error {
 }
 
 func ResolveConnStr(db, profileName string) (string, error) {
+	p, err := Lookup(db, profileName)
+	if err != nil {
+		return "", err
+	}
+	return p.ConnStr, nil
+}
+
+// Lookup returns the profile to connect with: an unnamed profile holding db
+// when it is set, else the named profile, else the default one. The zero
+// Profile means no connection is configured.
+func Lookup(db, profileName string) (Profile, error) {
 	if db != "" {
-		return db, nil
+		return Profile{ConnStr: db}, nil
 	}
 	if profileName != "" {
-		return Resolve(profileName)
+		return Get(profileName)
 	}
 
 	def, err := GetDefault()
 	if err != nil {
-		return "", err
+		return Profile{}, err
 	}
 	if def != "" {
-		return Resolve(def)
+		return Get(def)
 	}
 
-	return "", nil
+	return Profile{}, nil
 }
 
 func load() (*Config, error) {
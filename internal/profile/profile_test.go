@@ -1,6 +1,9 @@
 package profile
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
 func setupTestConfig(t *testing.T) func() {
 	t.Helper()
@@ -287,3 +290,67 @@ func TestList_EmptyConfig(t *testing.T) {
 		t.Errorf("expected nil profiles, got %v", profiles)
 	}
 }
+
+func TestLookup_SafetySettings(t *testing.T) {
+	cleanup := setupTestConfig(t)
+	defer cleanup()
+
+	path, err := configPath()
+	if err != nil {
+		t.Fatalf("configPath failed: %v", err)
+	}
+	config := `default: staging
+profiles:
+  - name: staging
+    conn_str: postgres://staging/db
+    allow_writes: true
+  - name: prod
+    conn_str: postgres://prod/db
+    read_only: true
+    settings:
+      work_mem: 64MB
+      jit: "off"
+`
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	p, err := Lookup("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name != "staging" || !p.AllowWrites || p.ReadOnly {
+		t.Errorf("default profile = %+v, want staging with allow_writes", p)
+	}
+
+	p, err = Lookup("", "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.AllowWrites || !p.ReadOnly {
+		t.Errorf("prod profile = %+v, want read_only only", p)
+	}
+	if p.Settings["work_mem"] != "64MB" || p.Settings["jit"] != "off" {
+		t.Errorf("prod settings = %v, want work_mem and jit", p.Settings)
+	}
+
+	// Re-adding a profile updates its connection string only.
+	if err := Add("staging", "postgres://staging2/db"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	p, err = Lookup("", "staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.ConnStr != "postgres://staging2/db" || !p.AllowWrites {
+		t.Errorf("after Add, staging = %+v, want new conn_str and allow_writes kept", p)
+	}
+
+	p, err = Lookup("postgres://direct/db", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.ConnStr != "postgres://direct/db" || p.AllowWrites || p.ReadOnly {
+		t.Errorf("--db profile = %+v, want conn_str only", p)
+	}
+}
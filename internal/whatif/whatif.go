@@ -0,0 +1,145 @@
+// Package whatif runs a SQL file under alternative planner settings, such as
+// enable_nestloop=off or a larger work_mem, and compares each variant's plans
+// with those of the unchanged settings, to tell a costing problem from a
+// plan that is simply the best there is.
+package whatif
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+// Variant is a named set of settings to run the SQL file under.
+type Variant struct {
+	Name     string
+	Settings []plan.Setting
+}
+
+// ParseVariant parses "[name:]setting=value[,setting=value]...", e.g.
+// "no-nestloop:enable_nestloop=off" or "work_mem=256MB,jit=off". A piece
+// without "=" continues the value before it, so search_path=app,public is
+// one setting. Without a name, the variant is named after its settings.
+func ParseVariant(s string) (Variant, error) {
+	var v Variant
+	spec := s
+	if name, rest, ok := strings.Cut(s, ":"); ok && !strings.Contains(name, "=") {
+		v.Name, spec = strings.TrimSpace(name), rest
+		if v.Name == "" {
+			return Variant{}, fmt.Errorf("invalid variant %q: empty name", s)
+		}
+	}
+
+	var pieces []string
+	for _, piece := range strings.Split(spec, ",") {
+		if len(pieces) > 0 && !strings.Contains(piece, "=") {
+			pieces[len(pieces)-1] += "," + piece
+			continue
+		}
+		pieces = append(pieces, piece)
+	}
+	for _, piece := range pieces {
+		setting, err := plan.ParseSetting(piece)
+		if err != nil {
+			return Variant{}, fmt.Errorf("variant %q: %w", s, err)
+		}
+		v.Settings = append(v.Settings, setting)
+	}
+
+	if v.Name == "" {
+		names := make([]string, len(v.Settings))
+		for i, setting := range v.Settings {
+			names[i] = setting.Name + "=" + setting.Value
+		}
+		v.Name = strings.Join(names, ",")
+	}
+	return v, nil
+}
+
+// Options says what to run, where, and under which variants.
+type Options struct {
+	File     string
+	DBConn   string
+	Exec     plan.ExecOptions
+	Variants []Variant
+}
+
+// Report is the outcome of Run.
+type Report struct {
+	File     string
+	Variants []VariantResult
+}
+
+// VariantResult compares a variant's plans with the baseline's.
+type VariantResult struct {
+	Name     string
+	Settings []plan.Setting
+
+	// ShapeChanged is true when the variant changed any statement's plan
+	// shape.
+	ShapeChanged bool
+	Statements   []StatementResult
+}
+
+// StatementResult compares one statement's plan under a variant (new) with
+// its baseline plan (old).
+type StatementResult struct {
+	Statement int
+	Query     string
+
+	// ShapeChanged is true when the planner chose a different plan under
+	// the variant: see plan.SameShape.
+	ShapeChanged bool
+	Comparison   comparator.ComparisonResult
+}
+
+// Run plans the SQL file with opts.Exec's settings, then once per variant
+// with the variant's settings on top, each in a transaction of its own, and
+// compares each variant's plans with the baseline's.
+func Run(ctx context.Context, opts Options, c *comparator.Comparator) (Report, error) {
+	if !strings.EqualFold(filepath.Ext(opts.File), ".sql") {
+		return Report{}, fmt.Errorf("%s is not a .sql file: what-if needs a query to run under each variant", opts.File)
+	}
+	if len(opts.Variants) == 0 {
+		return Report{}, fmt.Errorf("no variants to run")
+	}
+
+	baseline, err := plan.ResolveAll(ctx, opts.File, opts.DBConn, "baseline ", opts.Exec)
+	if err != nil {
+		return Report{}, fmt.Errorf("baseline: %w", err)
+	}
+
+	report := Report{File: opts.File}
+	for _, v := range opts.Variants {
+		exec := opts.Exec
+		exec.Settings = append(slices.Clone(opts.Exec.Settings), v.Settings...)
+		plans, err := plan.ResolveAll(ctx, opts.File, opts.DBConn, v.Name+" ", exec)
+		if err != nil {
+			return Report{}, fmt.Errorf("variant %s: %w", v.Name, err)
+		}
+		report.Variants = append(report.Variants, compareVariant(v, baseline, plans, c))
+	}
+	return report, nil
+}
+
+// compareVariant compares a variant's plans with the baseline's, statement
+// by statement.
+func compareVariant(v Variant, baseline, plans []plan.ExplainOutput, c *comparator.Comparator) VariantResult {
+	result := VariantResult{Name: v.Name, Settings: v.Settings}
+	for i := range min(len(baseline), len(plans)) {
+		stmt := StatementResult{
+			Statement:    i + 1,
+			Query:        plans[i].QueryText,
+			ShapeChanged: !plan.SameShape(&baseline[i].Plan, &plans[i].Plan),
+			Comparison:   c.Compare(baseline[i], plans[i]),
+		}
+		result.ShapeChanged = result.ShapeChanged || stmt.ShapeChanged
+		result.Statements = append(result.Statements, stmt)
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package whatif
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jacobarthurs/pgplan/internal/comparator"
+	"github.com/jacobarthurs/pgplan/internal/plan"
+)
+
+func TestParseVariant(t *testing.T) {
+	tests := []struct {
+		in       string
+		name     string
+		settings []plan.Setting
+	}{
+		{"enable_nestloop=off", "enable_nestloop=off", []plan.Setting{{Name: "enable_nestloop", Value: "off"}}},
+		{"big-mem:work_mem=256MB,hash_mem_multiplier=4", "big-mem", []plan.Setting{{Name: "work_mem", Value: "256MB"}, {Name: "hash_mem_multiplier", Value: "4"}}},
+		{"search_path=app,public,jit=off", "search_path=app,public,jit=off", []plan.Setting{{Name: "search_path", Value: "app,public"}, {Name: "jit", Value: "off"}}},
+		{"search_path=a:b", "search_path=a:b", []plan.Setting{{Name: "search_path", Value: "a:b"}}},
+	}
+	for _, tt := range tests {
+		v, err := ParseVariant(tt.in)
+		if err != nil {
+			t.Errorf("ParseVariant(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if v.Name != tt.name || !slices.Equal(v.Settings, tt.settings) {
+			t.Errorf("ParseVariant(%q) = %+v, want %q %+v", tt.in, v, tt.name, tt.settings)
+		}
+	}
+
+	for _, in := range []string{"", "name:", ":jit=off", "jit"} {
+		if _, err := ParseVariant(in); err == nil {
+			t.Errorf("ParseVariant(%q): expected an error", in)
+		}
+	}
+}
+
+func TestRun_RequiresSQLFile(t *testing.T) {
+	_, err := Run(context.Background(), Options{File: "plan.json", Variants: []Variant{{Name: "jit=off"}}}, &comparator.Comparator{})
+	if err == nil || !strings.Contains(err.Error(), "not a .sql file") {
+		t.Errorf("err = %v, want a .sql file error", err)
+	}
+}
+
+func TestCompareVariant(t *testing.T) {
+	nestLoop := plan.ExplainOutput{ExecutionTime: 100, Plan: plan.PlanNode{NodeType: "Nested Loop", TotalCost: 50, ActualLoops: 1, Plans: []plan.PlanNode{
+		{NodeType: "Seq Scan", RelationName: "a", TotalCost: 10, ActualLoops: 1},
+		{NodeType: "Index Scan", RelationName: "b", IndexName: "b_pkey", TotalCost: 10, ActualLoops: 1},
+	}}}
+	hashJoin := plan.ExplainOutput{ExecutionTime: 20, Plan: plan.PlanNode{NodeType: "Hash Join", TotalCost: 80, ActualLoops: 1, Plans: []plan.PlanNode{
+		{NodeType: "Seq Scan", RelationName: "a", TotalCost: 10, ActualLoops: 1},
+		{NodeType: "Hash", TotalCost: 10, ActualLoops: 1, Plans: []plan.PlanNode{
+			{NodeType: "Seq Scan", RelationName: "b", TotalCost: 10, ActualLoops: 1},
+		}},
+	}}}
+	c := &comparator.Comparator{Threshold: 5}
+
+	v := Variant{Name: "enable_nestloop=off", Settings: []plan.Setting{{Name: "enable_nestloop", Value: "off"}}}
+	result := compareVariant(v, []plan.ExplainOutput{nestLoop, nestLoop}, []plan.ExplainOutput{hashJoin, nestLoop}, c)
+
+	if !result.ShapeChanged || len(result.Statements) != 2 {
+		t.Fatalf("result = %+v, want a shape change over 2 statements", result)
+	}
+	first, second := result.Statements[0], result.Statements[1]
+	if !first.ShapeChanged || first.Comparison.Summary.TimeDir != comparator.Improved {
+		t.Errorf("statement 1 = shape changed %v, time %v; want a faster new plan", first.ShapeChanged, first.Comparison.Summary.TimeDir)
+	}
+	if second.ShapeChanged {
+		t.Errorf("statement 2 kept its plan, but ShapeChanged is true")
+	}
+}